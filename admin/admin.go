@@ -0,0 +1,182 @@
+// Package admin provides an HTTP handler for inspecting and mutating a doublejump hash,
+// an operational escape hatch for production rings.
+//
+// The endpoints are relative to where the handler is mounted:
+//
+//	GET    /nodes               list the objects
+//	POST   /nodes?node=X        add X
+//	DELETE /nodes?node=X        remove X
+//	POST   /drain?node=X        drain X, see doublejump.Hash.Drain
+//	DELETE /drain?node=X        undrain X
+//	POST   /shrink              remove the empty slots
+//	GET    /stats               show doublejump.Stats
+//
+// Responses are JSON. Objects are shown by fmt.Sprint and parsed from the node parameter
+// by Options.Parse.
+//
+// Service implements the same operations as the RingAdmin service defined in admin.proto,
+// for driving the hash remotely over gRPC or another RPC transport.
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gnat88/doublejump"
+)
+
+// Options configures the handler.
+type Options struct {
+	// Auth wraps the endpoints which mutate the hash, e.g. to check credentials.
+	// If Auth is nil, the mutating endpoints are disabled and respond 403 Forbidden,
+	// so that a forgotten middleware does not leave the ring open to everyone.
+	Auth func(next http.Handler) http.Handler
+
+	// ReadAuth wraps the read-only endpoints. If ReadAuth is nil, they are unprotected.
+	ReadAuth func(next http.Handler) http.Handler
+
+	// Parse converts the node parameter to an object of the hash. If Parse is nil, the
+	// parameter is used as a string.
+	Parse func(s string) (interface{}, error)
+}
+
+// Node is an object as listed by GET /nodes.
+type Node struct {
+	Node    string `json:"node"`
+	Drained bool   `json:"drained,omitempty"`
+}
+
+type handler struct {
+	hash  *doublejump.Hash
+	parse func(s string) (interface{}, error)
+}
+
+// Handler returns an http.Handler serving the endpoints of the package for h.
+func Handler(h *doublejump.Hash, opts Options) http.Handler {
+	s := &handler{hash: h, parse: opts.Parse}
+	if s.parse == nil {
+		s.parse = func(s string) (interface{}, error) { return s, nil }
+	}
+
+	read := func(f http.HandlerFunc) http.Handler {
+		if opts.ReadAuth == nil {
+			return f
+		}
+		return opts.ReadAuth(f)
+	}
+	write := func(f http.HandlerFunc) http.Handler {
+		if opts.Auth == nil {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				writeError(w, http.StatusForbidden, "mutation is disabled")
+			})
+		}
+		return opts.Auth(f)
+	}
+
+	routes := map[string]map[string]http.Handler{
+		"/nodes": {
+			http.MethodGet:    read(s.nodes),
+			http.MethodPost:   write(s.add),
+			http.MethodDelete: write(s.remove),
+		},
+		"/drain": {
+			http.MethodPost:   write(s.drain),
+			http.MethodDelete: write(s.undrain),
+		},
+		"/shrink": {
+			http.MethodPost: write(s.shrink),
+		},
+		"/stats": {
+			http.MethodGet: read(s.stats),
+		},
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var methods map[string]http.Handler
+		for path, m := range routes {
+			if strings.HasSuffix(r.URL.Path, path) {
+				methods = m
+				break
+			}
+		}
+		if methods == nil {
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+
+		next, ok := methods[r.Method]
+		if !ok {
+			allowed := make([]string, 0, len(methods))
+			for m := range methods {
+				allowed = append(allowed, m)
+			}
+			sort.Strings(allowed)
+			w.Header().Set("Allow", strings.Join(allowed, ", "))
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (this *handler) nodes(w http.ResponseWriter, r *http.Request) {
+	objs := this.hash.Nodes()
+	a := make([]Node, len(objs))
+	for i, obj := range objs {
+		a[i] = Node{Node: fmt.Sprint(obj), Drained: this.hash.Drained(obj)}
+	}
+	writeJSON(w, http.StatusOK, a)
+}
+
+func (this *handler) stats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, this.hash.Stats())
+}
+
+func (this *handler) add(w http.ResponseWriter, r *http.Request) {
+	this.mutate(w, r, this.hash.Add)
+}
+
+func (this *handler) remove(w http.ResponseWriter, r *http.Request) {
+	this.mutate(w, r, this.hash.Remove)
+}
+
+func (this *handler) drain(w http.ResponseWriter, r *http.Request) {
+	this.mutate(w, r, this.hash.Drain)
+}
+
+func (this *handler) undrain(w http.ResponseWriter, r *http.Request) {
+	this.mutate(w, r, this.hash.Undrain)
+}
+
+func (this *handler) shrink(w http.ResponseWriter, r *http.Request) {
+	this.hash.Shrink()
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+// 解析node参数后执行操作，返回操作是否改变了哈希
+func (this *handler) mutate(w http.ResponseWriter, r *http.Request, f func(obj interface{}) bool) {
+	s := r.URL.Query().Get("node")
+	if s == "" {
+		writeError(w, http.StatusBadRequest, "missing node")
+		return
+	}
+	obj, err := this.parse(s)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": f(obj)})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]string{"error": msg})
+}
@@ -0,0 +1,122 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gnat88/doublejump"
+)
+
+func do(t *testing.T, h http.Handler, method, url string, v interface{}) int {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, url, nil))
+	if v != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatalf("%s %s: %v", method, url, err)
+		}
+	}
+	return w.Code
+}
+
+func TestHandler(t *testing.T) {
+	hash := doublejump.NewHash()
+	hash.Add("a")
+	hash.Add("b")
+
+	auth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Token") != "secret" && r.URL.Query().Get("token") != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	h := http.StripPrefix("/admin", Handler(hash, Options{Auth: auth}))
+
+	var nodes []Node
+	if code := do(t, h, "GET", "/admin/nodes", &nodes); code != http.StatusOK {
+		t.Fatalf("unexpected code: %d", code)
+	}
+	if len(nodes) != 2 || nodes[0].Node != "a" || nodes[1].Node != "b" {
+		t.Fatalf("unexpected nodes: %v", nodes)
+	}
+
+	if code := do(t, h, "POST", "/admin/nodes?node=c", nil); code != http.StatusUnauthorized {
+		t.Fatalf("the mutation should be protected. code: %d", code)
+	}
+
+	var res map[string]bool
+	if code := do(t, h, "POST", "/admin/nodes?node=c&token=secret", &res); code != http.StatusOK || !res["ok"] {
+		t.Fatalf("POST /nodes failed. code: %d", code)
+	}
+	if !hash.Contains("c") {
+		t.Fatal("c should be added")
+	}
+	if do(t, h, "POST", "/admin/nodes?node=c&token=secret", &res); res["ok"] {
+		t.Fatal("adding c again should report false")
+	}
+	if code := do(t, h, "POST", "/admin/nodes?token=secret", nil); code != http.StatusBadRequest {
+		t.Fatalf("a missing node should be rejected. code: %d", code)
+	}
+
+	do(t, h, "POST", "/admin/drain?node=a&token=secret", &res)
+	if !res["ok"] || !hash.Drained("a") {
+		t.Fatal("a should be drained")
+	}
+	do(t, h, "GET", "/admin/nodes", &nodes)
+	if !nodes[0].Drained {
+		t.Fatal("GET /nodes should show the drained state")
+	}
+	do(t, h, "DELETE", "/admin/drain?node=a&token=secret", &res)
+	if hash.Drained("a") {
+		t.Fatal("a should be undrained")
+	}
+
+	do(t, h, "DELETE", "/admin/nodes?node=b&token=secret", &res)
+	if !res["ok"] || hash.Contains("b") {
+		t.Fatal("b should be removed")
+	}
+	var stats doublejump.Stats
+	do(t, h, "GET", "/admin/stats", &stats)
+	if stats.Len != 2 || stats.EmptySlots != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	do(t, h, "POST", "/admin/shrink?token=secret", &res)
+	if hash.EmptySlots() != 0 {
+		t.Fatal("hash.EmptySlots() != 0")
+	}
+
+	if code := do(t, h, "PUT", "/admin/nodes", nil); code != http.StatusMethodNotAllowed {
+		t.Fatalf("unexpected code: %d", code)
+	}
+	if code := do(t, h, "GET", "/admin/unknown", nil); code != http.StatusNotFound {
+		t.Fatalf("unexpected code: %d", code)
+	}
+}
+
+func TestHandlerReadOnly(t *testing.T) {
+	hash := doublejump.NewHash()
+	h := Handler(hash, Options{Parse: func(s string) (interface{}, error) { return strconv.Atoi(s) }})
+	if code := do(t, h, "POST", "/nodes?node=1", nil); code != http.StatusForbidden {
+		t.Fatalf("the mutation should be disabled without Auth. code: %d", code)
+	}
+	if code := do(t, h, "GET", "/nodes", nil); code != http.StatusOK {
+		t.Fatalf("unexpected code: %d", code)
+	}
+
+	h = Handler(hash, Options{
+		Auth:  func(next http.Handler) http.Handler { return next },
+		Parse: func(s string) (interface{}, error) { return strconv.Atoi(s) },
+	})
+	do(t, h, "POST", "/nodes?node=1", nil)
+	if !hash.Contains(1) {
+		t.Fatal("the node should be parsed by Parse")
+	}
+	if code := do(t, h, "POST", "/nodes?node=x", nil); code != http.StatusBadRequest {
+		t.Fatalf("a broken node should be rejected. code: %d", code)
+	}
+}
@@ -0,0 +1,190 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gnat88/doublejump"
+)
+
+// ErrSlowSubscriber ends a Subscribe stream whose client does not keep up with the changes.
+var ErrSlowSubscriber = errors.New("admin: subscriber is too slow")
+
+// 订阅者最多缓存的事件数量，超过后结束订阅
+const subscribeBuffer = 256
+
+// The messages of the RingAdmin service defined in admin.proto.
+type (
+	ListRequest struct{}
+
+	ListResponse struct {
+		Nodes      []string
+		Generation uint64
+	}
+
+	NodeRequest struct {
+		Node string
+	}
+
+	SetRequest struct {
+		Nodes []string
+		// ExpectedGeneration, if not nil, makes Set fail with doublejump.ErrGenerationMismatch
+		// unless the hash is still at that generation.
+		ExpectedGeneration *uint64
+	}
+
+	MutateResponse struct {
+		Changed    bool
+		Generation uint64
+	}
+
+	StatsRequest struct{}
+
+	StatsResponse struct {
+		Len        int64
+		LooseLen   int64
+		EmptySlots int64
+		Generation uint64
+	}
+
+	SubscribeRequest struct{}
+
+	ChangeEvent struct {
+		Type       string
+		Node       string
+		Generation uint64
+	}
+)
+
+// Service implements the RingAdmin service defined in admin.proto, independently of the
+// transport, so that a central controller can drive hashes embedded in many processes.
+// Objects are shown by fmt.Sprint and parsed by Options.Parse, like Handler does.
+// Authentication is left to the transport, e.g. a gRPC interceptor.
+type Service struct {
+	hash  *doublejump.Hash
+	parse func(s string) (interface{}, error)
+}
+
+// NewService creates a service managing h. Only Options.Parse is used.
+func NewService(h *doublejump.Hash, opts Options) *Service {
+	s := &Service{hash: h, parse: opts.Parse}
+	if s.parse == nil {
+		s.parse = func(s string) (interface{}, error) { return s, nil }
+	}
+	return s
+}
+
+// List lists the objects in their slot order.
+func (this *Service) List(ctx context.Context, req *ListRequest) (*ListResponse, error) {
+	gen := this.hash.Generation()
+	objs := this.hash.Nodes()
+	res := &ListResponse{Nodes: make([]string, len(objs)), Generation: gen}
+	for i, obj := range objs {
+		res.Nodes[i] = fmt.Sprint(obj)
+	}
+	return res, nil
+}
+
+// Add adds an object.
+func (this *Service) Add(ctx context.Context, req *NodeRequest) (*MutateResponse, error) {
+	return this.mutate(req, this.hash.Add)
+}
+
+// Remove removes an object.
+func (this *Service) Remove(ctx context.Context, req *NodeRequest) (*MutateResponse, error) {
+	return this.mutate(req, this.hash.Remove)
+}
+
+func (this *Service) mutate(req *NodeRequest, f func(obj interface{}) bool) (*MutateResponse, error) {
+	if req.Node == "" {
+		return nil, errors.New("admin: missing node")
+	}
+	obj, err := this.parse(req.Node)
+	if err != nil {
+		return nil, err
+	}
+	changed := f(obj)
+	return &MutateResponse{Changed: changed, Generation: this.hash.Generation()}, nil
+}
+
+// Set reconciles the hash to contain exactly the given objects, see doublejump.Hash.Set.
+func (this *Service) Set(ctx context.Context, req *SetRequest) (*MutateResponse, error) {
+	nodes := make([]interface{}, len(req.Nodes))
+	for i, s := range req.Nodes {
+		obj, err := this.parse(s)
+		if err != nil {
+			return nil, err
+		}
+		nodes[i] = obj
+	}
+
+	before := this.hash.Generation()
+	if req.ExpectedGeneration != nil {
+		before = *req.ExpectedGeneration
+		if err := this.hash.CompareAndSet(before, nodes); err != nil {
+			return nil, err
+		}
+	} else {
+		this.hash.Set(nodes)
+	}
+	gen := this.hash.Generation()
+	return &MutateResponse{Changed: gen != before, Generation: gen}, nil
+}
+
+// Stats shows the state of the hash.
+func (this *Service) Stats(ctx context.Context, req *StatsRequest) (*StatsResponse, error) {
+	s := this.hash.Stats()
+	return &StatsResponse{
+		Len:        int64(s.Len),
+		LooseLen:   int64(s.LooseLen),
+		EmptySlots: int64(s.EmptySlots),
+		Generation: s.Generation,
+	}, nil
+}
+
+// Subscribe calls send for every change of the hash until ctx is done or send fails, which
+// is the shape of a server-streaming RPC. It returns ErrSlowSubscriber if the changes pile
+// up faster than send delivers them.
+func (this *Service) Subscribe(ctx context.Context, req *SubscribeRequest, send func(ev *ChangeEvent) error) error {
+	// 事件在哈希加锁时同步发出，只能放入缓冲区，不能在回调中调用send
+	ch := make(chan *ChangeEvent, subscribeBuffer)
+	overflow := make(chan struct{})
+	closed := false
+	cancel := this.hash.Subscribe(func(ev doublejump.Event) {
+		if closed {
+			return
+		}
+		e := &ChangeEvent{Type: ev.Type.String(), Generation: ev.Generation}
+		if ev.Node != nil {
+			e.Node = fmt.Sprint(ev.Node)
+		}
+		select {
+		case ch <- e:
+		default:
+			closed = true
+			close(overflow)
+		}
+	})
+	defer cancel()
+
+	for {
+		// 优先检查是否已经溢出，不再发送过时的事件
+		select {
+		case <-overflow:
+			return ErrSlowSubscriber
+		default:
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case ev := <-ch:
+			if err := send(ev); err != nil {
+				return err
+			}
+		case <-overflow:
+			return ErrSlowSubscriber
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gnat88/doublejump"
+)
+
+func TestService(t *testing.T) {
+	ctx := context.Background()
+	hash := doublejump.NewHash()
+	s := NewService(hash, Options{})
+
+	if res, err := s.Add(ctx, &NodeRequest{Node: "a"}); err != nil || !res.Changed {
+		t.Fatal("s.Add(a) failed")
+	}
+	if res, _ := s.Add(ctx, &NodeRequest{Node: "a"}); res.Changed {
+		t.Fatal("adding a again should not change the hash")
+	}
+	if _, err := s.Add(ctx, &NodeRequest{}); err == nil {
+		t.Fatal("a missing node should be rejected")
+	}
+
+	res, err := s.Set(ctx, &SetRequest{Nodes: []string{"a", "b", "c"}})
+	if err != nil || !res.Changed {
+		t.Fatal("s.Set failed")
+	}
+	list, _ := s.List(ctx, &ListRequest{})
+	if !reflect.DeepEqual(list.Nodes, []string{"a", "b", "c"}) || list.Generation != res.Generation {
+		t.Fatalf("unexpected list: %+v", list)
+	}
+
+	stale := res.Generation - 1
+	if _, err := s.Set(ctx, &SetRequest{Nodes: []string{"a"}, ExpectedGeneration: &stale}); err != doublejump.ErrGenerationMismatch {
+		t.Fatal("a stale generation should be rejected")
+	}
+	if _, err := s.Set(ctx, &SetRequest{Nodes: []string{"a", "b"}, ExpectedGeneration: &res.Generation}); err != nil {
+		t.Fatal(err)
+	}
+
+	if res, _ := s.Remove(ctx, &NodeRequest{Node: "b"}); !res.Changed {
+		t.Fatal("s.Remove(b) failed")
+	}
+	stats, _ := s.Stats(ctx, &StatsRequest{})
+	if stats.Len != 1 || stats.Generation != hash.Generation() {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	ints := NewService(doublejump.NewHash(), Options{Parse: func(s string) (interface{}, error) { return strconv.Atoi(s) }})
+	if _, err := ints.Set(ctx, &SetRequest{Nodes: []string{"1", "x"}}); err == nil {
+		t.Fatal("a broken node should be rejected")
+	}
+}
+
+func TestService_Subscribe(t *testing.T) {
+	hash := doublejump.NewHash()
+	s := NewService(hash, Options{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan *ChangeEvent, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Subscribe(ctx, &SubscribeRequest{}, func(ev *ChangeEvent) error {
+			events <- ev
+			return nil
+		})
+	}()
+
+	// 等待订阅生效
+	deadline := time.Now().Add(5 * time.Second)
+	for i := 0; ; i++ {
+		hash.Add(i)
+		select {
+		case <-events:
+		case <-time.After(time.Millisecond):
+			if time.Now().After(deadline) {
+				t.Fatal("the subscription does not start")
+			}
+			continue
+		}
+		break
+	}
+
+	hash.Remove(0)
+	ev := <-events
+	if ev.Type != "remove" || ev.Node != "0" || ev.Generation != hash.Generation() {
+		t.Fatalf("unexpected event: %+v", ev)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// send失败时结束订阅
+	e := errors.New("e")
+	go func() {
+		done <- s.Subscribe(context.Background(), &SubscribeRequest{}, func(ev *ChangeEvent) error { return e })
+	}()
+	if err := waitDone(t, hash, done); err != e {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// send阻塞时事件堆积，结束订阅
+	block := make(chan struct{})
+	started := make(chan struct{}, 1)
+	go func() {
+		done <- s.Subscribe(context.Background(), &SubscribeRequest{}, func(ev *ChangeEvent) error {
+			select {
+			case started <- struct{}{}:
+			default:
+			}
+			<-block
+			return nil
+		})
+	}()
+	for i := 2000; len(started) == 0; i++ {
+		hash.Add(i)
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; i < 2*subscribeBuffer; i++ {
+		hash.Add(3000 + i)
+	}
+	close(block)
+	if err := <-done; err != ErrSlowSubscriber {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// 不断修改哈希直到订阅结束
+func waitDone(t *testing.T, hash *doublejump.Hash, done chan error) error {
+	deadline := time.Now().Add(5 * time.Second)
+	for i := 1000; ; i++ {
+		if !hash.Add(i) {
+			hash.Remove(i)
+		}
+		select {
+		case err := <-done:
+			return err
+		default:
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the subscription does not end")
+		}
+		if i%100 == 0 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// Package analysis runs the same membership trace through several consistent hashing
+// algorithms and reports their balance, disruption, memory and lookup latency, so that
+// teams can choose an algorithm with data instead of folklore:
+//
+//	reports := analysis.Run(analysis.RandomTrace(100, 50, rand.New(rand.NewSource(1))), 100000, analysis.Backends()...)
+//	analysis.WriteTable(os.Stdout, reports)
+//
+// The backends are doublejump itself, Maglev, rendezvous hashing and ketama. They are meant
+// for comparison, not production: every backend other than doublejump rebuilds its state
+// or scans every node the simple way.
+package analysis
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gnat88/doublejump"
+)
+
+// Backend is a consistent hashing algorithm under analysis.
+type Backend interface {
+	Name() string
+	Add(node string)
+	Remove(node string)
+	// Get returns the node for the key, "" if there is none.
+	Get(key uint64) string
+	// Footprint estimates the number of bytes held by the backend.
+	Footprint() int64
+}
+
+// Backends returns all backends with their default settings.
+func Backends() []Backend {
+	return []Backend{DoubleJump(), Maglev(0), Rendezvous(), Ketama()}
+}
+
+// Op is a membership change of a trace.
+type Op struct {
+	// Remove tells whether the node is removed rather than added.
+	Remove bool
+	Node   string
+}
+
+// Trace is a sequence of membership changes.
+type Trace []Op
+
+// RandomTrace returns a trace adding initial nodes, followed by churn random changes, each
+// adding a new node or removing an existing one with the same probability.
+func RandomTrace(initial, churn int, r *rand.Rand) Trace {
+	var t Trace
+	var live []string
+	next := 0
+	add := func() {
+		name := fmt.Sprintf("node-%d", next)
+		next++
+		live = append(live, name)
+		t = append(t, Op{Node: name})
+	}
+
+	for i := 0; i < initial; i++ {
+		add()
+	}
+	for i := 0; i < churn; i++ {
+		if len(live) < 2 || r.Intn(2) == 0 {
+			add()
+			continue
+		}
+		j := r.Intn(len(live))
+		t = append(t, Op{Remove: true, Node: live[j]})
+		live = append(live[:j], live[j+1:]...)
+	}
+	return t
+}
+
+// Report is the result of a backend.
+type Report struct {
+	Backend string
+	// Balance is the load of the most loaded node divided by the mean load, after the
+	// trace. 1 is a perfect balance.
+	Balance float64
+	// Moved is the fraction of keys moved over the changes of the trace after the initial
+	// additions, summed up, and Minimal the fraction a perfect algorithm would move.
+	Moved   float64
+	Minimal float64
+	// Memory is the footprint of the backend after the trace.
+	Memory int64
+	// Lookup is the mean latency of a lookup after the trace.
+	Lookup time.Duration
+}
+
+// Excess returns Moved divided by Minimal, i.e. how many times more keys the backend moves
+// than necessary. It is 1 for a perfectly consistent algorithm.
+func (this Report) Excess() float64 {
+	if this.Minimal == 0 {
+		return 0
+	}
+	return this.Moved / this.Minimal
+}
+
+// Run applies the trace to each backend, sampling the given number of keys after every
+// change to measure how many keys move, and returns a report for each backend. The
+// disruption is only measured from the first removal on, when the initial deployment is
+// considered complete.
+func Run(trace Trace, keys int, backends ...Backend) []Report {
+	sample := make([]uint64, keys)
+	for i := range sample {
+		sample[i] = doublejump.SplitMix64(uint64(i))
+	}
+	start := len(trace)
+	for i, op := range trace {
+		if op.Remove {
+			start = i
+			break
+		}
+	}
+
+	reports := make([]Report, 0, len(backends))
+	for _, b := range backends {
+		reports = append(reports, run(b, trace, start, sample))
+	}
+	return reports
+}
+
+func run(b Backend, trace Trace, start int, sample []uint64) Report {
+	r := Report{Backend: b.Name()}
+	owners := make([]string, len(sample))
+	live := make(map[string]bool)
+
+	for i, op := range trace {
+		// 节点数量变化时理想情况下移动的比例
+		n := len(live)
+		minimal := 1 / float64(n+1)
+		if op.Remove {
+			if !live[op.Node] {
+				continue
+			}
+			delete(live, op.Node)
+			b.Remove(op.Node)
+			minimal = 1 / float64(n)
+		} else {
+			if live[op.Node] {
+				continue
+			}
+			live[op.Node] = true
+			b.Add(op.Node)
+		}
+
+		moved := 0
+		for j, key := range sample {
+			owner := b.Get(key)
+			if owner != owners[j] {
+				moved++
+				owners[j] = owner
+			}
+		}
+		if i >= start {
+			r.Moved += float64(moved) / float64(len(sample))
+			r.Minimal += minimal
+		}
+	}
+
+	load := make(map[string]int)
+	for _, owner := range owners {
+		load[owner]++
+	}
+	max := 0
+	for _, n := range load {
+		if n > max {
+			max = n
+		}
+	}
+	if len(live) > 0 {
+		r.Balance = float64(max) / (float64(len(sample)) / float64(len(live)))
+	}
+
+	r.Memory = b.Footprint()
+	t := time.Now()
+	for _, key := range sample {
+		b.Get(key)
+	}
+	if len(sample) > 0 {
+		r.Lookup = time.Since(t) / time.Duration(len(sample))
+	}
+	return r
+}
+
+// WriteTable writes the reports to w as an aligned text table.
+func WriteTable(w io.Writer, reports []Report) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "backend\tbalance\tmoved\tminimal\texcess\tmemory\tlookup\t")
+	for _, r := range reports {
+		fmt.Fprintf(tw, "%s\t%.3f\t%.3f\t%.3f\t%.2f\t%d\t%v\t\n",
+			r.Backend, r.Balance, r.Moved, r.Minimal, r.Excess(), r.Memory, r.Lookup)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,112 @@
+package analysis
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestRandomTrace(t *testing.T) {
+	trace := RandomTrace(10, 100, rand.New(rand.NewSource(1)))
+	if len(trace) != 110 {
+		t.Fatal("len(trace) != 110")
+	}
+
+	live := make(map[string]bool)
+	for _, op := range trace {
+		if op.Remove {
+			if !live[op.Node] {
+				t.Fatal("removed a node not live")
+			}
+			delete(live, op.Node)
+		} else {
+			if live[op.Node] {
+				t.Fatal("added a live node")
+			}
+			live[op.Node] = true
+		}
+		if len(live) == 0 {
+			t.Fatal("len(live) == 0")
+		}
+	}
+}
+
+func TestBackends(t *testing.T) {
+	for _, b := range Backends() {
+		if b.Get(1) != "" {
+			t.Fatalf("%s: b.Get(1) != \"\"", b.Name())
+		}
+		b.Add("a")
+		b.Add("b")
+		b.Add("c")
+		for key := uint64(0); key < 100; key++ {
+			if g := b.Get(key); g != "a" && g != "b" && g != "c" {
+				t.Fatalf("%s: unexpected node: %q", b.Name(), g)
+			}
+		}
+		b.Remove("b")
+		for key := uint64(0); key < 100; key++ {
+			if g := b.Get(key); g != "a" && g != "c" {
+				t.Fatalf("%s: unexpected node: %q", b.Name(), g)
+			}
+		}
+		if b.Footprint() <= 0 {
+			t.Fatalf("%s: b.Footprint() <= 0", b.Name())
+		}
+	}
+}
+
+func TestMaglev_Size(t *testing.T) {
+	// 不是质数的大小向上取到下一个质数，否则排列可能无法填满表
+	for _, v := range []struct{ size, want int }{{1, 2}, {4, 5}, {100, 101}, {65537, 65537}, {0, 65537}} {
+		b := Maglev(v.size).(*maglev)
+		if b.size != v.want {
+			t.Fatalf("Maglev(%d).size != %d. size: %d", v.size, v.want, b.size)
+		}
+		for i := 0; i < 10; i++ {
+			b.Add(fmt.Sprintf("node%d", i))
+		}
+		for _, n := range b.table {
+			if n < 0 {
+				t.Fatalf("the table of size %d is not filled", b.size)
+			}
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	trace := RandomTrace(20, 20, rand.New(rand.NewSource(1)))
+	reports := Run(trace, 10000, Backends()...)
+	if len(reports) != 4 {
+		t.Fatal("len(reports) != 4")
+	}
+
+	for _, r := range reports {
+		if r.Minimal <= 0 || r.Moved <= 0 {
+			t.Fatalf("%s: no disruption measured", r.Backend)
+		}
+		if r.Balance < 1 {
+			t.Fatalf("%s: r.Balance < 1", r.Backend)
+		}
+		if r.Memory <= 0 {
+			t.Fatalf("%s: r.Memory <= 0", r.Backend)
+		}
+	}
+
+	// doublejump与rendezvous只移动必要的键
+	for _, i := range []int{0, 2} {
+		if e := reports[i].Excess(); e > 1.1 {
+			t.Fatalf("%s: excess: %f", reports[i].Backend, e)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTable(&buf, reports); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(buf.String(), "\n") != 5 {
+		t.Fatalf("unexpected table: %s", buf.String())
+	}
+}
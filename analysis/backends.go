@@ -0,0 +1,234 @@
+package analysis
+
+import (
+	"sort"
+	"unsafe"
+
+	"github.com/gnat88/doublejump"
+	"github.com/gnat88/doublejump/ketama"
+)
+
+type doubleJump struct {
+	hash *doublejump.Hash
+}
+
+// DoubleJump returns the backend of a doublejump hash without lock.
+func DoubleJump() Backend {
+	return &doubleJump{hash: doublejump.NewHashWithoutLock()}
+}
+
+func (this *doubleJump) Name() string       { return "doublejump" }
+func (this *doubleJump) Add(node string)    { this.hash.Add(node) }
+func (this *doubleJump) Remove(node string) { this.hash.Remove(node) }
+func (this *doubleJump) Footprint() int64   { return this.hash.MemoryFootprint() }
+
+func (this *doubleJump) Get(key uint64) string {
+	s, _ := this.hash.Get(key).(string)
+	return s
+}
+
+// 表的大小必须是质数
+const maglevTableSize = 65537
+
+type maglev struct {
+	size  int
+	nodes []string
+	table []int
+}
+
+// Maglev returns the backend of Maglev hashing, as described in "Maglev: A Fast and
+// Reliable Software Network Load Balancer", with a lookup table of the given prime size.
+// A size which is not prime is rounded up to the next prime, since the permutations only
+// fill the table if its size is prime. size <= 0 means 65537. The table is rebuilt on
+// every change.
+func Maglev(size int) Backend {
+	if size <= 0 {
+		size = maglevTableSize
+	}
+	for !isPrime(size) {
+		size++
+	}
+	return &maglev{size: size}
+}
+
+func isPrime(n int) bool {
+	if n < 2 {
+		return false
+	}
+	for i := 2; i*i <= n; i++ {
+		if n%i == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (this *maglev) Name() string { return "maglev" }
+
+func (this *maglev) Add(node string) {
+	this.nodes = append(this.nodes, node)
+	this.build()
+}
+
+func (this *maglev) Remove(node string) {
+	for i, n := range this.nodes {
+		if n == node {
+			this.nodes = append(this.nodes[:i], this.nodes[i+1:]...)
+			this.build()
+			return
+		}
+	}
+}
+
+// 节点按名字排序后依次按各自的排列填充表
+func (this *maglev) build() {
+	names := append([]string(nil), this.nodes...)
+	sort.Strings(names)
+	this.nodes = names
+	this.table = nil
+	if len(names) == 0 {
+		return
+	}
+
+	m := uint64(this.size)
+	offsets := make([]uint64, len(names))
+	skips := make([]uint64, len(names))
+	nexts := make([]uint64, len(names))
+	for i, name := range names {
+		offsets[i] = doublejump.KeyString(name, "offset") % m
+		skips[i] = doublejump.KeyString(name, "skip")%(m-1) + 1
+	}
+
+	this.table = make([]int, this.size)
+	for i := range this.table {
+		this.table[i] = -1
+	}
+	for filled := 0; ; {
+		for i := range names {
+			c := (offsets[i] + nexts[i]*skips[i]) % m
+			for this.table[c] >= 0 {
+				nexts[i]++
+				c = (offsets[i] + nexts[i]*skips[i]) % m
+			}
+			this.table[c] = i
+			nexts[i]++
+			if filled++; filled == this.size {
+				return
+			}
+		}
+	}
+}
+
+func (this *maglev) Get(key uint64) string {
+	if len(this.table) == 0 {
+		return ""
+	}
+	return this.nodes[this.table[key%uint64(this.size)]]
+}
+
+func (this *maglev) Footprint() int64 {
+	return int64(cap(this.table))*int64(unsafe.Sizeof(int(0))) + stringsFootprint(this.nodes)
+}
+
+type rendezvous struct {
+	nodes  []string
+	hashes []uint64
+}
+
+// Rendezvous returns the backend of rendezvous (highest random weight) hashing, which
+// scans all nodes on every lookup.
+func Rendezvous() Backend {
+	return &rendezvous{}
+}
+
+func (this *rendezvous) Name() string { return "rendezvous" }
+
+func (this *rendezvous) Add(node string) {
+	this.nodes = append(this.nodes, node)
+	this.hashes = append(this.hashes, doublejump.KeyString(node))
+}
+
+func (this *rendezvous) Remove(node string) {
+	for i, n := range this.nodes {
+		if n == node {
+			this.nodes = append(this.nodes[:i], this.nodes[i+1:]...)
+			this.hashes = append(this.hashes[:i], this.hashes[i+1:]...)
+			return
+		}
+	}
+}
+
+func (this *rendezvous) Get(key uint64) string {
+	best, max := -1, uint64(0)
+	for i, h := range this.hashes {
+		if score := doublejump.SplitMix64(key ^ h); best < 0 || score > max {
+			best, max = i, score
+		}
+	}
+	if best < 0 {
+		return ""
+	}
+	return this.nodes[best]
+}
+
+func (this *rendezvous) Footprint() int64 {
+	return int64(cap(this.hashes))*8 + stringsFootprint(this.nodes)
+}
+
+type ketamaBackend struct {
+	nodes     []string
+	continuum *ketama.Continuum
+}
+
+// Ketama returns the backend of the ketama continuum of twemproxy, with 160 points per
+// node. The keys are mapped to the continuum by their high 32 bits, and the continuum is
+// rebuilt on every change.
+func Ketama() Backend {
+	return &ketamaBackend{continuum: ketama.New(nil)}
+}
+
+func (this *ketamaBackend) Name() string { return "ketama" }
+
+func (this *ketamaBackend) Add(node string) {
+	this.nodes = append(this.nodes, node)
+	this.build()
+}
+
+func (this *ketamaBackend) Remove(node string) {
+	for i, n := range this.nodes {
+		if n == node {
+			this.nodes = append(this.nodes[:i], this.nodes[i+1:]...)
+			this.build()
+			return
+		}
+	}
+}
+
+func (this *ketamaBackend) build() {
+	servers := make([]ketama.Server, len(this.nodes))
+	for i, n := range this.nodes {
+		servers[i] = ketama.Server{Name: n, Weight: 1}
+	}
+	this.continuum = ketama.New(servers)
+}
+
+func (this *ketamaBackend) Get(key uint64) string {
+	i := this.continuum.Dispatch(uint32(key >> 32))
+	if i < 0 {
+		return ""
+	}
+	return this.nodes[i]
+}
+
+// 每个点包含32位的值和服务器的序号
+func (this *ketamaBackend) Footprint() int64 {
+	return int64(this.continuum.Len())*16 + stringsFootprint(this.nodes)
+}
+
+func stringsFootprint(a []string) int64 {
+	n := int64(cap(a)) * int64(unsafe.Sizeof(""))
+	for _, s := range a {
+		n += int64(len(s))
+	}
+	return n
+}
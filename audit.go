@@ -0,0 +1,40 @@
+package doublejump
+
+import "math/rand"
+
+// Assignment is a key together with the object selected for it.
+type Assignment struct {
+	Key  uint64
+	Node interface{}
+}
+
+// SampleOwnership returns the objects selected for n keys drawn uniformly from r, for audit
+// pipelines which cross-check the decisions of the hash against where data actually lives.
+// The keys are selected like Get does, against one consistent state of the hash. Seeding r
+// makes the sample reproducible; nil r means a random seed. It returns nil if the hash has
+// no object.
+func (this *Hash) SampleOwnership(r *rand.Rand, n int) []Assignment {
+	if this == nil || n <= 0 {
+		return nil
+	}
+	if r == nil {
+		r = rand.New(rand.NewSource(rand.Int63()))
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	if len(this.nodes) == 0 || !this.ready() {
+		return nil
+	}
+	a := make([]Assignment, n)
+	for i := range a {
+		key := r.Uint64()
+		a[i] = Assignment{Key: key, Node: this.get(key)}
+	}
+	return a
+}
@@ -0,0 +1,40 @@
+package doublejump
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestHash_SampleOwnership(t *testing.T) {
+	h := NewHash()
+	if h.SampleOwnership(nil, 10) != nil {
+		t.Fatal("an empty hash should return nil")
+	}
+	for i := 0; i < 5; i++ {
+		h.Add(i)
+	}
+	h.Drain(2)
+
+	a := h.SampleOwnership(rand.New(rand.NewSource(1)), 1000)
+	if len(a) != 1000 {
+		t.Fatalf("len(a) != 1000. len: %d", len(a))
+	}
+	owned := make(map[interface{}]int)
+	for _, v := range a {
+		if v.Node != h.Get(v.Key) {
+			t.Fatalf("the sample should match Get. key: %d", v.Key)
+		}
+		owned[v.Node]++
+	}
+	if len(owned) != 4 || owned[2] != 0 {
+		t.Fatalf("unexpected ownership: %v", owned)
+	}
+
+	if !reflect.DeepEqual(a, h.SampleOwnership(rand.New(rand.NewSource(1)), 1000)) {
+		t.Fatal("the sample should be reproducible")
+	}
+	if len(h.SampleOwnership(nil, 3)) != 3 {
+		t.Fatal("nil r should be allowed")
+	}
+}
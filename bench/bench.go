@@ -0,0 +1,228 @@
+// Package bench runs micro-benchmarks of doublejump programmatically and returns structured
+// results, so that services can measure the ring in their own environment and gate their
+// deployments on its performance:
+//
+//	for _, c := range bench.Suite(100, 1000) {
+//		r := bench.Run(c)
+//		if err := r.Check(bench.Budget{MaxNsPerOp: 200, ZeroAllocs: true}); err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+//
+// Unlike testing.Benchmark, it does not depend on the flags of the test binary, and the
+// duration of every run can be set.
+package bench
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gnat88/doublejump"
+)
+
+// ErrOverBudget is returned by Result.Check when a result exceeds its budget.
+var ErrOverBudget = errors.New("bench: over budget")
+
+// Workload is the operation a benchmark repeats.
+type Workload int
+
+const (
+	// Get looks up a key.
+	Get Workload = iota
+	// Churn removes a node or adds it back, alternately.
+	Churn
+	// Mixed is Get or Churn, with Config.ReadPercent percent of Get.
+	Mixed
+)
+
+// String implements fmt.Stringer.
+func (this Workload) String() string {
+	switch this {
+	case Get:
+		return "get"
+	case Churn:
+		return "churn"
+	case Mixed:
+		return "mixed"
+	}
+	return "workload(" + strconv.Itoa(int(this)) + ")"
+}
+
+// DefaultDuration is the default duration of a run.
+const DefaultDuration = time.Second
+
+// Config is a benchmark to run.
+type Config struct {
+	Workload Workload
+	// Nodes is the number of nodes of the ring, at least 1.
+	Nodes int
+	// Lock tells whether the ring is threadsafe, see doublejump.NewHashWithoutLock.
+	Lock bool
+	// ReadPercent is the percentage of Get of Mixed, 90 if not in (0, 100].
+	ReadPercent int
+	// Duration is the minimum duration of the run, DefaultDuration if not positive.
+	Duration time.Duration
+}
+
+// Name returns the name of the benchmark, e.g. "get/1000-nodes/lock".
+func (this Config) Name() string {
+	lock := "nolock"
+	if this.Lock {
+		lock = "lock"
+	}
+	return fmt.Sprintf("%s/%d-nodes/%s", this.Workload, this.Nodes, lock)
+}
+
+// Suite returns the configs of every workload in both lock modes for each number of nodes.
+func Suite(nodes ...int) []Config {
+	var a []Config
+	for _, n := range nodes {
+		for _, w := range []Workload{Get, Churn, Mixed} {
+			a = append(a, Config{Workload: w, Nodes: n, Lock: true}, Config{Workload: w, Nodes: n})
+		}
+	}
+	return a
+}
+
+// Result is the result of a benchmark.
+type Result struct {
+	Config
+	// N is the number of operations run.
+	N           int
+	NsPerOp     int64
+	AllocsPerOp int64
+	BytesPerOp  int64
+}
+
+// Budget is the maximum cost of an operation a result may have. Zero limits are not checked.
+type Budget struct {
+	MaxNsPerOp     int64
+	MaxAllocsPerOp int64
+	MaxBytesPerOp  int64
+	// ZeroAllocs requires that an operation does not allocate at all.
+	ZeroAllocs bool
+}
+
+// Check returns an error wrapping ErrOverBudget if the result exceeds the budget.
+func (this Result) Check(b Budget) error {
+	switch {
+	case b.MaxNsPerOp > 0 && this.NsPerOp > b.MaxNsPerOp:
+		return fmt.Errorf("%w: %s: %d ns/op > %d", ErrOverBudget, this.Name(), this.NsPerOp, b.MaxNsPerOp)
+	case b.ZeroAllocs && this.AllocsPerOp > 0:
+		return fmt.Errorf("%w: %s: %d allocs/op > 0", ErrOverBudget, this.Name(), this.AllocsPerOp)
+	case b.MaxAllocsPerOp > 0 && this.AllocsPerOp > b.MaxAllocsPerOp:
+		return fmt.Errorf("%w: %s: %d allocs/op > %d", ErrOverBudget, this.Name(), this.AllocsPerOp, b.MaxAllocsPerOp)
+	case b.MaxBytesPerOp > 0 && this.BytesPerOp > b.MaxBytesPerOp:
+		return fmt.Errorf("%w: %s: %d B/op > %d", ErrOverBudget, this.Name(), this.BytesPerOp, b.MaxBytesPerOp)
+	}
+	return nil
+}
+
+// Run runs the benchmark, growing the number of operations like testing.Benchmark until
+// the run lasts at least the duration of the config.
+func Run(c Config) Result {
+	if c.Nodes < 1 {
+		c.Nodes = 1
+	}
+	if c.ReadPercent <= 0 || c.ReadPercent > 100 {
+		c.ReadPercent = 90
+	}
+	if c.Duration <= 0 {
+		c.Duration = DefaultDuration
+	}
+
+	var h *doublejump.Hash
+	if c.Lock {
+		h = doublejump.NewHash()
+	} else {
+		h = doublejump.NewHashWithoutLock()
+	}
+	nodes := make([]string, c.Nodes)
+	for i := range nodes {
+		nodes[i] = "node" + strconv.Itoa(i)
+		h.Add(nodes[i])
+	}
+	op := workload(c, h, nodes)
+
+	r := Result{Config: c}
+	n := 1
+	for {
+		d, allocs, bytes := measure(op, n)
+		r.N = n
+		r.NsPerOp = d.Nanoseconds() / int64(n)
+		r.AllocsPerOp = int64(allocs) / int64(n)
+		r.BytesPerOp = int64(bytes) / int64(n)
+		if d >= c.Duration || n >= 1e9 {
+			return r
+		}
+
+		// 与testing相同，按已经测得的速度预估，多估20%，每次最多增长100倍
+		next := int(1.2 * float64(c.Duration) / float64(d) * float64(n))
+		if d <= 0 || next > 100*n {
+			next = 100 * n
+		}
+		if next <= n {
+			next = n + 1
+		}
+		n = next
+	}
+}
+
+// 返回执行第i次操作的函数
+func workload(c Config, h *doublejump.Hash, nodes []string) func(i int) {
+	// 交替删除和加回同一个节点
+	churn := func(i int) {
+		node := nodes[i/2%len(nodes)]
+		if i%2 == 0 {
+			h.Remove(node)
+		} else {
+			h.Add(node)
+		}
+	}
+
+	switch c.Workload {
+	case Churn:
+		return churn
+	case Mixed:
+		writes := 0
+		return func(i int) {
+			if i%100 < c.ReadPercent {
+				h.Get(doublejump.SplitMix64(uint64(i)))
+				return
+			}
+			churn(writes)
+			writes++
+		}
+	}
+	return func(i int) {
+		h.Get(doublejump.SplitMix64(uint64(i)))
+	}
+}
+
+func measure(op func(i int), n int) (d time.Duration, allocs, bytes uint64) {
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	t := time.Now()
+	for i := 0; i < n; i++ {
+		op(i)
+	}
+	d = time.Since(t)
+	runtime.ReadMemStats(&after)
+	return d, after.Mallocs - before.Mallocs, after.TotalAlloc - before.TotalAlloc
+}
+
+// WriteTable writes the results to w as an aligned text table.
+func WriteTable(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "benchmark\tops\tns/op\tallocs/op\tB/op\t")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t\n", r.Name(), r.N, r.NsPerOp, r.AllocsPerOp, r.BytesPerOp)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,53 @@
+package bench
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	configs := Suite(10)
+	if len(configs) != 6 {
+		t.Fatal("len(configs) != 6")
+	}
+
+	var results []Result
+	for _, c := range configs {
+		c.Duration = 10 * time.Millisecond
+		r := Run(c)
+		if r.N < 1 || r.NsPerOp <= 0 || r.ReadPercent != 90 {
+			t.Fatalf("unexpected result of %s: %+v", c.Name(), r)
+		}
+		if r.Workload == Get && r.AllocsPerOp != 0 {
+			t.Fatalf("Get should not allocate. r.AllocsPerOp: %d", r.AllocsPerOp)
+		}
+		results = append(results, r)
+	}
+
+	var b strings.Builder
+	if err := WriteTable(&b, results); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), "churn/10-nodes/nolock") || strings.Count(b.String(), "\n") != 7 {
+		t.Fatalf("unexpected table:\n%s", b.String())
+	}
+}
+
+func TestResult_Check(t *testing.T) {
+	r := Result{Config: Config{Workload: Get, Nodes: 10}, NsPerOp: 50, AllocsPerOp: 1}
+	if err := r.Check(Budget{MaxNsPerOp: 100}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Check(Budget{ZeroAllocs: true}); !errors.Is(err, ErrOverBudget) {
+		t.Fatalf("err != ErrOverBudget. err: %v", err)
+	}
+	err := r.Check(Budget{MaxNsPerOp: 10})
+	if !errors.Is(err, ErrOverBudget) || !strings.Contains(err.Error(), "get/10-nodes/nolock") {
+		t.Fatalf("err != ErrOverBudget. err: %v", err)
+	}
+	if Workload(9).String() != "workload(9)" {
+		t.Fatal("unexpected name of an unknown workload")
+	}
+}
@@ -17,6 +17,7 @@ func BenchmarkDoubleJumpWithoutLock(b *testing.B) {
 				h.Add(fmt.Sprintf("node%d", j))
 			}
 
+			b.ReportAllocs()
 			b.ResetTimer()
 			for j := 0; j < b.N; j++ {
 				h.Get(uint64(j))
@@ -33,6 +34,7 @@ func BenchmarkDoubleJump(b *testing.B) {
 				h.Add(fmt.Sprintf("node%d", j))
 			}
 
+			b.ReportAllocs()
 			b.ResetTimer()
 			for j := 0; j < b.N; j++ {
 				h.Get(uint64(j))
@@ -61,3 +63,63 @@ func BenchmarkSerialxHashring(b *testing.B) {
 		})
 	}
 }
+
+func BenchmarkDoubleJumpString(b *testing.B) {
+	for i := 10; i <= 1000; i *= 10 {
+		b.Run(fmt.Sprintf("%d-nodes", i), func(b *testing.B) {
+			h := doublejump.NewHashStringWithoutLock()
+			for j := 0; j < i; j++ {
+				h.Add(fmt.Sprintf("node%d", j))
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for j := 0; j < b.N; j++ {
+				h.Get(uint64(j))
+			}
+		})
+	}
+}
+
+type addr struct {
+	host string
+	port int
+}
+
+func BenchmarkDoubleJumpOf(b *testing.B) {
+	for i := 10; i <= 1000; i *= 10 {
+		b.Run(fmt.Sprintf("%d-nodes", i), func(b *testing.B) {
+			h := doublejump.NewHashOf[addr]()
+			for j := 0; j < i; j++ {
+				h.Add(addr{host: fmt.Sprintf("node%d", j), port: 80})
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for j := 0; j < b.N; j++ {
+				h.Get(uint64(j))
+			}
+		})
+	}
+}
+
+func BenchmarkDoubleJumpGetString(b *testing.B) {
+	for _, d := range []doublejump.Digest{doublejump.DigestFNV1a, doublejump.DigestXXHash, doublejump.DigestMurmur3, doublejump.DigestMapHash} {
+		b.Run(d.String(), func(b *testing.B) {
+			h := doublejump.NewHashWithoutLock(doublejump.WithDigest(d))
+			for j := 0; j < 100; j++ {
+				h.Add(fmt.Sprintf("node%d", j))
+			}
+			keys := make([]string, 1024)
+			for j := range keys {
+				keys[j] = fmt.Sprintf("user:%d:session:%064d", j, j)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for j := 0; j < b.N; j++ {
+				h.GetString(keys[j&1023])
+			}
+		})
+	}
+}
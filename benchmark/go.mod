@@ -0,0 +1,10 @@
+module github.com/gnat88/doublejump/benchmark
+
+go 1.21
+
+require (
+	github.com/gnat88/doublejump v0.0.0
+	github.com/serialx/hashring v0.0.0-20180504054112-49a4782e9908
+)
+
+replace github.com/gnat88/doublejump => ../
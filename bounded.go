@@ -0,0 +1,31 @@
+package doublejump
+
+import "math"
+
+// WithBoundedLoad combines weights with bounded loads: together with WithLoad, Get
+// deterministically overflows keys from an object whose load has reached its bound,
+// c times its fair share of the total load, to the next candidate below its own bound.
+// The fair share is proportional to the weight, so a heavier object takes proportionally
+// more load before it overflows, and no object ends up with more than about c times its
+// share. The bound applies on top of SetCapacity. c <= 1 means 1.25. Every check of a bound
+// sums up the loads of all objects, so it suits rings of up to some hundreds of objects.
+func WithBoundedLoad(c float64) Option {
+	return func(h *Hash) {
+		if c <= 1 {
+			c = 1.25
+		}
+		h.bounded = c
+	}
+}
+
+// 节点的负载是否达到了按权重分配的上限，调用方负责加锁
+func (this *Hash) overBound(n *node) bool {
+	load := this.load(n.obj)
+	total := 0
+	for _, m := range this.nodes {
+		total += this.load(m.obj)
+	}
+	// 上限计入即将分配的这一个KEY
+	bound := math.Ceil(this.bounded * float64(total+1) * float64(n.weight) / float64(len(this.compact.a)))
+	return float64(load) >= bound
+}
@@ -0,0 +1,51 @@
+package doublejump
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHash_WithBoundedLoad(t *testing.T) {
+	loads := make(map[interface{}]int)
+	h := NewHash(WithLoad(func(obj interface{}) int { return loads[obj] }), WithBoundedLoad(1.1))
+	objs := []interface{}{"a", "b", "c", "d"}
+	weights := map[interface{}]int{"a": 1, "b": 1, "c": 2, "d": 4}
+	for _, obj := range objs {
+		h.AddWeighted(obj, weights[obj])
+	}
+
+	// 把KEY逐个分配出去，每个节点的负载都不超过按权重分配的上限
+	const keys = 8000
+	for key := uint64(0); key < keys; key++ {
+		loads[h.Get(key)]++
+	}
+	for obj, k := range weights {
+		bound := math.Ceil(1.1 * keys * float64(k) / 8)
+		if float64(loads[obj]) > bound {
+			t.Fatalf("the load of %v exceeds its bound. load: %d, bound: %v", obj, loads[obj], bound)
+		}
+	}
+
+	// 负载不变时选择是确定的，且与没有负载时相同
+	for obj := range loads {
+		loads[obj] = 0
+	}
+	plain := NewHash()
+	for _, obj := range objs {
+		plain.AddWeighted(obj, weights[obj])
+	}
+	for key := uint64(0); key < 1000; key++ {
+		if h.Get(key) != plain.Get(key) {
+			t.Fatalf("key %d should not be redirected without load", key)
+		}
+	}
+
+	s := h.Snapshot()
+	defer s.Release()
+	loads["d"] = keys
+	for key := uint64(0); key < 1000; key++ {
+		if s.Get(key) == "d" {
+			t.Fatal("the snapshot should keep the bounded loads")
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package doublejump
+
+// Breaker is the circuit breaker of an object, e.g. an adapter of the breaker of the
+// client talking to it. See SetBreaker.
+type Breaker interface {
+	// Open reports whether the breaker is open, i.e. the object must not receive keys.
+	// A half-open breaker should report false, so that the object is probed again.
+	// Open is called with the hash locked, so it must not call back into the hash.
+	Open() bool
+}
+
+// BreakerFunc adapts a function to a Breaker.
+type BreakerFunc func() bool
+
+// Open calls f.
+func (f BreakerFunc) Open() bool {
+	return f()
+}
+
+// SetBreaker attaches the circuit breaker to the object, nil detaches it. While the breaker
+// is open, Get deterministically routes the keys of the object to the next candidates, or
+// still returns the object if the breakers of all candidates are open. Once the breaker
+// half-opens or closes the object receives its keys again, as there is no change of the
+// hash. It returns false if the object is not in the hash.
+func (this *Hash) SetBreaker(obj interface{}, b Breaker) bool {
+	if this == nil || obj == nil {
+		return false
+	}
+
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	n, ok := this.nodes[this.id(obj)]
+	if !ok {
+		return false
+	}
+
+	if n.breaker != nil {
+		this.breakers--
+	}
+	if b != nil {
+		this.breakers++
+	}
+	n.breaker = b
+	this.retire()
+	return true
+}
+
+// Breaker returns the circuit breaker attached to the object, or nil.
+func (this *Hash) Breaker(obj interface{}) Breaker {
+	if this == nil || obj == nil {
+		return nil
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	if n, ok := this.nodes[this.id(obj)]; ok {
+		return n.breaker
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package doublejump
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func TestHash_SetBreaker(t *testing.T) {
+	h := NewHash()
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+	before := make([]interface{}, 10000)
+	for key := range before {
+		before[key] = h.Get(uint64(key))
+	}
+
+	var open int32
+	b := BreakerFunc(func() bool { return atomic.LoadInt32(&open) == 1 })
+	if !h.SetBreaker(3, b) || h.SetBreaker(100, b) {
+		t.Fatal("SetBreaker is wrong")
+	}
+	if h.Breaker(3) == nil || h.Breaker(4) != nil {
+		t.Fatal("Breaker is wrong")
+	}
+	for key, obj := range before {
+		if h.Get(uint64(key)) != obj {
+			t.Fatal("a closed breaker should not move keys")
+		}
+	}
+
+	atomic.StoreInt32(&open, 1)
+	for key, obj := range before {
+		got := h.Get(uint64(key))
+		if got == 3 {
+			t.Fatalf("the keys should be routed around an open breaker. key: %d", key)
+		}
+		if obj != 3 && got != obj {
+			t.Fatalf("only the keys of the open node should move. key: %d", key)
+		}
+		if got != h.Get(uint64(key)) {
+			t.Fatal("the routing should be deterministic")
+		}
+	}
+
+	// 半开状态重新接收原来的KEY
+	atomic.StoreInt32(&open, 0)
+	for key, obj := range before {
+		if h.Get(uint64(key)) != obj {
+			t.Fatal("the node should be re-included")
+		}
+	}
+
+	h.Remove(3)
+	if h.breakers != 0 {
+		t.Fatalf("h.breakers != 0. breakers: %d", h.breakers)
+	}
+
+	// 全部熔断时仍然返回选中的节点
+	h2 := NewHash()
+	h2.Add("a")
+	h2.SetBreaker("a", BreakerFunc(func() bool { return true }))
+	if h2.Get(0) != "a" {
+		t.Fatal("h2.Get(0) != a")
+	}
+	h2.SetBreaker("a", nil)
+	if h2.breakers != 0 || h2.Breaker("a") != nil {
+		t.Fatal("the breaker should be detached")
+	}
+}
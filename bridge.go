@@ -0,0 +1,120 @@
+package doublejump
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// Mutable is a selector whose membership can be changed, such as Hash.
+type Mutable interface {
+	Selector
+	Add(obj interface{}) bool
+	Remove(obj interface{}) bool
+}
+
+var _ Mutable = (*Hash)(nil)
+
+// 按KEY决定路由时的精度，百分比换算为万分比
+const bridgeScale = 10000
+
+// Bridge keeps two selectors, the current algorithm and the one migrated to, e.g. Maglev
+// behind an adapter, in sync on every mutation, and routes every key to one of them, so
+// that a migration can be rolled out gradually in production. A key is routed to the next
+// selector if it is allowed explicitly, or if it falls within the percentage of keys set by
+// SetPercent. Raising the percentage only moves keys from the current selector to the next
+// one, never back. Bridge is threadsafe as long as both selectors are.
+type Bridge struct {
+	current Mutable
+	next    Mutable
+
+	mu sync.Mutex // 保证两边按相同的顺序修改
+
+	percent uint64 // 万分比
+
+	allowMu sync.RWMutex
+	allowed map[uint64]struct{}
+}
+
+// NewBridge creates a bridge routing every key to current until SetPercent or Allow is
+// called. The selectors should have the same membership already.
+func NewBridge(current, next Mutable) *Bridge {
+	return &Bridge{current: current, next: next, allowed: make(map[uint64]struct{})}
+}
+
+// SetPercent sets the percentage of keys routed to the next selector, from 0 to 100. The
+// keys are picked deterministically by their hashes.
+func (this *Bridge) SetPercent(p float64) {
+	p = math.Max(0, math.Min(100, p))
+	atomic.StoreUint64(&this.percent, uint64(math.Round(p*bridgeScale/100)))
+}
+
+// Percent returns the percentage of keys routed to the next selector.
+func (this *Bridge) Percent() float64 {
+	return float64(atomic.LoadUint64(&this.percent)) * 100 / bridgeScale
+}
+
+// Allow routes the keys to the next selector regardless of the percentage, e.g. the keys
+// of test accounts.
+func (this *Bridge) Allow(keys ...uint64) {
+	this.allowMu.Lock()
+	defer this.allowMu.Unlock()
+
+	for _, key := range keys {
+		this.allowed[key] = struct{}{}
+	}
+}
+
+// Disallow undoes Allow for the keys.
+func (this *Bridge) Disallow(keys ...uint64) {
+	this.allowMu.Lock()
+	defer this.allowMu.Unlock()
+
+	for _, key := range keys {
+		delete(this.allowed, key)
+	}
+}
+
+// Migrated reports whether the key is routed to the next selector.
+func (this *Bridge) Migrated(key uint64) bool {
+	if SplitMix64(key)%bridgeScale < atomic.LoadUint64(&this.percent) {
+		return true
+	}
+
+	this.allowMu.RLock()
+	_, ok := this.allowed[key]
+	this.allowMu.RUnlock()
+	return ok
+}
+
+// Get returns the object selected for the key by the selector the key is routed to.
+func (this *Bridge) Get(key uint64) interface{} {
+	if this == nil {
+		return nil
+	}
+
+	if this.Migrated(key) {
+		return this.next.Get(key)
+	}
+	return this.current.Get(key)
+}
+
+// Add adds the object to both selectors. It reports whether either of them added it.
+func (this *Bridge) Add(obj interface{}) bool {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	a := this.current.Add(obj)
+	b := this.next.Add(obj)
+	return a || b
+}
+
+// Remove removes the object from both selectors. It reports whether either of them removed it.
+func (this *Bridge) Remove(obj interface{}) bool {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	a := this.current.Remove(obj)
+	b := this.next.Remove(obj)
+	return a || b
+}
@@ -0,0 +1,61 @@
+package doublejump
+
+import "testing"
+
+func TestBridge(t *testing.T) {
+	current := NewHash()
+	next := NewHash(WithMixer(SplitMix64))
+	b := NewBridge(current, next)
+	for i := 0; i < 10; i++ {
+		if !b.Add(i) {
+			t.Fatal("b.Add should add to both selectors")
+		}
+	}
+	b.Remove(3)
+	if current.Len() != 9 || next.Len() != 9 || next.Contains(3) {
+		t.Fatal("both selectors should be in sync")
+	}
+
+	for key := uint64(0); key < 1000; key++ {
+		if b.Get(key) != current.Get(key) {
+			t.Fatal("every key should be routed to current")
+		}
+	}
+
+	b.SetPercent(30)
+	if b.Percent() != 30 {
+		t.Fatal("b.Percent() != 30")
+	}
+	migrated := make(map[uint64]bool)
+	for key := uint64(0); key < 10000; key++ {
+		if b.Migrated(key) {
+			migrated[key] = true
+			if b.Get(key) != next.Get(key) {
+				t.Fatal("a migrated key should be routed to next")
+			}
+		} else if b.Get(key) != current.Get(key) {
+			t.Fatal("a key not migrated should be routed to current")
+		}
+	}
+	if n := len(migrated); n < 2800 || n > 3200 {
+		t.Fatalf("about 30%% of the keys should be migrated. n: %d", n)
+	}
+
+	// 提高比例只会让更多的KEY迁移
+	b.SetPercent(60)
+	for key := range migrated {
+		if !b.Migrated(key) {
+			t.Fatal("a migrated key should stay migrated")
+		}
+	}
+
+	b.SetPercent(0)
+	b.Allow(7)
+	if !b.Migrated(7) || b.Migrated(8) || b.Get(7) != next.Get(7) {
+		t.Fatal("an allowed key should be routed to next")
+	}
+	b.Disallow(7)
+	if b.Migrated(7) {
+		t.Fatal("b.Migrated(7) should be false")
+	}
+}
@@ -0,0 +1,84 @@
+package doublejump
+
+// Builder accumulates objects and produces a fully-populated Hash in one shot, with the
+// internals pre-sized. The result is the same as adding the objects one by one in order.
+type Builder struct {
+	opts  []Option
+	nodes []Node
+}
+
+// NewBuilder creates a builder. The options are applied to the built hash.
+func NewBuilder(opts ...Option) *Builder {
+	return &Builder{opts: opts}
+}
+
+// Add adds objects with weight 1 and no metadata.
+func (this *Builder) Add(objs ...interface{}) *Builder {
+	for _, obj := range objs {
+		this.nodes = append(this.nodes, Node{Value: obj})
+	}
+	return this
+}
+
+// AddNode adds objects together with their weights and metadata.
+func (this *Builder) AddNode(nodes ...Node) *Builder {
+	this.nodes = append(this.nodes, nodes...)
+	return this
+}
+
+// Len returns the number of objects accumulated so far, including duplicated ones.
+func (this *Builder) Len() int {
+	return len(this.nodes)
+}
+
+// Build creates a new doublejump hash instance, which is threadsafe.
+func (this *Builder) Build() *Hash {
+	return this.build(true)
+}
+
+// BuildWithoutLock creates a new doublejump hash instance, which does NOT threadsafe.
+func (this *Builder) BuildWithoutLock() *Hash {
+	return this.build(false)
+}
+
+func (this *Builder) build(lock bool) *Hash {
+	h := newHash(lock, this.opts)
+
+	slots := 0
+	for _, n := range this.nodes {
+		if n.Weight > 1 {
+			slots += n.Weight
+		} else {
+			slots++
+		}
+	}
+	h.loose.a = make([]interface{}, 0, slots)
+	h.loose.m = make(map[interface{}]int, slots)
+	h.compact.a = make([]interface{}, 0, slots)
+	h.compact.m = make(map[interface{}]int, slots)
+	h.nodes = make(map[interface{}]*node, len(this.nodes))
+
+	for _, n := range this.nodes {
+		if n.Value != nil {
+			h.addNode(n)
+		}
+	}
+	return h
+}
+
+// NewHashFromNodes creates a new threadsafe hash holding the objects, with the internals
+// pre-sized. It is the same as adding the objects one by one in order.
+// Use NewBuilder for options or weights.
+func NewHashFromNodes(nodes ...interface{}) *Hash {
+	return NewBuilder().Add(nodes...).Build()
+}
+
+// NewHashFromStrings is like NewHashFromNodes, but takes a list of strings such as the
+// addresses of hosts.
+func NewHashFromStrings(nodes []string) *Hash {
+	b := &Builder{nodes: make([]Node, len(nodes))}
+	for i, s := range nodes {
+		b.nodes[i] = Node{Value: s}
+	}
+	return b.Build()
+}
@@ -0,0 +1,101 @@
+package doublejump
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	b := NewBuilder()
+	h := NewHash()
+	for i := 0; i < 100; i++ {
+		b.Add(i)
+		h.Add(i)
+	}
+	b.Add(nil, 1)
+	h.Add(1)
+
+	h1 := b.Build()
+	always(h1, t)
+	if !h1.lock || b.BuildWithoutLock().lock {
+		t.Fatal("something is wrong with the lock of the built hash")
+	}
+	if h1.Len() != 100 || b.Len() != 102 {
+		t.Fatalf("the built hash has wrong length. h1.Len(): %d, b.Len(): %d", h1.Len(), b.Len())
+	}
+	if !h1.EqualLayout(h) {
+		t.Fatal("the built hash should be the same as adding the nodes one by one")
+	}
+}
+
+func TestBuilder_Weight(t *testing.T) {
+	h := NewBuilder().AddNode(
+		Node{Value: "small", Meta: "1 cpu"},
+		Node{Value: "big", Weight: 3, Meta: "3 cpus"},
+	).Build()
+	always(h, t)
+
+	if h.Len() != 2 || h.LooseLen() != 4 {
+		t.Fatalf("the weighted node should take 3 slots. h.Len(): %d, h.LooseLen(): %d", h.Len(), h.LooseLen())
+	}
+	if fmt.Sprint(h.Nodes()) != "[small big]" {
+		t.Fatalf("h.Nodes() should not contain virtual slots. nodes: %v", h.Nodes())
+	}
+	if h.Meta("big") != "3 cpus" || h.Meta("none") != nil {
+		t.Fatal("something is wrong with Meta")
+	}
+	if !h.SetMeta("small", "2 cpus") || h.Meta("small") != "2 cpus" || h.SetMeta("none", 1) {
+		t.Fatal("something is wrong with SetMeta")
+	}
+
+	m := make(map[interface{}]int)
+	total := 100000
+	for i := 0; i < total; i++ {
+		m[h.Get(uint64(i))]++
+	}
+	if r := float64(m["big"]) / float64(total); r < 0.7 || r > 0.8 {
+		t.Fatalf("the weighted node should own about 3/4 keys. r: %.2f", r)
+	}
+	if a := h.GetN(0, 2); len(a) != 2 || a[0] == a[1] {
+		t.Fatalf("GetN should skip the virtual slots of the same node. a: %v", a)
+	}
+
+	h.Remove("big")
+	always(h, t)
+	if h.Len() != 1 || len(h.compact.a) != 1 {
+		t.Fatalf("Remove should remove all the slots of the node. h.Len(): %d", h.Len())
+	}
+	h.addNode(Node{Value: "big", Weight: 3})
+	always(h, t)
+	if h.LooseLen() != 4 || h.loose.a[1] != "big" {
+		t.Fatalf("the re-added node should take back its slots. a: %v", h.loose.a)
+	}
+}
+
+func TestNewHashFromNodes(t *testing.T) {
+	h1 := NewHashFromNodes(1, 2, 3, 2)
+	always(h1, t)
+	if h1.Len() != 3 {
+		t.Fatal("h1.Len() != 3")
+	}
+	if !h1.lock {
+		t.Fatal("NewHashFromNodes should create a threadsafe hash")
+	}
+
+	hosts := []string{"10.0.0.1:80", "10.0.0.2:80", "10.0.0.3:80"}
+	h2 := NewHashFromStrings(hosts)
+	always(h2, t)
+	h3 := NewHash()
+	for _, s := range hosts {
+		h3.Add(s)
+	}
+	for key := uint64(0); key < 1000; key++ {
+		if h2.Get(key) != h3.Get(key) {
+			t.Fatalf("h2.Get(%d) != h3.Get(%d)", key, key)
+		}
+	}
+
+	if NewHashFromStrings(nil).Len() != 0 {
+		t.Fatal("NewHashFromStrings(nil).Len() != 0")
+	}
+}
@@ -0,0 +1,110 @@
+package doublejump
+
+import (
+	"runtime"
+	"sync"
+)
+
+// BulkGet returns the objects for all the keys, resolved under a single lock acquisition.
+// The i-th object is for keys[i]. With WithPool, the slice can be handed back by Recycle.
+func (this *Hash) BulkGet(keys []uint64) []interface{} {
+	if this == nil {
+		return nil
+	}
+
+	a := this.pool.get(len(keys))[:len(keys)]
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	for i, key := range keys {
+		a[i] = this.get(key)
+	}
+	return a
+}
+
+// GroupByNode buckets the keys by the objects Get returns for them in one pass, keeping the
+// order of keys in each bucket. The map is keyed by the objects, or by their IDs if
+// WithKeyFunc is given, since the objects themselves may not be comparable. Keys are dropped
+// if the hash is empty.
+func (this *Hash) GroupByNode(keys []uint64) map[interface{}][]uint64 {
+	if this == nil {
+		return nil
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	m := make(map[interface{}][]uint64, len(this.nodes))
+	for _, key := range keys {
+		if id := this.selectID(this.windowKey(key)); id != nil {
+			m[id] = append(m[id], key)
+		}
+	}
+	return m
+}
+
+// 每个worker至少处理的KEY数量，太少的话并发得不偿失
+const minKeysPerWorker = 4096
+
+// BulkGetParallel is like BulkGet, but partitions the keys across workers resolving them
+// against a single snapshot of the hash. workers <= 0 means runtime.GOMAXPROCS(0).
+func (this *Hash) BulkGetParallel(keys []uint64, workers int) []interface{} {
+	if this == nil {
+		return nil
+	}
+	s := this.Snapshot()
+	defer s.Release()
+	return s.BulkGetParallel(keys, workers)
+}
+
+// BulkGetParallel returns the objects for all the keys, partitioning them across workers.
+// workers <= 0 means runtime.GOMAXPROCS(0).
+func (this *Snapshot) BulkGetParallel(keys []uint64, workers int) []interface{} {
+	if this == nil {
+		return nil
+	}
+
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if n := (len(keys) + minKeysPerWorker - 1) / minKeysPerWorker; workers > n {
+		workers = n
+	}
+
+	a := make([]interface{}, len(keys))
+	if workers <= 1 {
+		for i, key := range keys {
+			a[i] = this.hash.get(key)
+		}
+		return a
+	}
+
+	// 每个worker写入结果中属于自己的一段，不需要额外合并
+	var wg sync.WaitGroup
+	size := (len(keys) + workers - 1) / workers
+	for begin := 0; begin < len(keys); begin += size {
+		end := begin + size
+		if end > len(keys) {
+			end = len(keys)
+		}
+
+		wg.Add(1)
+		go func(begin, end int) {
+			defer wg.Done()
+			for i := begin; i < end; i++ {
+				a[i] = this.hash.get(keys[i])
+			}
+		}(begin, end)
+	}
+	wg.Wait()
+	return a
+}
@@ -0,0 +1,108 @@
+package doublejump
+
+import "testing"
+
+func TestHash_BulkGet(t *testing.T) {
+	h := NewHash()
+	keys := make([]uint64, 1000)
+	for i := range keys {
+		keys[i] = uint64(i) * 7919
+	}
+	if a := h.BulkGet(keys); len(a) != len(keys) || a[0] != nil {
+		t.Fatal("BulkGet should return nils when the hash has no node at all")
+	}
+
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+	h.Remove(3)
+
+	a := h.BulkGet(keys)
+	for i, key := range keys {
+		if a[i] != h.Get(key) {
+			t.Fatalf("BulkGet should be the same as Get. key: %d", key)
+		}
+	}
+
+	var h2 *Hash
+	if h2.BulkGet(keys) != nil {
+		t.Fatal("BulkGet should handle nil hash")
+	}
+}
+
+func TestHash_GroupByNode(t *testing.T) {
+	h := NewHash()
+	keys := make([]uint64, 1000)
+	for i := range keys {
+		keys[i] = uint64(i) * 7919
+	}
+	if len(h.GroupByNode(keys)) != 0 {
+		t.Fatal("GroupByNode should return no bucket when the hash has no node at all")
+	}
+
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+
+	m := h.GroupByNode(keys)
+	if len(m) != 10 {
+		t.Fatalf("the keys should spread over all the nodes. len(m): %d", len(m))
+	}
+	n := 0
+	for obj, a := range m {
+		for i, key := range a {
+			if h.Get(key) != obj {
+				t.Fatalf("key %d should not be in the bucket of %v", key, obj)
+			}
+			if i > 0 && a[i-1] >= key {
+				t.Fatal("the order of keys should be kept in each bucket")
+			}
+		}
+		n += len(a)
+	}
+	if n != len(keys) {
+		t.Fatalf("every key should be in a bucket. n: %d", n)
+	}
+
+	// 与Get一样绕开排空的节点
+	h.Drain(3)
+	m = h.GroupByNode(keys)
+	if len(m[3]) != 0 {
+		t.Fatalf("a drained node should get no key. len(m[3]): %d", len(m[3]))
+	}
+	for obj, a := range m {
+		for _, key := range a {
+			if h.Get(key) != obj {
+				t.Fatalf("key %d should not be in the bucket of %v", key, obj)
+			}
+		}
+	}
+}
+
+func TestHash_BulkGetParallel(t *testing.T) {
+	h := NewHash()
+	for i := 0; i < 100; i++ {
+		h.Add(i)
+	}
+	for i := 0; i < 100; i += 7 {
+		h.Remove(i)
+	}
+
+	for _, n := range []int{0, 10, minKeysPerWorker*5 + 3} {
+		keys := make([]uint64, n)
+		for i := range keys {
+			keys[i] = uint64(i) * 7919
+		}
+		for _, workers := range []int{0, 1, 3, 100} {
+			a := h.BulkGetParallel(keys, workers)
+			if len(a) != len(keys) {
+				t.Fatalf("len(a) != len(keys). len(a): %d, len(keys): %d", len(a), len(keys))
+			}
+			for i, key := range keys {
+				if a[i] != h.Get(key) {
+					t.Fatalf("BulkGetParallel should be the same as Get. key: %d, workers: %d", key, workers)
+				}
+			}
+		}
+	}
+}
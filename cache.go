@@ -0,0 +1,50 @@
+package doublejump
+
+import "sync"
+
+// 缓存KEY对应的节点标识，哈希的generation变化时整体失效
+type resultCache struct {
+	mu     sync.Mutex
+	lru    *lru
+	gen    uint64
+	hits   uint64
+	misses uint64
+}
+
+// WithCache puts a bounded LRU cache of key→object resolutions in front of Get, which is
+// invalidated whenever the generation of the hash changes. It pays off when a small set of
+// hot keys accounts for most lookups.
+func WithCache(size int) Option {
+	return func(h *Hash) {
+		h.cache = &resultCache{lru: newLRU(size)}
+	}
+}
+
+// 调用方负责加锁，保证hash.gen在查询过程中不变
+func (this *resultCache) getID(hash *Hash, key uint64) interface{} {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	if this.gen != hash.gen {
+		this.lru.clear()
+		this.gen = hash.gen
+	}
+
+	if id, ok := this.lru.get(key); ok {
+		this.hits++
+		return id
+	}
+
+	this.misses++
+	id := hash.getID(key)
+	if id != nil {
+		this.lru.add(key, id)
+	}
+	return id
+}
+
+func (this *resultCache) counters() (hits, misses uint64) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.hits, this.misses
+}
@@ -0,0 +1,38 @@
+package doublejump
+
+import "testing"
+
+func TestHash_WithCache(t *testing.T) {
+	h := NewHash(WithCache(100))
+	h0 := NewHash()
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+		h0.Add(i)
+	}
+
+	for loop := 0; loop < 3; loop++ {
+		for key := uint64(0); key < 50; key++ {
+			if h.Get(key) != h0.Get(key) {
+				t.Fatalf("the cached result is wrong. key: %d", key)
+			}
+		}
+	}
+	s := h.Stats()
+	if s.CacheMisses != 50 || s.CacheHits != 100 {
+		t.Fatalf("the cache counters are wrong. hits: %d, misses: %d", s.CacheHits, s.CacheMisses)
+	}
+
+	// generation变化后缓存应该失效
+	h.Remove(h.Get(0))
+	h0.Remove(h0.Get(0))
+	h.Pin(1, 5)
+	h0.Pin(1, 5)
+	for key := uint64(0); key < 50; key++ {
+		if h.Get(key) != h0.Get(key) {
+			t.Fatalf("the cache should be invalidated after the hash changes. key: %d", key)
+		}
+	}
+	if s := h.Stats(); s.CacheMisses != 100 {
+		t.Fatalf("all lookups should miss after invalidation. misses: %d", s.CacheMisses)
+	}
+}
@@ -0,0 +1,101 @@
+package doublejump
+
+// 与KEY混合后决定是否由金丝雀节点接收，与节点无关，所以与内部布局无关
+const canarySalt = 0x2545f4914f6cdd1d
+
+// AddCanary adds an object which receives only the given share of the keyspace, e.g. 0.01
+// for 1% of the keys, instead of the share of a full object. The other keys it would own
+// deterministically go to their next candidates. Raising the share by SetShare only moves
+// more keys onto the object, and a share >= 1 promotes it to a full object. It reports
+// whether the object was newly inserted.
+func (this *Hash) AddCanary(obj interface{}, share float64) bool {
+	if this == nil || obj == nil {
+		return false
+	}
+
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	if !this.add(obj) {
+		return false
+	}
+	this.setShare(this.nodes[this.id(obj)], share)
+	return true
+}
+
+// SetShare changes the share of the keyspace received by the object, see AddCanary.
+// A share >= 1 makes it a full object. It returns false if the object is not in the hash.
+func (this *Hash) SetShare(obj interface{}, share float64) bool {
+	if this == nil || obj == nil {
+		return false
+	}
+
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	n, ok := this.nodes[this.id(obj)]
+	if !ok {
+		return false
+	}
+	this.setShare(n, share)
+	n.undrain = 0 // 停止DrainOver
+	this.retire()
+	return true
+}
+
+// Share returns the share of the keyspace received by a canary object, or 1 for a full
+// object. It returns 0 if the object is not in the hash.
+func (this *Hash) Share(obj interface{}) float64 {
+	if this == nil || obj == nil {
+		return 0
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	n, ok := this.nodes[this.id(obj)]
+	if !ok {
+		return 0
+	}
+	if n.share > 0 {
+		return n.share
+	}
+	return 1
+}
+
+// 调用方负责加锁
+func (this *Hash) setShare(n *node, share float64) {
+	if share >= 1 {
+		share = 0
+	} else if share <= 0 {
+		share = 1e-12 // 保持为金丝雀节点，几乎不接收KEY
+	}
+
+	if n.share > 0 {
+		this.canaries--
+	}
+	if share > 0 {
+		this.canaries++
+	}
+	n.share = share
+}
+
+// 金丝雀节点是否接收该KEY: 节点原本占有weight/slots的KEY，按比例接收其中一部分，
+// 使得接收的KEY占全部KEY的share，调用方负责加锁
+func (this *Hash) accepts(n *node, key uint64) bool {
+	p := n.share * float64(len(this.compact.a)) / float64(n.weight)
+	u := float64(SplitMix64(key^canarySalt)>>11) / (1 << 53)
+	return u < p
+}
@@ -0,0 +1,79 @@
+package doublejump
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHash_Canary(t *testing.T) {
+	h := NewHash()
+	for i := 0; i < 20; i++ {
+		h.Add(i)
+	}
+	full := NewHash()
+	for i := 0; i <= 20; i++ {
+		full.Add(i)
+	}
+
+	if !h.AddCanary(20, 0.01) {
+		t.Fatal("h.AddCanary(20, 0.01) failed")
+	}
+	if h.AddCanary(20, 0.01) {
+		t.Fatal("adding the canary again should fail")
+	}
+	if h.Share(20) != 0.01 || h.Share(1) != 1 || h.Share(100) != 0 {
+		t.Fatal("unexpected shares")
+	}
+
+	const n = 100000
+	count := func() (map[uint64]bool, float64) {
+		m := make(map[uint64]bool)
+		for key := uint64(0); key < n; key++ {
+			if h.Get(key) == 20 {
+				m[key] = true
+			}
+		}
+		return m, float64(len(m)) / n
+	}
+
+	m1, share := count()
+	if math.Abs(share-0.01) > 0.002 {
+		t.Fatalf("the canary should receive about 1%% of the keys. share: %f", share)
+	}
+	for key := range m1 {
+		if full.Get(key) != 20 {
+			t.Fatalf("the canary should only receive its own keys. key: %d", key)
+		}
+	}
+
+	// 提高比例时只会有更多的KEY移动到金丝雀节点
+	h.SetShare(20, 0.03)
+	m2, share := count()
+	if math.Abs(share-0.03) > 0.004 {
+		t.Fatalf("the canary should receive about 3%% of the keys. share: %f", share)
+	}
+	for key := range m1 {
+		if !m2[key] {
+			t.Fatalf("key %d left the canary when its share was raised", key)
+		}
+	}
+
+	h.SetShare(20, 1)
+	if h.Share(20) != 1 || h.canaries != 0 {
+		t.Fatal("the canary should be promoted")
+	}
+	for key := uint64(0); key < 1000; key++ {
+		if h.Get(key) != full.Get(key) {
+			t.Fatalf("h.Get(%d) != full.Get(%d) after the promotion", key, key)
+		}
+	}
+
+	h.SetShare(20, 0.5)
+	h.Remove(20)
+	if h.canaries != 0 {
+		t.Fatal("h.canaries != 0")
+	}
+	if h.SetShare(20, 0.5) {
+		t.Fatal("h.SetShare should fail for a removed object")
+	}
+}
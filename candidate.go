@@ -0,0 +1,193 @@
+package doublejump
+
+// 按确定的顺序依次产生KEY的候选节点标识，保证不重复:
+// 首先是Get的结果，然后在compactHolder上用变换过的KEY重新哈希，
+// 重试次数过多时从某个位置开始顺序扫描compactHolder，保证一定能遍历全部节点
+type candidates struct {
+	hash  *Hash
+	key   uint64
+	step  int
+	start int
+	seen  []interface{}
+}
+
+func (this *Hash) candidates(key uint64) candidates {
+	return candidates{hash: this, key: key}
+}
+
+func (this *candidates) visited(obj interface{}) bool {
+	for _, v := range this.seen {
+		if v == obj {
+			return true
+		}
+	}
+	return false
+}
+
+// 调用方负责加锁，所有节点都遍历过后返回false
+func (this *candidates) next() (interface{}, bool) {
+	if len(this.seen) >= len(this.hash.nodes) || !this.hash.ready() {
+		return nil, false
+	}
+
+	if this.step == 0 {
+		this.step++
+		id := this.hash.getID(this.key)
+		this.seen = append(this.seen, id)
+		return id, true
+	}
+
+	a := this.hash.compact.a
+	n := len(a)
+	for this.step <= 2*n {
+		k := (this.key + uint64(this.step)*0x9e3779b97f4a7c15) * 0xbf58476d1ce4e5b9
+		this.step++
+		id := owner(a[jumpHash(this.hash.compact.jump, k, n)])
+		if !this.visited(id) {
+			this.seen = append(this.seen, id)
+			return id, true
+		}
+	}
+
+	if this.step == 2*n+1 {
+		this.start = int(this.key % uint64(n))
+	}
+	for i := this.step - 2*n - 1; i < n; i++ {
+		this.step++
+		id := owner(a[(this.start+i)%n])
+		if !this.visited(id) {
+			this.seen = append(this.seen, id)
+			return id, true
+		}
+	}
+	return nil, false
+}
+
+// GetExcluding returns the first object in the deterministic candidate sequence of
+// the key which is not in exclude. It returns nil if every object is excluded.
+func (this *Hash) GetExcluding(key uint64, exclude ...interface{}) interface{} {
+	if this == nil {
+		return nil
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	ids := make([]interface{}, 0, len(exclude))
+	for _, obj := range exclude {
+		ids = append(ids, this.id(obj))
+	}
+
+	c := this.candidates(this.windowKey(key))
+	for {
+		id, ok := c.next()
+		if !ok {
+			return nil
+		}
+		if !contains(ids, id) {
+			return this.value(id)
+		}
+	}
+}
+
+// GetWhere returns the first object in the deterministic candidate sequence of the
+// key which satisfies ok. It returns nil if no object satisfies ok.
+// ok is called with the hash locked, so it must not call back into the hash.
+func (this *Hash) GetWhere(key uint64, ok func(obj interface{}) bool) interface{} {
+	if this == nil || ok == nil {
+		return nil
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	c := this.candidates(this.windowKey(key))
+	for {
+		id, more := c.next()
+		if !more {
+			return nil
+		}
+		if obj := this.value(id); ok(obj) {
+			return obj
+		}
+	}
+}
+
+// GetN returns n distinct objects for the key, in the order of the deterministic candidate
+// sequence, so the first one is the same as Get. It returns nil if n is not positive or is
+// greater than the number of objects in the hash, see GetUpToN for small clusters.
+// Every candidate is drawn over the virtual slots of the objects, so weights are honored for
+// the replicas too: the set is a weighted sample without replacement, in which heavier
+// objects are more likely to appear.
+func (this *Hash) GetN(key uint64, n int) []interface{} {
+	if this == nil {
+		return nil
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	return this.getN(key, n)
+}
+
+// GetUpToN is like GetN, but returns all objects if n is greater than the number of objects
+// in the hash, so callers need not clamp n for small clusters. ok reports whether n objects
+// were returned.
+func (this *Hash) GetUpToN(key uint64, n int) (a []interface{}, ok bool) {
+	if this == nil {
+		return nil, n <= 0
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	if n <= 0 {
+		return nil, true
+	}
+	if n > len(this.nodes) {
+		a = this.getN(key, len(this.nodes))
+		return a, false
+	}
+	a = this.getN(key, n)
+	return a, a != nil
+}
+
+// 调用方负责加锁
+func (this *Hash) getN(key uint64, n int) []interface{} {
+	if n <= 0 || n > len(this.nodes) || !this.ready() {
+		return nil
+	}
+
+	a := make([]interface{}, 0, n)
+	c := this.candidates(this.windowKey(key))
+	for len(a) < n {
+		id, _ := c.next()
+		a = append(a, this.value(id))
+	}
+	return a
+}
+
+func contains(a []interface{}, obj interface{}) bool {
+	for _, v := range a {
+		if v == obj {
+			return true
+		}
+	}
+	return false
+}
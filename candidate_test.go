@@ -0,0 +1,173 @@
+package doublejump
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHash_Candidates(t *testing.T) {
+	h := NewHashWithoutLock()
+	for i := 0; i < 100; i++ {
+		h.Add(i)
+	}
+	for i := 0; i < 100; i += 3 {
+		h.Remove(i)
+	}
+
+	for key := uint64(0); key < 100; key++ {
+		c := h.candidates(key)
+		m := make(map[interface{}]bool)
+		for {
+			obj, ok := c.next()
+			if !ok {
+				break
+			}
+			if m[obj] {
+				t.Fatalf("duplicated candidate. key: %d, obj: %v", key, obj)
+			}
+			if !h.Contains(obj) {
+				t.Fatalf("candidate is not in the hash. key: %d, obj: %v", key, obj)
+			}
+			m[obj] = true
+		}
+		if len(m) != h.Len() {
+			t.Fatalf("candidates should cover all the nodes. key: %d, len(m): %d, h.Len(): %d", key, len(m), h.Len())
+		}
+	}
+}
+
+func TestHash_GetExcluding(t *testing.T) {
+	h := NewHash()
+	if h.GetExcluding(0) != nil {
+		t.Fatal("GetExcluding should return nil when the hash has no node at all")
+	}
+
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+
+	for key := uint64(0); key < 1000; key++ {
+		obj := h.Get(key)
+		if h.GetExcluding(key) != obj {
+			t.Fatal("GetExcluding without exclusion should be the same as Get")
+		}
+
+		obj2 := h.GetExcluding(key, obj)
+		if obj2 == nil || obj2 == obj {
+			t.Fatalf("GetExcluding should avoid the excluded node. key: %d, obj: %v, obj2: %v", key, obj, obj2)
+		}
+		if h.GetExcluding(key, obj) != obj2 {
+			t.Fatal("GetExcluding should be deterministic")
+		}
+	}
+
+	all := make([]interface{}, 0, 10)
+	for i := 0; i < 10; i++ {
+		all = append(all, i)
+	}
+	if h.GetExcluding(0, all...) != nil {
+		t.Fatal("GetExcluding should return nil when all nodes are excluded")
+	}
+}
+
+func TestHash_GetWhere(t *testing.T) {
+	h := NewHash()
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+
+	even := func(obj interface{}) bool { return obj.(int)%2 == 0 }
+	for key := uint64(0); key < 1000; key++ {
+		obj := h.GetWhere(key, even)
+		if obj == nil || !even(obj) {
+			t.Fatalf("GetWhere should return a node satisfying the predicate. key: %d, obj: %v", key, obj)
+		}
+		if first := h.Get(key); even(first) && first != obj {
+			t.Fatalf("GetWhere should return the first candidate if it satisfies the predicate. key: %d", key)
+		}
+	}
+
+	if h.GetWhere(0, func(interface{}) bool { return false }) != nil {
+		t.Fatal("GetWhere should return nil when no node satisfies the predicate")
+	}
+}
+
+func TestHash_GetN(t *testing.T) {
+	h := NewHash()
+	if h.GetN(0, 1) != nil {
+		t.Fatal("GetN should return nil when the hash has no node at all")
+	}
+
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+	if h.GetN(0, 0) != nil || h.GetN(0, 11) != nil {
+		t.Fatal("GetN should return nil when n is out of range")
+	}
+
+	for key := uint64(0); key < 1000; key++ {
+		a := h.GetN(key, 3)
+		if len(a) != 3 || a[0] != h.Get(key) {
+			t.Fatalf("the first of GetN should be the same as Get. key: %d, a: %v", key, a)
+		}
+		if a[0] == a[1] || a[1] == a[2] || a[0] == a[2] {
+			t.Fatalf("GetN should return distinct nodes. key: %d, a: %v", key, a)
+		}
+		if h.GetExcluding(key, a[0]) != a[1] {
+			t.Fatal("the second of GetN should be the same as GetExcluding the first")
+		}
+	}
+}
+
+func TestHash_GetN_Weighted(t *testing.T) {
+	h := NewHash()
+	h.AddWeighted("a", 4)
+	for _, obj := range []string{"b", "c", "d", "e"} {
+		h.Add(obj)
+	}
+
+	// 不放回的加权抽样: a作为第二个副本的概率为 4/8 * 0 + 4/8 * 4/7
+	counts := make(map[interface{}]int)
+	for key := uint64(0); key < 100000; key++ {
+		a := h.GetN(key, 2)
+		if a[0] == a[1] {
+			t.Fatal("the replicas should be distinct")
+		}
+		if !reflect.DeepEqual(a, h.GetN(key, 2)) {
+			t.Fatal("the replicas should be deterministic")
+		}
+		counts[a[1]]++
+	}
+	if n := counts["a"]; n < 27000 || n > 30000 {
+		t.Fatalf("the weight of a is not honored. n: %d", n)
+	}
+	for _, obj := range []string{"b", "c", "d", "e"} {
+		if n := counts[obj]; n < 16500 || n > 19200 {
+			t.Fatalf("the weight of %s is not honored. n: %d", obj, n)
+		}
+	}
+}
+
+func TestHash_GetUpToN(t *testing.T) {
+	h := NewHash()
+	if a, ok := h.GetUpToN(0, 2); a != nil || ok {
+		t.Fatal("GetUpToN should return nothing when the hash has no node at all")
+	}
+	if a, ok := h.GetUpToN(0, 0); a != nil || !ok {
+		t.Fatal("GetUpToN should return nothing when n is not positive")
+	}
+
+	for i := 0; i < 3; i++ {
+		h.Add(i)
+	}
+	for key := uint64(0); key < 1000; key++ {
+		a, ok := h.GetUpToN(key, 5)
+		if ok || !reflect.DeepEqual(a, h.GetN(key, 3)) {
+			t.Fatalf("GetUpToN should return all nodes. key: %d, a: %v", key, a)
+		}
+		a, ok = h.GetUpToN(key, 2)
+		if !ok || !reflect.DeepEqual(a, h.GetN(key, 2)) {
+			t.Fatalf("GetUpToN should be the same as GetN. key: %d, a: %v", key, a)
+		}
+	}
+}
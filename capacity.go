@@ -0,0 +1,64 @@
+package doublejump
+
+// WithLoad sets the function reporting the current load of an object, e.g. its number of
+// in-flight requests. Together with SetCapacity, Get overflows keys from saturated objects.
+// load is called with the hash locked, so it must not call back into the hash.
+func WithLoad(load func(obj interface{}) int) Option {
+	return func(h *Hash) {
+		h.load = load
+	}
+}
+
+// SetCapacity sets the max load of the object, 0 means unlimited. When the object selected
+// for a key has reached its capacity, Get deterministically overflows to the next candidate
+// below its capacity, or still returns the selected object if all of them are saturated.
+// It returns false if the object is not in the hash.
+func (this *Hash) SetCapacity(obj interface{}, max int) bool {
+	if this == nil || obj == nil {
+		return false
+	}
+
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	n, ok := this.nodes[this.id(obj)]
+	if !ok {
+		return false
+	}
+	if max < 0 {
+		max = 0
+	}
+
+	if n.capacity > 0 {
+		this.capped--
+	}
+	if max > 0 {
+		this.capped++
+	}
+	n.capacity = max
+	this.retire()
+	return true
+}
+
+// Capacity returns the max load of the object, 0 means unlimited.
+func (this *Hash) Capacity(obj interface{}) int {
+	if this == nil || obj == nil {
+		return 0
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	if n, ok := this.nodes[this.id(obj)]; ok {
+		return n.capacity
+	}
+	return 0
+}
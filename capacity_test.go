@@ -0,0 +1,58 @@
+package doublejump
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestHash_Capacity(t *testing.T) {
+	var mu sync.Mutex
+	loads := make(map[interface{}]int)
+	h := NewHash(WithLoad(func(obj interface{}) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return loads[obj]
+	}))
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+
+	key := uint64(42)
+	a := h.GetN(key, 3)
+	if h.SetCapacity(100, 1) || !h.SetCapacity(a[0], 5) || h.Capacity(a[0]) != 5 {
+		t.Fatal("something is wrong with SetCapacity")
+	}
+
+	loads[a[0]] = 4
+	if h.Get(key) != a[0] {
+		t.Fatal("the node below its capacity should be selected")
+	}
+
+	loads[a[0]] = 5
+	if h.Get(key) != a[1] {
+		t.Fatalf("the key should overflow to the next candidate. obj: %v, want: %v", h.Get(key), a[1])
+	}
+
+	h.SetCapacity(a[1], 1)
+	loads[a[1]] = 1
+	if h.Get(key) != a[2] {
+		t.Fatalf("the key should overflow to the next unsaturated candidate. obj: %v, want: %v", h.Get(key), a[2])
+	}
+
+	for i := 0; i < 10; i++ {
+		h.SetCapacity(i, 1)
+		loads[i] = 1
+	}
+	if h.Get(key) != a[0] {
+		t.Fatal("the selected node should be returned when all nodes are saturated")
+	}
+
+	h.SetCapacity(a[0], 0)
+	if h.Get(key) != a[0] || h.capped != 9 {
+		t.Fatalf("the node without capacity should never be saturated. capped: %d", h.capped)
+	}
+	h.Remove(a[1])
+	if h.capped != 8 {
+		t.Fatalf("removing a capped node should update the counter. capped: %d", h.capped)
+	}
+}
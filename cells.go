@@ -0,0 +1,122 @@
+package doublejump
+
+import "sync"
+
+// 与外层的KEY去相关，否则落到同一个集群的KEY在内层也会集中到少数节点
+const cellSalt = 0x2545f4914f6cdd1d
+
+// Cells is a two-level hash for cell-based architectures: a key first selects a cell in the
+// outer hash, then an object within the cell in the inner hash of the cell. Both levels are
+// consistent, so adding or removing an object only moves keys within its cell, and adding
+// or removing a cell only moves keys to or from that cell. It is threadsafe.
+type Cells struct {
+	opts  []Option
+	outer *Hash
+
+	mu    sync.RWMutex
+	inner map[interface{}]*Hash
+}
+
+// NewCells creates an empty two-level hash. The options are applied to the inner hash of
+// every cell.
+func NewCells(opts ...Option) *Cells {
+	return &Cells{
+		opts:  opts,
+		outer: NewHash(),
+		inner: make(map[interface{}]*Hash),
+	}
+}
+
+// AddCell adds a cell without objects. It reports whether the cell was not there yet. Keys
+// selecting an empty cell get nil, they are not moved to other cells.
+func (this *Cells) AddCell(cell interface{}) bool {
+	if cell == nil {
+		return false
+	}
+
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	return this.addCell(cell) != nil
+}
+
+// 调用方持有mu，返回新加入的内层哈希，已经存在时返回nil
+func (this *Cells) addCell(cell interface{}) *Hash {
+	if _, ok := this.inner[cell]; ok {
+		return nil
+	}
+	h := NewHash(this.opts...)
+	this.inner[cell] = h
+	this.outer.Add(cell)
+	return h
+}
+
+// RemoveCell removes a cell together with its objects. It reports whether the cell was there.
+func (this *Cells) RemoveCell(cell interface{}) bool {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	if _, ok := this.inner[cell]; !ok {
+		return false
+	}
+	delete(this.inner, cell)
+	this.outer.Remove(cell)
+	return true
+}
+
+// Cell returns the inner hash of the cell, or nil if there is no such cell. Objects can
+// be managed directly through it.
+func (this *Cells) Cell(cell interface{}) *Hash {
+	this.mu.RLock()
+	defer this.mu.RUnlock()
+
+	return this.inner[cell]
+}
+
+// Add adds an object to the cell, adding the cell first if it is not there. It reports
+// whether the object was added.
+func (this *Cells) Add(cell, obj interface{}) bool {
+	if cell == nil || obj == nil {
+		return false
+	}
+
+	this.mu.Lock()
+	h, ok := this.inner[cell]
+	if !ok {
+		h = this.addCell(cell)
+	}
+	this.mu.Unlock()
+
+	return h.Add(obj)
+}
+
+// Remove removes an object from the cell, which is kept even if it becomes empty. It reports
+// whether the object was in the cell.
+func (this *Cells) Remove(cell, obj interface{}) bool {
+	return this.Cell(cell).Remove(obj)
+}
+
+// Cells returns all cells, see Hash.Nodes.
+func (this *Cells) Cells() []interface{} {
+	return this.outer.Nodes()
+}
+
+// Get returns an object according to the key provided.
+func (this *Cells) Get(key uint64) interface{} {
+	_, obj := this.GetCell(key)
+	return obj
+}
+
+// GetCell is like Get, but also returns the cell selected for the key. obj is nil if the
+// cell is empty, cell is nil if there is no cell.
+func (this *Cells) GetCell(key uint64) (cell, obj interface{}) {
+	if this == nil {
+		return nil, nil
+	}
+
+	cell = this.outer.Get(key)
+	if cell == nil {
+		return nil, nil
+	}
+	return cell, this.Cell(cell).Get(SplitMix64(key ^ cellSalt))
+}
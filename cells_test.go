@@ -0,0 +1,64 @@
+package doublejump
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCells(t *testing.T) {
+	c := NewCells()
+	if c.Get(1) != nil {
+		t.Fatal("c.Get(1) != nil")
+	}
+
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 5; j++ {
+			c.Add(i, fmt.Sprintf("%d-%d", i, j))
+		}
+	}
+	if len(c.Cells()) != 4 || c.Cell(0).Len() != 5 {
+		t.Fatal("there should be 4 cells of 5 nodes")
+	}
+
+	counts := make(map[interface{}]int)
+	before := make([]interface{}, 20000)
+	for i := range before {
+		cell, obj := c.GetCell(uint64(i))
+		if obj.(string)[:1] != fmt.Sprint(cell) {
+			t.Fatalf("%v is not in cell %v", obj, cell)
+		}
+		before[i] = obj
+		counts[obj]++
+	}
+	if len(counts) != 20 {
+		t.Fatal("len(counts) != 20")
+	}
+	for obj, n := range counts {
+		if n < 700 || n > 1300 {
+			t.Fatalf("%v is not balanced. n: %d", obj, n)
+		}
+	}
+
+	// 删除节点只影响该节点上的KEY
+	c.Remove(1, "1-0")
+	for i, obj := range before {
+		if obj != "1-0" && c.Get(uint64(i)) != obj {
+			t.Fatalf("key %d should stay on %v", i, obj)
+		}
+	}
+
+	c.RemoveCell(1)
+	if c.Cell(1) != nil || len(c.Cells()) != 3 {
+		t.Fatal("cell 1 should be removed")
+	}
+	for i, obj := range before {
+		if obj.(string)[:1] != "1" && c.Get(uint64(i)) != obj {
+			t.Fatalf("key %d should stay on %v", i, obj)
+		}
+	}
+
+	c.AddCell(9)
+	if cell, obj := c.GetCell(0); cell == 9 && obj != nil {
+		t.Fatal("an empty cell should select nil")
+	}
+}
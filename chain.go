@@ -0,0 +1,27 @@
+package doublejump
+
+// 依次查询的多个选择器
+type chain []Selector
+
+// Chain returns a selector which consults the selectors in order and returns the first
+// object selected, e.g. to prefer the ring of the local region and fall back to the global
+// ring when the local one is empty or not ready. Nil selectors are skipped.
+func Chain(primary Selector, fallbacks ...Selector) Selector {
+	c := make(chain, 0, 1+len(fallbacks))
+	for _, s := range append([]Selector{primary}, fallbacks...) {
+		if s != nil {
+			c = append(c, s)
+		}
+	}
+	return c
+}
+
+// Get returns an object according to the key provided.
+func (this chain) Get(key uint64) interface{} {
+	for _, s := range this {
+		if obj := s.Get(key); obj != nil {
+			return obj
+		}
+	}
+	return nil
+}
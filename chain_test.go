@@ -0,0 +1,33 @@
+package doublejump
+
+import "testing"
+
+func TestChain(t *testing.T) {
+	local := NewHash(WithMinNodes(2))
+	global := NewHash()
+	global.Add("g1")
+	global.Add("g2")
+
+	var missing *Hash
+	c := Chain(local, nil, missing, global)
+	for key := uint64(0); key < 1000; key++ {
+		if c.Get(key) != global.Get(key) {
+			t.Fatalf("c.Get(%d) != global.Get(%d)", key, key)
+		}
+	}
+
+	local.Add("l1")
+	if obj := c.Get(0); obj != global.Get(0) {
+		t.Fatalf("the local ring is not ready. obj: %v", obj)
+	}
+	local.Add("l2")
+	for key := uint64(0); key < 1000; key++ {
+		if c.Get(key) != local.Get(key) {
+			t.Fatalf("c.Get(%d) != local.Get(%d)", key, key)
+		}
+	}
+
+	if Chain(nil).Get(0) != nil || Chain(NewHash(), NewHash()).Get(0) != nil {
+		t.Fatal("an empty chain should select nothing")
+	}
+}
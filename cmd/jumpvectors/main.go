@@ -0,0 +1,69 @@
+// Command jumpvectors emits test vectors of the doublejump core algorithm as JSON, for
+// checking implementations of the same placement in other languages.
+//
+// It adds the nodes in order, removes the given ones, then selects the nodes for keys
+// generated by SplitMix64 from the seed:
+//
+//	jumpvectors -nodes a,b,c,d,e -remove b,d -keys 1000 -seed 1 > vectors.json
+//
+// The output records the operations together with doublejump.TestVectors, so that another
+// implementation can either replay the operations or load the layout directly.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gnat88/doublejump"
+)
+
+type output struct {
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"`
+	Seed   uint64   `json:"seed,string"`
+	*doublejump.TestVectors
+}
+
+func split(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func main() {
+	nodes := flag.String("nodes", "a,b,c,d,e,f,g,h", "comma-separated nodes to add in order")
+	remove := flag.String("remove", "b,e", "comma-separated nodes to remove after adding")
+	n := flag.Int("keys", 100, "number of keys")
+	seed := flag.Uint64("seed", 0, "seed of the keys")
+	flag.Parse()
+
+	out := output{Add: split(*nodes), Remove: split(*remove), Seed: *seed}
+	h := doublejump.NewHashWithoutLock()
+	for _, s := range out.Add {
+		h.Add(s)
+	}
+	for _, s := range out.Remove {
+		h.Remove(s)
+	}
+
+	keys := make([]uint64, *n)
+	for i := range keys {
+		keys[i] = doublejump.SplitMix64(*seed + uint64(i))
+	}
+	out.TestVectors = h.TestVectors(keys)
+	if out.TestVectors == nil {
+		fmt.Fprintln(os.Stderr, "jumpvectors: no node left")
+		os.Exit(1)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		fmt.Fprintln(os.Stderr, "jumpvectors:", err)
+		os.Exit(1)
+	}
+}
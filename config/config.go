@@ -0,0 +1,291 @@
+// Package config maintains a doublejump hash from a configuration file listing the nodes,
+// reloading it when the file changes, so simple deployments get a dynamic topology without
+// a discovery system.
+//
+// The file is either JSON:
+//
+//	{"nodes": ["10.0.0.1:80", "10.0.0.2:80"]}
+//
+// or, if its name ends with .yaml or .yml, the following subset of YAML:
+//
+//	# comments are allowed
+//	nodes:
+//	  - 10.0.0.1:80
+//	  - "10.0.0.2:80"
+//
+// Changes are detected by polling the modification time and size of the file, which works
+// on every platform and file system, including mounted config maps, without any dependency.
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gnat88/doublejump"
+)
+
+// File is the content of a configuration file.
+type File struct {
+	// Nodes lists the objects of the hash, usually addresses of hosts.
+	Nodes []string `json:"nodes"`
+}
+
+// ErrNoNodes is returned when a configuration file lists no nodes. Such a file is treated
+// as broken rather than as a request to empty the hash.
+var ErrNoNodes = errors.New("config: no nodes")
+
+// ErrBadInterval is returned by Watch when the interval is not positive.
+var ErrBadInterval = errors.New("config: interval must be positive")
+
+// Load reads and parses the configuration file at path.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data, filepath.Ext(path))
+}
+
+// Parse parses the content of a configuration file. ext is the extension of the file name,
+// which selects the format: ".yaml" and ".yml" for YAML, otherwise JSON.
+func Parse(data []byte, ext string) (*File, error) {
+	var f File
+	switch strings.ToLower(ext) {
+	case ".yaml", ".yml":
+		nodes, err := parseYAML(data)
+		if err != nil {
+			return nil, err
+		}
+		f.Nodes = nodes
+	default:
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("config: %v", err)
+		}
+	}
+
+	if len(f.Nodes) == 0 {
+		return nil, ErrNoNodes
+	}
+	return &f, nil
+}
+
+// 只支持nodes列表
+func parseYAML(data []byte) ([]string, error) {
+	var nodes []string
+	inNodes := false
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; s.Scan(); n++ {
+		line := stripComment(s.Text())
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") && !strings.HasPrefix(line, "-") {
+			i := strings.Index(trimmed, ":")
+			if i < 0 {
+				return nil, fmt.Errorf("config: line %d: expect a key", n)
+			}
+			key, value := strings.TrimSpace(trimmed[:i]), strings.TrimSpace(trimmed[i+1:])
+			inNodes = key == "nodes"
+			if inNodes && value != "" {
+				return nil, fmt.Errorf("config: line %d: nodes must be a block list", n)
+			}
+			continue
+		}
+		if !inNodes {
+			continue
+		}
+
+		if !strings.HasPrefix(trimmed, "-") {
+			return nil, fmt.Errorf("config: line %d: expect a list item", n)
+		}
+		item := strings.TrimSpace(trimmed[1:])
+		if strings.HasPrefix(item, `"`) || strings.HasPrefix(item, "'") {
+			unquoted, err := unquote(item)
+			if err != nil {
+				return nil, fmt.Errorf("config: line %d: %v", n, err)
+			}
+			item = unquoted
+		}
+		if item != "" {
+			nodes = append(nodes, item)
+		}
+	}
+	return nodes, s.Err()
+}
+
+// 与YAML一样，只有引号之外、行首或空白之后的#才开始注释，引号也只在行首或空白之后开始
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		start := i == 0 || line[i-1] == ' ' || line[i-1] == '\t'
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case start && (c == '"' || c == '\''):
+			quote = c
+		case start && c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func unquote(s string) (string, error) {
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+	return strconv.Unquote(s)
+}
+
+// Watcher maintains a hash from a configuration file.
+type Watcher struct {
+	path string
+	hash *doublejump.Hash
+
+	mu    sync.Mutex
+	mod   time.Time
+	size  int64
+	err   error
+	nodes []string
+
+	once sync.Once
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Watch loads the configuration file at path into a new threadsafe hash created with
+// opts, then checks the file every interval and reconciles the membership of the hash
+// when the file changes. If a changed file cannot be loaded, the hash keeps its current
+// membership and the error is reported by Err. Call Close to stop watching. It returns
+// ErrBadInterval if interval <= 0.
+func Watch(path string, interval time.Duration, opts ...doublejump.Option) (*Watcher, error) {
+	if interval <= 0 {
+		return nil, ErrBadInterval
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]interface{}, len(f.Nodes))
+	for i, s := range f.Nodes {
+		nodes[i] = s
+	}
+	w := &Watcher{
+		path:  path,
+		hash:  doublejump.NewBuilder(opts...).Add(nodes...).Build(),
+		mod:   info.ModTime(),
+		size:  info.Size(),
+		nodes: f.Nodes,
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go w.loop(interval)
+	return w, nil
+}
+
+// Hash returns the hash maintained by the watcher.
+func (this *Watcher) Hash() *doublejump.Hash {
+	return this.hash
+}
+
+// Nodes returns the nodes listed by the last successfully loaded configuration.
+func (this *Watcher) Nodes() []string {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return append([]string(nil), this.nodes...)
+}
+
+// Err returns the error of the last reload, or nil if it succeeded.
+func (this *Watcher) Err() error {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.err
+}
+
+// Reload loads the configuration file immediately, regardless of whether it changed.
+func (this *Watcher) Reload() error {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	info, err := os.Stat(this.path)
+	if err == nil {
+		this.mod, this.size = info.ModTime(), info.Size()
+	}
+	return this.reload()
+}
+
+// 调用方负责加锁
+func (this *Watcher) reload() error {
+	f, err := Load(this.path)
+	this.err = err
+	if err != nil {
+		return err
+	}
+
+	nodes := make([]interface{}, len(f.Nodes))
+	for i, s := range f.Nodes {
+		nodes[i] = s
+	}
+	this.hash.Set(nodes)
+	this.nodes = f.Nodes
+	return nil
+}
+
+// Close stops watching the configuration file. The hash stays usable.
+func (this *Watcher) Close() {
+	this.once.Do(func() { close(this.stop) })
+	<-this.done
+}
+
+func (this *Watcher) loop(interval time.Duration) {
+	defer close(this.done)
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-this.stop:
+			return
+		case <-t.C:
+			this.check()
+		}
+	}
+}
+
+// 文件的修改时间或大小变化时重新加载
+func (this *Watcher) check() {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	info, err := os.Stat(this.path)
+	if err != nil {
+		this.err = err
+		return
+	}
+	if info.ModTime().Equal(this.mod) && info.Size() == this.size {
+		return
+	}
+	this.mod, this.size = info.ModTime(), info.Size()
+	this.reload()
+}
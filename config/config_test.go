@@ -0,0 +1,132 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	f, err := Parse([]byte(`{"nodes": ["a", "b"]}`), ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(f.Nodes, []string{"a", "b"}) {
+		t.Fatalf("unexpected nodes: %v", f.Nodes)
+	}
+
+	yaml := `
+# the ring
+name: x
+nodes:
+  - 10.0.0.1:80   # primary
+  - "10.0.0.2:80"
+  - 'it''s'
+  - "host#1:80" # quoted
+  - 'a # b'
+  - host#2:80
+  - it's #3
+other:
+  - ignored
+`
+	f, err = Parse([]byte(yaml), ".YAML")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(f.Nodes, []string{"10.0.0.1:80", "10.0.0.2:80", "it's", "host#1:80", "a # b", "host#2:80", "it's"}) {
+		t.Fatalf("unexpected nodes: %v", f.Nodes)
+	}
+
+	if _, err := Parse([]byte(`{"nodes": []}`), ".json"); err != ErrNoNodes {
+		t.Fatal("an empty node list should be rejected")
+	}
+	if _, err := Parse([]byte(`{`), ".json"); err == nil {
+		t.Fatal("broken JSON should be rejected")
+	}
+	if _, err := Parse([]byte("nodes:\n  a\n"), ".yml"); err == nil {
+		t.Fatal("broken YAML should be rejected")
+	}
+}
+
+func write(t *testing.T, path, content string, mod time.Time) {
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// 显式设置修改时间，避免文件系统时间精度不够导致检测不到变化
+	if err := os.Chtimes(path, mod, mod); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ring.json")
+	mod := time.Now().Add(-time.Hour)
+	write(t, path, `{"nodes": ["a", "b", "c"]}`, mod)
+
+	w, err := Watch(path, 5*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	h := w.Hash()
+	if h.Len() != 3 {
+		t.Fatal("h.Len() != 3")
+	}
+
+	write(t, path, `{"nodes": ["a", "c", "d", "e"]}`, mod.Add(time.Second))
+	deadline := time.Now().Add(5 * time.Second)
+	for h.Len() != 4 || !h.Contains("e") {
+		if time.Now().After(deadline) {
+			t.Fatal("the change of the file is not picked up")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if h.Contains("b") {
+		t.Fatal("b should be removed")
+	}
+
+	// 文件损坏时保持当前的节点
+	write(t, path, `{"nodes": [`, mod.Add(2*time.Second))
+	if err := w.Reload(); err == nil {
+		t.Fatal("w.Reload() should fail")
+	}
+	if w.Err() == nil {
+		t.Fatal("w.Err() == nil")
+	}
+	if h.Len() != 4 {
+		t.Fatal("h.Len() != 4")
+	}
+	if !reflect.DeepEqual(w.Nodes(), []string{"a", "c", "d", "e"}) {
+		t.Fatalf("unexpected nodes: %v", w.Nodes())
+	}
+
+	write(t, path, `{"nodes": ["a"]}`, mod.Add(3*time.Second))
+	if err := w.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if w.Err() != nil || h.Len() != 1 {
+		t.Fatal("w.Reload() should recover")
+	}
+
+	w.Close()
+	w.Close()
+}
+
+func TestWatchMissing(t *testing.T) {
+	if _, err := Watch(filepath.Join(t.TempDir(), "missing.json"), time.Second); err == nil {
+		t.Fatal("Watch should fail on a missing file")
+	}
+}
+
+func TestWatchBadInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ring.json")
+	write(t, path, `{"nodes": ["a"]}`, time.Now())
+	for _, d := range []time.Duration{0, -time.Second} {
+		if _, err := Watch(path, d); err != ErrBadInterval {
+			t.Fatalf("Watch should return ErrBadInterval. interval: %v, err: %v", d, err)
+		}
+	}
+}
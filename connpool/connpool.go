@@ -0,0 +1,236 @@
+// Package connpool keeps a pool of net.Conn per object of a doublejump hash, so that
+// topology and connections are managed in one place:
+//
+//	ps := connpool.New(h, func(ctx context.Context, node interface{}) (net.Conn, error) {
+//		return dialer.DialContext(ctx, "tcp", node.(string))
+//	}, connpool.Options{MaxIdle: 4})
+//	conn, err := ps.ConnFor(ctx, key)
+//	...
+//	conn.Close() // back to the pool of its node
+//
+// When an object is removed from the hash, its pool is drained: the idle connections are
+// closed at once, and the connections in use are closed when they are handed back.
+package connpool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/gnat88/doublejump"
+)
+
+// ErrNoNode is returned by ConnFor when the hash has no object for the key.
+var ErrNoNode = errors.New("connpool: no node")
+
+// ErrClosed is returned by ConnFor once the pool set is closed.
+var ErrClosed = errors.New("connpool: closed")
+
+// DialFunc opens a connection to an object of the hash.
+type DialFunc func(ctx context.Context, node interface{}) (net.Conn, error)
+
+// Options configures a PoolSet.
+type Options struct {
+	// MaxIdle is the maximum number of idle connections kept per object, 2 by default.
+	MaxIdle int
+}
+
+// PoolSet maintains a pool of connections per object of the hash. It is threadsafe.
+type PoolSet struct {
+	hash    *doublejump.Hash
+	dial    DialFunc
+	maxIdle int
+	cancel  func()
+
+	mu     sync.Mutex
+	pools  map[interface{}]*pool // 以节点在哈希中的标识为键
+	closed bool
+}
+
+type pool struct {
+	mu     sync.Mutex
+	idle   []net.Conn
+	inUse  int
+	closed bool
+}
+
+// Stats counts the connections of the pool of an object.
+type Stats struct {
+	Idle  int
+	InUse int
+}
+
+// New creates a pool set over the objects of h, opening connections with dial. The pools are
+// keyed by Hash.ID, so the objects must be comparable, or h must have WithKeyFunc.
+func New(h *doublejump.Hash, dial DialFunc, opts Options) *PoolSet {
+	if opts.MaxIdle <= 0 {
+		opts.MaxIdle = 2
+	}
+
+	this := &PoolSet{
+		hash:    h,
+		dial:    dial,
+		maxIdle: opts.MaxIdle,
+		pools:   make(map[interface{}]*pool),
+	}
+	this.cancel = h.Subscribe(func(ev doublejump.Event) {
+		if ev.Type == doublejump.EventRemove {
+			this.drop(h.ID(ev.Node))
+		}
+	})
+	return this
+}
+
+// ConnFor returns a connection to the object selected for the key, an idle one of its pool
+// if there is any, otherwise a new one. Closing the connection hands it back to the pool.
+func (this *PoolSet) ConnFor(ctx context.Context, key uint64) (*Conn, error) {
+	node := this.hash.Get(key)
+	if node == nil {
+		return nil, ErrNoNode
+	}
+	id := this.hash.ID(node)
+	p, created, err := this.pool(id)
+	if err != nil {
+		return nil, err
+	}
+	// 节点可能在Get之后被删除了，此时删除的事件已经处理过
+	if created && !this.hash.Contains(node) {
+		this.drop(id)
+	}
+
+	p.mu.Lock()
+	if n := len(p.idle); n > 0 && !p.closed {
+		c := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.inUse++
+		p.mu.Unlock()
+		return &Conn{Conn: c, pool: p, Node: node, set: this}, nil
+	}
+	p.inUse++
+	p.mu.Unlock()
+
+	c, err := this.dial(ctx, node)
+	if err != nil {
+		p.mu.Lock()
+		p.inUse--
+		p.mu.Unlock()
+		return nil, err
+	}
+	return &Conn{Conn: c, pool: p, Node: node, set: this}, nil
+}
+
+// 不能在持有mu时访问哈希，删除事件的回调在哈希加锁期间获取mu
+func (this *PoolSet) pool(id interface{}) (p *pool, created bool, err error) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	if this.closed {
+		return nil, false, ErrClosed
+	}
+	p, ok := this.pools[id]
+	if !ok {
+		p = &pool{}
+		this.pools[id] = p
+	}
+	return p, !ok, nil
+}
+
+// 节点已从哈希中删除，关闭空闲连接，正在使用的连接归还时关闭
+func (this *PoolSet) drop(id interface{}) {
+	this.mu.Lock()
+	p, ok := this.pools[id]
+	delete(this.pools, id)
+	this.mu.Unlock()
+
+	if ok {
+		p.close()
+	}
+}
+
+// Stats returns the counters of the pools by the Hash.ID of their objects.
+func (this *PoolSet) Stats() map[interface{}]Stats {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	m := make(map[interface{}]Stats, len(this.pools))
+	for id, p := range this.pools {
+		p.mu.Lock()
+		m[id] = Stats{Idle: len(p.idle), InUse: p.inUse}
+		p.mu.Unlock()
+	}
+	return m
+}
+
+// Close stops following the hash and drains all pools.
+func (this *PoolSet) Close() error {
+	this.cancel()
+
+	this.mu.Lock()
+	pools := this.pools
+	this.pools = make(map[interface{}]*pool)
+	this.closed = true
+	this.mu.Unlock()
+
+	for _, p := range pools {
+		p.close()
+	}
+	return nil
+}
+
+func (this *pool) close() {
+	this.mu.Lock()
+	idle := this.idle
+	this.idle = nil
+	this.closed = true
+	this.mu.Unlock()
+
+	for _, c := range idle {
+		c.Close()
+	}
+}
+
+// Conn is a connection of a pool.
+type Conn struct {
+	net.Conn
+	// Node is the object the connection is opened to.
+	Node interface{}
+
+	set  *PoolSet
+	pool *pool
+	done bool
+}
+
+// Close hands the connection back to the pool of its object, or closes it if the pool is
+// full or drained. Calling it again does nothing.
+func (this *Conn) Close() error {
+	if this.done {
+		return nil
+	}
+	this.done = true
+
+	p := this.pool
+	p.mu.Lock()
+	p.inUse--
+	if !p.closed && len(p.idle) < this.set.maxIdle {
+		p.idle = append(p.idle, this.Conn)
+		p.mu.Unlock()
+		return nil
+	}
+	p.mu.Unlock()
+	return this.Conn.Close()
+}
+
+// Discard closes the connection instead of handing it back, e.g. after an I/O error.
+func (this *Conn) Discard() error {
+	if this.done {
+		return nil
+	}
+	this.done = true
+
+	p := this.pool
+	p.mu.Lock()
+	p.inUse--
+	p.mu.Unlock()
+	return this.Conn.Close()
+}
@@ -0,0 +1,143 @@
+package connpool
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gnat88/doublejump"
+)
+
+type dialer struct {
+	mu     sync.Mutex
+	dialed map[interface{}]int
+	peers  []net.Conn
+}
+
+func (this *dialer) dial(ctx context.Context, node interface{}) (net.Conn, error) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	this.dialed[node]++
+	c, peer := net.Pipe()
+	this.peers = append(this.peers, peer)
+	return c, nil
+}
+
+// 空闲的管道写入时会超时，已关闭的管道返回io.ErrClosedPipe
+func closed(c net.Conn) bool {
+	c.SetWriteDeadline(time.Now())
+	_, err := c.Write([]byte{0})
+	return errors.Is(err, io.ErrClosedPipe)
+}
+
+func TestPoolSet(t *testing.T) {
+	h := doublejump.NewHash()
+	ctx := context.Background()
+	d := &dialer{dialed: make(map[interface{}]int)}
+	ps := New(h, d.dial, Options{MaxIdle: 1})
+	defer ps.Close()
+
+	if _, err := ps.ConnFor(ctx, 1); err != ErrNoNode {
+		t.Fatalf("ConnFor should return ErrNoNode. err: %v", err)
+	}
+
+	h.Add("a")
+	h.Add("b")
+	var key uint64
+	for h.Get(key) != "a" {
+		key++
+	}
+
+	c1, err := ps.ConnFor(ctx, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, _ := ps.ConnFor(ctx, key)
+	if c1.Node != "a" || d.dialed["a"] != 2 {
+		t.Fatal("two connections should be dialed to a")
+	}
+	if s := ps.Stats()["a"]; s.InUse != 2 || s.Idle != 0 {
+		t.Fatalf("unexpected stats: %+v", s)
+	}
+
+	c1.Close()
+	c2.Close()
+	if s := ps.Stats()["a"]; s.InUse != 0 || s.Idle != 1 {
+		t.Fatalf("unexpected stats: %+v", s)
+	}
+	if !closed(c2.Conn) {
+		t.Fatal("the connection beyond MaxIdle should be closed")
+	}
+
+	c3, _ := ps.ConnFor(ctx, key)
+	if c3.Conn != c1.Conn || d.dialed["a"] != 2 {
+		t.Fatal("the idle connection should be reused")
+	}
+	c4, _ := ps.ConnFor(ctx, key)
+	c4.Close()
+
+	// 删除节点后排空它的连接池
+	h.Remove("a")
+	if _, ok := ps.Stats()["a"]; ok {
+		t.Fatal("the pool of a should be dropped")
+	}
+	if !closed(c4.Conn) {
+		t.Fatal("the idle connection of a should be closed")
+	}
+	if closed(c3.Conn) {
+		t.Fatal("the connection in use should be kept until it is handed back")
+	}
+	c3.Close()
+	if !closed(c3.Conn) {
+		t.Fatal("the connection of a should be closed when handed back")
+	}
+
+	c5, _ := ps.ConnFor(ctx, key)
+	if c5.Node != "b" {
+		t.Fatal("the key should move to b")
+	}
+	c5.Discard()
+	if !closed(c5.Conn) || ps.Stats()["b"].Idle != 0 {
+		t.Fatal("a discarded connection should be closed")
+	}
+
+	ps.Close()
+	if _, err := ps.ConnFor(ctx, key); err != ErrClosed {
+		t.Fatalf("ConnFor should return ErrClosed. err: %v", err)
+	}
+}
+
+func TestPoolSet_KeyFunc(t *testing.T) {
+	type server struct {
+		addr string
+		tags []string
+	}
+	h := doublejump.NewHash(doublejump.WithKeyFunc(func(obj interface{}) interface{} {
+		return obj.(server).addr
+	}))
+	ps := New(h, func(ctx context.Context, node interface{}) (net.Conn, error) {
+		c, _ := net.Pipe()
+		return c, nil
+	}, Options{})
+	defer ps.Close()
+
+	// 不可比较的节点按WithKeyFunc的标识分池
+	h.Add(server{addr: "a", tags: []string{"x"}})
+	c, err := ps.ConnFor(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+	if s := ps.Stats()["a"]; s.Idle != 1 {
+		t.Fatalf("the pool should be keyed by the ID. stats: %v", ps.Stats())
+	}
+	h.Remove(server{addr: "a"})
+	if len(ps.Stats()) != 0 {
+		t.Fatal("removing the node should drop its pool")
+	}
+}
@@ -0,0 +1,129 @@
+package doublejump
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// String implements fmt.Stringer.
+func (this *Hash) String() string {
+	if this == nil {
+		return "doublejump.Hash(nil)"
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	return fmt.Sprintf("doublejump.Hash{len: %d, looseLen: %d, empty: %d}",
+		len(this.nodes), len(this.loose.a), len(this.loose.emptyPoses))
+}
+
+// Dump writes the inner state of the hash to w: the loose holder including empty slots,
+// the compact holder, and their indices. It is meant for diagnosing key-routing surprises.
+func (this *Hash) Dump(w io.Writer) error {
+	if this == nil {
+		_, err := fmt.Fprintln(w, "doublejump.Hash(nil)")
+		return err
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "loose: len %d, empty %d\n", len(this.loose.a), len(this.loose.emptyPoses))
+	for i, slot := range this.loose.a {
+		if slot == nil {
+			fmt.Fprintf(bw, "  [%d] <empty>\n", i)
+		} else {
+			fmt.Fprintf(bw, "  [%d] %s\n", i, this.slotString(slot))
+		}
+	}
+	fmt.Fprintf(bw, "empty positions: %v\n", this.loose.emptyPoses)
+
+	fmt.Fprintf(bw, "compact: len %d\n", len(this.compact.a))
+	for i, slot := range this.compact.a {
+		fmt.Fprintf(bw, "  [%d] %s (loose %d)\n", i, this.slotString(slot), this.loose.m[slot])
+	}
+
+	if len(this.pins) > 0 {
+		keys := make([]uint64, 0, len(this.pins))
+		for key := range this.pins {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+		fmt.Fprintf(bw, "pins: %d\n", len(this.pins))
+		for _, key := range keys {
+			fmt.Fprintf(bw, "  %d -> %v\n", key, this.value(this.pins[key]))
+		}
+	}
+	return bw.Flush()
+}
+
+// 虚拟位置输出为"节点 #序号"
+func (this *Hash) slotString(slot interface{}) string {
+	if v, ok := slot.(vslot); ok {
+		return fmt.Sprintf("%v #%d", this.value(v.id), v.i)
+	}
+	return fmt.Sprint(this.value(slot))
+}
+
+// ExportDOT writes the topology of the hash to w in Graphviz DOT format. The ownership of
+// each object is estimated by sampling sampleKeys keys, and the objects are sized by it,
+// while empty slots of the loose holder are highlighted.
+func (this *Hash) ExportDOT(w io.Writer, sampleKeys int) error {
+	if this == nil {
+		return nil
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	owned := make(map[interface{}]int, len(this.nodes))
+	for i := 0; i < sampleKeys; i++ {
+		owned[this.getID(uint64(i)*0x9e3779b97f4a7c15)]++
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph doublejump {")
+	fmt.Fprintln(bw, "  rankdir=LR;")
+	fmt.Fprintln(bw, "  node [shape=circle, fixedsize=true];")
+	for i, slot := range this.loose.a {
+		if slot == nil {
+			fmt.Fprintf(bw, "  s%d [label=\"%d: empty\", width=0.6, style=dashed, color=red, fontcolor=red];\n", i, i)
+			continue
+		}
+		if !isPrimary(slot) {
+			fmt.Fprintf(bw, "  s%d [label=%q, width=0.6, style=dotted];\n", i, fmt.Sprintf("%d: %s", i, this.slotString(slot)))
+			continue
+		}
+
+		share := 0.0
+		if sampleKeys > 0 {
+			share = float64(owned[slot]) / float64(sampleKeys)
+		}
+		// 以平均占比为基准缩放节点大小
+		width := 0.6 + 1.2*share*float64(len(this.nodes))
+		label := fmt.Sprintf("%d: %v\n%.1f%%", i, this.value(slot), share*100)
+		fmt.Fprintf(bw, "  s%d [label=%q, width=%.2f];\n", i, label, width)
+	}
+	for i := 1; i < len(this.loose.a); i++ {
+		fmt.Fprintf(bw, "  s%d -> s%d [style=invis];\n", i-1, i)
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
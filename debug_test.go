@@ -0,0 +1,63 @@
+package doublejump
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestHash_String(t *testing.T) {
+	h := NewHash()
+	h.Add("a")
+	h.Add("b")
+	h.Remove("a")
+	if s := h.String(); s != "doublejump.Hash{len: 1, looseLen: 2, empty: 1}" {
+		t.Fatalf("h.String() is wrong. s: %s", s)
+	}
+
+	var h2 *Hash
+	if h2.String() != "doublejump.Hash(nil)" {
+		t.Fatal("String should handle nil hash")
+	}
+}
+
+func TestHash_Dump(t *testing.T) {
+	h := NewHash()
+	h.Add("a")
+	h.Add("b")
+	h.Add("c")
+	h.Remove("b")
+
+	var buf bytes.Buffer
+	if err := h.Dump(&buf); err != nil {
+		t.Fatal(err)
+	}
+	s := buf.String()
+	for _, want := range []string{"loose: len 3, empty 1", "[1] <empty>", "compact: len 2", "[1] c (loose 2)"} {
+		if !strings.Contains(s, want) {
+			t.Fatalf("h.Dump should contain %q. s: %s", want, s)
+		}
+	}
+}
+
+func TestHash_ExportDOT(t *testing.T) {
+	h := NewHash()
+	h.Add("a")
+	h.Add("b")
+	h.Add("c")
+	h.Remove("b")
+
+	var buf bytes.Buffer
+	if err := h.ExportDOT(&buf, 10000); err != nil {
+		t.Fatal(err)
+	}
+	s := buf.String()
+	if !strings.HasPrefix(s, "digraph doublejump {") || !strings.HasSuffix(s, "}\n") {
+		t.Fatalf("h.ExportDOT should output a digraph. s: %s", s)
+	}
+	for _, want := range []string{`s1 [label="1: empty"`, `label="0: a\n`, `label="2: c\n`} {
+		if !strings.Contains(s, want) {
+			t.Fatalf("h.ExportDOT should contain %q. s: %s", want, s)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package doublejump
+
+import (
+	"hash/maphash"
+	"math/bits"
+)
+
+// Digest is a string digest algorithm turning the keys of GetString and GetBytes into
+// uint64 keys, see WithDigest. Systems sharing keys with the hash often dictate the digest.
+type Digest int
+
+const (
+	// DigestFNV1a is the 64-bit FNV-1a hash, the same as hash/fnv.New64a. It is the default.
+	DigestFNV1a Digest = iota
+	// DigestXXHash is the 64-bit XXH64 hash with seed 0, the same as the Sum64 of
+	// github.com/cespare/xxhash.
+	DigestXXHash
+	// DigestMurmur3 is the first half of the 128-bit x64 MurmurHash3 with seed 0, the same
+	// as the Sum64 of github.com/spaolacci/murmur3.
+	DigestMurmur3
+	// DigestMapHash is hash/maphash with a seed chosen when the hash is created. It is the
+	// fastest, but only stable within the hash: another hash or process maps the same
+	// string to another key.
+	DigestMapHash
+)
+
+func (this Digest) String() string {
+	switch this {
+	case DigestFNV1a:
+		return "fnv1a"
+	case DigestXXHash:
+		return "xxhash"
+	case DigestMurmur3:
+		return "murmur3"
+	case DigestMapHash:
+		return "maphash"
+	}
+	return "unknown"
+}
+
+// WithDigest sets the digest of the string keys of GetString and GetBytes.
+// The default is DigestFNV1a.
+func WithDigest(d Digest) Option {
+	return func(h *Hash) {
+		h.digest = d
+		if d == DigestMapHash {
+			h.seed = maphash.MakeSeed()
+		}
+	}
+}
+
+// GetString returns an object according to the digest of the key, see WithDigest.
+func (this *Hash) GetString(key string) interface{} {
+	if this == nil {
+		return nil
+	}
+	return this.Get(digest(this, key))
+}
+
+// GetBytes is the same as GetString with a []byte key.
+//
+// Neither GetString nor GetBytes copy or allocate: the digests read strings and byte
+// slices in place, so there is no need to convert between them with unsafe. DigestMapHash
+// uses the hardware-accelerated hash of the runtime, e.g. with AES instructions on amd64,
+// and is the fastest choice for long keys when the placement need not be stable across
+// processes.
+func (this *Hash) GetBytes(key []byte) interface{} {
+	if this == nil {
+		return nil
+	}
+	if this.digest == DigestMapHash {
+		return this.Get(maphash.Bytes(this.seed, key))
+	}
+	return this.Get(digest(this, key))
+}
+
+// Sum64 returns the digest of the key GetString uses.
+func (this *Hash) Sum64(key string) uint64 {
+	if this == nil {
+		return 0
+	}
+	return digest(this, key)
+}
+
+// 摘要算法和种子在创建后不再变化，不需要加锁。[]byte使用maphash时转换成string会复制，由GetBytes单独处理
+func digest[T string | []byte](h *Hash, b T) uint64 {
+	switch h.digest {
+	case DigestXXHash:
+		return xxh64(b)
+	case DigestMurmur3:
+		return murmur3(b)
+	case DigestMapHash:
+		return maphash.String(h.seed, string(b))
+	}
+	return fnv1a(b)
+}
+
+func fnv1a[T string | []byte](b T) uint64 {
+	h := uint64(fnvOffset64)
+	for i := 0; i < len(b); i++ {
+		h ^= uint64(b[i])
+		h *= fnvPrime64
+	}
+	return h
+}
+
+// 按小端序读取从i开始的8个字节
+func le64[T string | []byte](b T, i int) uint64 {
+	return uint64(b[i]) | uint64(b[i+1])<<8 | uint64(b[i+2])<<16 | uint64(b[i+3])<<24 |
+		uint64(b[i+4])<<32 | uint64(b[i+5])<<40 | uint64(b[i+6])<<48 | uint64(b[i+7])<<56
+}
+
+func le32[T string | []byte](b T, i int) uint32 {
+	return uint32(b[i]) | uint32(b[i+1])<<8 | uint32(b[i+2])<<16 | uint32(b[i+3])<<24
+}
+
+const (
+	xxPrime1 uint64 = 11400714785074694791
+	xxPrime2 uint64 = 14029467366897019727
+	xxPrime3 uint64 = 1609587929392839161
+	xxPrime4 uint64 = 9650029242287828579
+	xxPrime5 uint64 = 2870177450012600261
+)
+
+func xxRound(acc, input uint64) uint64 {
+	acc += input * xxPrime2
+	return bits.RotateLeft64(acc, 31) * xxPrime1
+}
+
+func xxMerge(acc, v uint64) uint64 {
+	acc ^= xxRound(0, v)
+	return acc*xxPrime1 + xxPrime4
+}
+
+func xxh64[T string | []byte](b T) uint64 {
+	n := len(b)
+	i := 0
+	var h uint64
+	if n >= 32 {
+		// 常量的和溢出，需要在运行时计算
+		v1, v2, v3, v4 := xxPrime1, xxPrime2, uint64(0), uint64(0)
+		v1 += xxPrime2
+		v4 -= xxPrime1
+		for ; i+32 <= n; i += 32 {
+			v1 = xxRound(v1, le64(b, i))
+			v2 = xxRound(v2, le64(b, i+8))
+			v3 = xxRound(v3, le64(b, i+16))
+			v4 = xxRound(v4, le64(b, i+24))
+		}
+		h = bits.RotateLeft64(v1, 1) + bits.RotateLeft64(v2, 7) + bits.RotateLeft64(v3, 12) + bits.RotateLeft64(v4, 18)
+		h = xxMerge(h, v1)
+		h = xxMerge(h, v2)
+		h = xxMerge(h, v3)
+		h = xxMerge(h, v4)
+	} else {
+		h = xxPrime5
+	}
+
+	h += uint64(n)
+	for ; i+8 <= n; i += 8 {
+		h ^= xxRound(0, le64(b, i))
+		h = bits.RotateLeft64(h, 27)*xxPrime1 + xxPrime4
+	}
+	if i+4 <= n {
+		h ^= uint64(le32(b, i)) * xxPrime1
+		h = bits.RotateLeft64(h, 23)*xxPrime2 + xxPrime3
+		i += 4
+	}
+	for ; i < n; i++ {
+		h ^= uint64(b[i]) * xxPrime5
+		h = bits.RotateLeft64(h, 11) * xxPrime1
+	}
+
+	h ^= h >> 33
+	h *= xxPrime2
+	h ^= h >> 29
+	h *= xxPrime3
+	return h ^ (h >> 32)
+}
+
+func murmur3[T string | []byte](b T) uint64 {
+	const (
+		c1 = 0x87c37b91114253d5
+		c2 = 0x4cf5ad432745937f
+	)
+	n := len(b)
+	var h1, h2 uint64
+	i := 0
+	for ; i+16 <= n; i += 16 {
+		k1, k2 := le64(b, i), le64(b, i+8)
+		k1 *= c1
+		k1 = bits.RotateLeft64(k1, 31)
+		k1 *= c2
+		h1 ^= k1
+		h1 = bits.RotateLeft64(h1, 27)
+		h1 += h2
+		h1 = h1*5 + 0x52dce729
+
+		k2 *= c2
+		k2 = bits.RotateLeft64(k2, 33)
+		k2 *= c1
+		h2 ^= k2
+		h2 = bits.RotateLeft64(h2, 31)
+		h2 += h1
+		h2 = h2*5 + 0x38495ab5
+	}
+
+	// 剩余不足16字节的部分
+	var k1, k2 uint64
+	for j := n - 1; j >= i; j-- {
+		if j-i >= 8 {
+			k2 = k2<<8 | uint64(b[j])
+		} else {
+			k1 = k1<<8 | uint64(b[j])
+		}
+	}
+	if n-i > 8 {
+		k2 *= c2
+		k2 = bits.RotateLeft64(k2, 33)
+		k2 *= c1
+		h2 ^= k2
+	}
+	if n-i > 0 {
+		k1 *= c1
+		k1 = bits.RotateLeft64(k1, 31)
+		k1 *= c2
+		h1 ^= k1
+	}
+
+	h1 ^= uint64(n)
+	h2 ^= uint64(n)
+	h1 += h2
+	h2 += h1
+	h1 = Murmur3Mixer(h1)
+	h2 = Murmur3Mixer(h2)
+	return h1 + h2
+}
@@ -0,0 +1,71 @@
+package doublejump
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDigest(t *testing.T) {
+	// 与hash/fnv、github.com/cespare/xxhash和github.com/spaolacci/murmur3的结果一致
+	vectors := []struct {
+		s                    string
+		fnv, xxhash, murmur3 uint64
+	}{
+		{"", 0xcbf29ce484222325, 0xef46db3751d8e999, 0x0},
+		{"a", 0xaf63dc4c8601ec8c, 0xd24ec4f1a98c6e5b, 0x85555565f6597889},
+		{"hello", 0xa430d84680aabd0b, 0x26c7827d889f6da3, 0xcbd8a7b341bd9b02},
+		{"hello world", 0x779a65e7023cd2e7, 0x45ab6734b21e6968, 0x533f6046eb7f610e},
+		{"0123456789abcdef0", 0x353d49cf45a687d7, 0xf4e911320106d43c, 0xeb24ae8785a5c075},
+		{strings.Repeat("abcdefghij", 10), 0x9b71dbe2c4a4d841, 0xc4cc1eafce2327f1, 0x661a897eee0f7daa},
+	}
+	fnv, xx, mm := NewHash(), NewHash(WithDigest(DigestXXHash)), NewHash(WithDigest(DigestMurmur3))
+	for _, v := range vectors {
+		if fnv.Sum64(v.s) != v.fnv || fnv1a([]byte(v.s)) != v.fnv {
+			t.Fatalf("unexpected fnv1a of %q: %#x", v.s, fnv.Sum64(v.s))
+		}
+		if xx.Sum64(v.s) != v.xxhash || xxh64([]byte(v.s)) != v.xxhash {
+			t.Fatalf("unexpected xxhash of %q: %#x", v.s, xx.Sum64(v.s))
+		}
+		if mm.Sum64(v.s) != v.murmur3 || murmur3([]byte(v.s)) != v.murmur3 {
+			t.Fatalf("unexpected murmur3 of %q: %#x", v.s, mm.Sum64(v.s))
+		}
+	}
+
+	m := NewHash(WithDigest(DigestMapHash))
+	for i := 0; i < 10; i++ {
+		m.Add(i)
+	}
+	snap := m.Snapshot()
+	defer snap.Release()
+	for _, s := range []string{"a", "b", "hello"} {
+		if m.GetString(s) != m.Get(m.Sum64(s)) || m.GetBytes([]byte(s)) != m.GetString(s) {
+			t.Fatalf("unexpected GetString of %q", s)
+		}
+		if snap.hash.Sum64(s) != m.Sum64(s) {
+			t.Fatal("the snapshot should keep the seed")
+		}
+	}
+
+	if DigestMurmur3.String() != "murmur3" || Digest(100).String() != "unknown" {
+		t.Fatal("Digest.String is wrong")
+	}
+}
+
+func TestGetString_Allocs(t *testing.T) {
+	b := []byte("user:1234567890:session:abcdefghijklmnopqrstuvwxyz")
+	s := string(b)
+	for _, d := range []Digest{DigestFNV1a, DigestXXHash, DigestMurmur3, DigestMapHash} {
+		h := NewHash(WithDigest(d))
+		h.Add(1)
+		h.Add(2)
+		if n := testing.AllocsPerRun(100, func() { h.GetString(s) }); n != 0 {
+			t.Fatalf("GetString with %s should not allocate. allocs: %f", d, n)
+		}
+		if n := testing.AllocsPerRun(100, func() { h.GetBytes(b) }); n != 0 {
+			t.Fatalf("GetBytes with %s should not allocate. allocs: %f", d, n)
+		}
+		if h.GetBytes(b) != h.GetString(s) {
+			t.Fatalf("GetBytes and GetString with %s should agree", d)
+		}
+	}
+}
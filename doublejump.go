@@ -1,23 +1,49 @@
 // Package doublejump provides a revamped Google's jump consistent hash.
+//
+// # Concurrency
+//
+// A Hash created by NewHash guards its state with a sync.RWMutex, so lookups only take the
+// read lock and never block each other. For read-dominated paths which want no lock at all,
+// Snapshot returns a frozen view whose lookups touch no shared mutable state.
+//
+// A seqlock-style optimistic read path, where readers retry if a writer interleaved, is
+// deliberately not provided: the readers would read the slices and maps of the hash while a
+// writer mutates them, which is a data race under the Go memory model even if the result is
+// discarded afterwards, and it would be reported by the race detector in every user's tests.
 package doublejump
 
 import (
+	"hash/maphash"
 	"sync"
-
-	"github.com/dgryski/go-jump"
+	"time"
 )
 
+// 获取当前时间，测试中可以替换
+var now = time.Now
+
 // 保持全量的节点信息，删除节点的时候不会从数组中直接删除，需要保留位置，将该位置对应的节点设置为nil
 // 增加节点的时候优先往空位置中填放
 type looseHolder struct {
-	a []interface{}
-	m map[interface{}]int
+	a          []interface{}
+	m          map[interface{}]int
 	emptyPoses []int
+
+	jump func(key uint64, n int) int // 见WithJumpFunc，为空时使用JumpHash
+
+	// 见WithTombstone，被删除节点的位置在宽限期内保留给该节点
+	grace    time.Duration
+	tombs    map[interface{}]tombstone
+	reserved map[int]interface{}
 }
 
-func (this *looseHolder) add(obj interface{}) {
+func (this *looseHolder) add(obj interface{}) bool {
 	if _, ok := this.m[obj]; ok {
-		return
+		return false
+	}
+
+	if this.grace > 0 {
+		this.addReserved(obj)
+		return true
 	}
 
 	if nf := len(this.emptyPoses); nf == 0 {
@@ -29,15 +55,21 @@ func (this *looseHolder) add(obj interface{}) {
 		this.a[idx] = obj
 		this.m[obj] = idx
 	}
+	return true
 }
 
 // 删除节点: 标记删除节点的位置为空
-func (this *looseHolder) remove(obj interface{}) {
-	if idx, ok := this.m[obj]; ok {
+func (this *looseHolder) remove(obj interface{}) bool {
+	idx, ok := this.m[obj]
+	if ok {
 		this.emptyPoses = append(this.emptyPoses, idx)
 		this.a[idx] = nil
 		delete(this.m, obj)
+		if this.grace > 0 {
+			this.bury(obj, idx)
+		}
 	}
+	return ok
 }
 
 // 根据KEY计算一致性哈希值
@@ -48,16 +80,23 @@ func (this *looseHolder) get(key uint64) interface{} {
 		return nil
 	}
 
-	h := jump.Hash(key, na)
-	return this.a[h]
+	return this.a[jumpHash(this.jump, key, na)]
+}
+
+// 返回KEY落在的位置，没有位置时返回-1
+func (this *looseHolder) index(key uint64) int {
+	if len(this.a) == 0 {
+		return -1
+	}
+	return jumpHash(this.jump, key, len(this.a))
 }
 
-func (this *looseHolder) shrink() {
+// a为存放结果的缓冲区，可以为空
+func (this *looseHolder) shrink(a []interface{}) {
 	if len(this.emptyPoses) == 0 {
 		return
 	}
 
-	var a []interface{}
 	for _, obj := range this.a {
 		if obj != nil {
 			a = append(a, obj)
@@ -66,13 +105,17 @@ func (this *looseHolder) shrink() {
 	}
 	this.a = a
 	this.emptyPoses = nil
+	this.tombs = nil
+	this.reserved = nil
 }
 
 // 作为looseHolder的"候补"，保存着当前有效的节点信息，不存在空位置
 // 当looseHolder哈希出来的值是已经删除的节点，就需要通过compactHolder重新计算一次
 type compactHolder struct {
-	a []interface{}
-	m map[interface{}]int
+	a    []interface{}
+	m    map[interface{}]int
+	mix  func(key uint64) uint64     // 为空时使用DefaultMixer
+	jump func(key uint64, n int) int // 见WithJumpFunc，为空时使用JumpHash
 }
 
 func (this *compactHolder) add(obj interface{}) {
@@ -104,69 +147,201 @@ func (this *compactHolder) remove(obj interface{}) {
 }
 
 func (this *compactHolder) get(key uint64) interface{} {
+	idx := this.index(key)
+	if idx < 0 {
+		return nil
+	}
+	return this.a[idx]
+}
+
+// 返回KEY落在的位置，没有位置时返回-1
+func (this *compactHolder) index(key uint64) int {
 	na := len(this.a)
 	if na == 0 {
-		return nil
+		return -1
 	}
 
-	// 这里大概是将KEY变换一下？
-	h := jump.Hash(key*0xc6a4a7935bd1e995, na)
-	return this.a[h]
+	// 将KEY变换一下，使得和looseHolder的选择相互独立
+	if this.mix != nil {
+		key = this.mix(key)
+	} else {
+		key *= 0xc6a4a7935bd1e995
+	}
+	return jumpHash(this.jump, key, na)
+}
+
+// 用f选择n个桶中的一个，f为空时使用JumpHash
+func jumpHash(f func(key uint64, n int) int, key uint64, n int) int {
+	if f != nil {
+		return f(key, n)
+	}
+	return int(JumpHash(key, n))
 }
 
 // Hash is a revamped Google's jump consistent hash. It overcomes the shortcoming of the
 // original implementation - not being able to remove nodes.
 type Hash struct {
-	mu      sync.RWMutex
+	mu      rwMutex
 	loose   looseHolder
 	compact compactHolder
 	lock    bool
+	pins    map[uint64]interface{}
+
+	// 两个holder中保存的是节点的标识(设置了权重的节点还有额外的虚拟位置)，
+	// nodes保存标识到节点信息的映射，未设置WithKeyFunc时标识就是节点本身
+	keyFunc   func(obj interface{}) interface{}
+	normalize func(obj interface{}) interface{} // 见WithNormalizer
+	nodes     map[interface{}]*node
+
+	// 每次改变KEY到节点的映射时加1
+	gen uint64
+
+	listeners     []*listener
+	slotListeners []*slotListener // 见SubscribeSlots
+	cache         *resultCache
+	hot           *hotKeys
+
+	load      func(obj interface{}) int
+	capped    int // 设置了容量上限的节点数量
+	drained   int // 正在排空的节点数量
+	canaries  int // 金丝雀节点的数量
+	breakers  int // 设置了熔断器的节点数量
+	penalized int // 正在惩罚的节点数量，见Penalize
+
+	snapMu sync.Mutex
+	snap   *Snapshot // 当前状态的快照，状态变化时作废
+
+	pool    *slicePool
+	flight  flightGroup // 见GetOrLoad
+	hygiene hygiene     // 见Stats.SinceShrink和WithShrinkAlert
+	frozen  bool        // 见Freeze
+	batch   bool        // 见Manager.Apply，为真时changed推迟到提交时
+	dirty   bool        // 推迟期间映射是否发生了变化
+
+	weightOf func(meta interface{}) int // 见WithWeightFunc
+	race     *raceGuard
+	replicas int           // 见WithReplication
+	minNodes int           // 见WithMinNodes
+	bounded  float64       // 见WithBoundedLoad
+	history  *history      // 见WithHistory
+	removed  *removedLog   // 见WithRecentlyRemoved
+	persist  *persister    // 见WithStore
+	window   time.Duration // 见WithTimeBucket
+	throttle *throttle     // 见WithThrottle
+	usage    *usage        // 见Manager.TenantRing
+
+	// 见WithLatencyAware
+	latencyK int
+	slack    float64
+
+	// 见WithLoadReporter
+	reporter LoadReporter
+	factor   float64
+
+	// 见WithDigest
+	digest Digest
+	seed   maphash.Seed
 }
 
 // NewHash creates a new doublejump hash instance, which is threadsafe.
-func NewHash() *Hash {
-	hash := &Hash{lock: true}
-	hash.loose.m = make(map[interface{}]int)
-	hash.compact.m = make(map[interface{}]int)
-	return hash
+func NewHash(opts ...Option) *Hash {
+	return newHash(true, opts)
 }
 
 // NewHashWithoutLock creates a new doublejump hash instance, which does NOT threadsafe.
-func NewHashWithoutLock() *Hash {
-	hash := &Hash{}
+func NewHashWithoutLock(opts ...Option) *Hash {
+	return newHash(false, opts)
+}
+
+func newHash(lock bool, opts []Option) *Hash {
+	hash := &Hash{lock: lock}
 	hash.loose.m = make(map[interface{}]int)
 	hash.compact.m = make(map[interface{}]int)
+	hash.nodes = make(map[interface{}]*node)
+	hash.hygiene.shrunk = now()
+	for _, opt := range opts {
+		opt(hash)
+	}
 	return hash
 }
 
-// Add adds an object to the hash.
-func (this *Hash) Add(obj interface{}) {
+// ID returns the identity by which the hash looks up obj: obj normalized by WithNormalizer,
+// then mapped by WithKeyFunc, or obj itself if neither is set. It is comparable even when obj
+// is not, so it can key maps of per-object state.
+func (this *Hash) ID(obj interface{}) interface{} {
+	if this == nil {
+		return obj
+	}
+	return this.id(obj)
+}
+
+// 计算节点在哈希中的标识，未设置WithKeyFunc时就是节点本身
+func (this *Hash) id(obj interface{}) interface{} {
+	if this.normalize != nil && obj != nil {
+		obj = this.normalize(obj)
+	}
+	return this.keyOf(obj)
+}
+
+// 计算已经规范化的节点的标识，WithNormalizer的函数不一定是幂等的，不能再规范化一次
+func (this *Hash) keyOf(obj interface{}) interface{} {
+	if this.keyFunc == nil || obj == nil {
+		return obj
+	}
+	return this.keyFunc(obj)
+}
+
+// 根据标识取回节点
+func (this *Hash) value(id interface{}) interface{} {
+	if this.keyFunc == nil || id == nil {
+		return id
+	}
+	if n, ok := this.nodes[id]; ok {
+		return n.obj
+	}
+	return nil
+}
+
+// Add adds an object to the hash. It reports whether the object was newly inserted.
+func (this *Hash) Add(obj interface{}) bool {
 	if this == nil || obj == nil {
-		return
+		return false
 	}
 
 	if this.lock {
 		this.mu.Lock()
 		defer this.mu.Unlock()
 	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
 
-	this.loose.add(obj)
-	this.compact.add(obj)
+	return this.add(obj)
 }
 
-// Remove removes an object from the hash.
-func (this *Hash) Remove(obj interface{}) {
+// 调用方负责加锁
+func (this *Hash) add(obj interface{}) bool {
+	return this.addNode(Node{Value: obj})
+}
+
+// Remove removes an object from the hash. It reports whether the object was in the hash.
+func (this *Hash) Remove(obj interface{}) bool {
 	if this == nil || obj == nil {
-		return
+		return false
 	}
 
 	if this.lock {
 		this.mu.Lock()
 		defer this.mu.Unlock()
 	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
 
-	this.loose.remove(obj)
-	this.compact.remove(obj)
+	return this.remove(obj)
+}
+
+// 调用方负责加锁
+func (this *Hash) remove(obj interface{}) bool {
+	return this.removeNode(this.id(obj))
 }
 
 // Len returns the number of objects in the hash.
@@ -177,12 +352,34 @@ func (this *Hash) Len() int {
 
 	if this.lock {
 		this.mu.RLock()
-		n := len(this.compact.a)
+		n := len(this.nodes)
 		this.mu.RUnlock()
 		return n
 	}
 
-	return len(this.compact.a)
+	this.race.lockRead()
+	n := len(this.nodes)
+	this.race.unlockRead()
+	return n
+}
+
+// Contains reports whether obj is in the hash.
+func (this *Hash) Contains(obj interface{}) bool {
+	if this == nil || obj == nil {
+		return false
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		_, ok := this.nodes[this.id(obj)]
+		this.mu.RUnlock()
+		return ok
+	}
+
+	this.race.lockRead()
+	_, ok := this.nodes[this.id(obj)]
+	this.race.unlockRead()
+	return ok
 }
 
 // LooseLen returns the size of the inner loose object holder.
@@ -198,7 +395,10 @@ func (this *Hash) LooseLen() int {
 		return n
 	}
 
-	return len(this.loose.a)
+	this.race.lockRead()
+	n := len(this.loose.a)
+	this.race.unlockRead()
+	return n
 }
 
 // Shrink removes all empty slots from the hash.
@@ -211,9 +411,37 @@ func (this *Hash) Shrink() {
 		this.mu.Lock()
 		defer this.mu.Unlock()
 	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
 
-	this.loose.shrink()
+	this.shrink()
+}
+
+// 调用方负责加锁
+func (this *Hash) shrink() {
+	if this.frozen {
+		return
+	}
+	this.hygiene.shrunk = now()
+	if len(this.loose.emptyPoses) == 0 {
+		return
+	}
+
+	before := this.slotOwners()
+	if this.pool == nil {
+		this.loose.shrink(nil)
+	} else {
+		old := this.loose.a
+		this.loose.shrink(this.pool.get(len(old) - len(this.loose.emptyPoses)))
+		this.pool.put(old)
+	}
 	this.compact.shrink(this.loose.a)
+	if this.removed != nil {
+		this.removed.shrunk()
+	}
+	this.changed()
+	this.emit(Event{Type: EventShrink})
+	this.emitSlots(before)
 }
 
 // Get returns an object according to the key provided.
@@ -222,22 +450,74 @@ func (this *Hash) Get(key uint64) interface{} {
 		return nil
 	}
 
-	var obj interface{}
 	if this.lock {
 		this.mu.RLock()
-		obj = this.loose.get(key)
-		switch obj {
-		case nil:
-			obj = this.compact.get(key)
-		}
+		obj := this.get(key)
 		this.mu.RUnlock()
-	} else {
-		obj = this.loose.get(key)
-		switch obj {
-		case nil:
-			obj = this.compact.get(key)
-		}
+		return obj
 	}
 
+	this.race.lockRead()
+	obj := this.get(key)
+	this.race.unlockRead()
 	return obj
 }
+
+// GetOrDefault returns an object according to the key provided, or def if the hash has
+// no node to return, e.g. before it is configured.
+func (this *Hash) GetOrDefault(key uint64, def interface{}) interface{} {
+	if obj := this.Get(key); obj != nil {
+		return obj
+	}
+	return def
+}
+
+// 调用方负责加锁
+func (this *Hash) get(key uint64) interface{} {
+	return this.value(this.selectID(this.windowKey(key)))
+}
+
+// 返回最终选中节点的标识，包括热点KEY、结果缓存和过滤规则的处理，调用方负责加锁
+func (this *Hash) selectID(key uint64) interface{} {
+	var id interface{}
+	switch {
+	case this.hot != nil && this.hot.isHot(key):
+		id = this.hot.getID(this, key)
+	case this.cache != nil:
+		id = this.cache.getID(this, key)
+	default:
+		id = this.getID(key)
+	}
+
+	if id == nil {
+		return nil
+	}
+	if this.latencyK > 1 {
+		id = this.fastest(key, id)
+	} else if this.filtering() {
+		id = this.avoid(key, id)
+	}
+	if this.reporter != nil {
+		id = this.lighter(key, id)
+	}
+	return id
+}
+
+// 返回选中节点的标识，调用方负责加锁
+func (this *Hash) getID(key uint64) interface{} {
+	if !this.ready() {
+		return nil
+	}
+	if len(this.pins) > 0 {
+		if id, ok := this.pinned(key); ok {
+			return id
+		}
+	}
+
+	slot := this.loose.get(key)
+	switch slot {
+	case nil:
+		slot = this.compact.get(key)
+	}
+	return owner(slot)
+}
@@ -122,10 +122,12 @@ func TestHash_Basic(t *testing.T) {
 
 func TestHash_Add(t *testing.T) {
 	h := NewHashWithoutLock()
-	h.Add(100)
-	h.Add(200)
-	h.Add(300)
-	h.Add(100)
+	if !h.Add(100) || !h.Add(200) || !h.Add(300) {
+		t.Fatal("h.Add should return true for new nodes")
+	}
+	if h.Add(100) || h.Add(nil) {
+		t.Fatal("h.Add should return false for existing or nil nodes")
+	}
 	always(h, t)
 
 	if h.Len() != 3 {
@@ -161,6 +163,43 @@ func TestHash_Get(t *testing.T) {
 	}
 }
 
+func TestHash_GetOrDefault(t *testing.T) {
+	var h0 *Hash
+	if h0.GetOrDefault(100, "default") != "default" {
+		t.Fatal("h0.GetOrDefault(100) != default")
+	}
+
+	h := NewHash()
+	if h.GetOrDefault(100, "default") != "default" {
+		t.Fatal("h.GetOrDefault(100) != default")
+	}
+	h.Add("a")
+	if h.GetOrDefault(100, "default") != "a" {
+		t.Fatal("h.GetOrDefault(100) != a")
+	}
+	h.Remove("a")
+	if h.GetOrDefault(100, "default") != "default" {
+		t.Fatal("h.GetOrDefault(100) != default")
+	}
+}
+
+func TestHash_Remove(t *testing.T) {
+	h := NewHash()
+	h.Add(100)
+	h.Add(200)
+
+	if !h.Remove(100) {
+		t.Fatal("h.Remove(100) should return true")
+	}
+	if h.Remove(100) {
+		t.Fatal("h.Remove(100) should return false when the node has been removed")
+	}
+	if h.Remove(300) || h.Remove(nil) {
+		t.Fatal("h.Remove should return false when the node does not exist")
+	}
+	always(h, t)
+}
+
 func TestHash_LooseLen(t *testing.T) {
 	h := NewHashWithoutLock()
 	for i := 0; i < 10; i++ {
@@ -266,7 +305,8 @@ func TestHash_Balance(t *testing.T) {
 				always(h1, t)
 			}
 			if h1.Len() != n1-numRemove {
-				t.Fatalf("h1.Len() != n1-numRemove. h1.Len(): %d, n1: %d, numRemove: %d", h1.Len(), n1, numRemove)
+				t.Errorf("h1.Len() != n1-numRemove. h1.Len(): %d, n1: %d, numRemove: %d", h1.Len(), n1, numRemove)
+				return
 			}
 
 			if numRemove < n1 {
@@ -281,7 +321,8 @@ func TestHash_Balance(t *testing.T) {
 					return true
 				}, nil)
 				if err != nil {
-					t.Fatal(err)
+					t.Error(err)
+					return
 				}
 			} else {
 				err := quick.Check(func(x int) bool {
@@ -289,7 +330,8 @@ func TestHash_Balance(t *testing.T) {
 					return obj == nil
 				}, nil)
 				if err != nil {
-					t.Fatal(err)
+					t.Error(err)
+					return
 				}
 			}
 
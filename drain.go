@@ -0,0 +1,208 @@
+package doublejump
+
+import (
+	"sync"
+	"time"
+)
+
+// Drain marks the object as draining: Get deterministically moves its keys to the next
+// candidates, as if it were removed, while the object stays in the hash, keeps its slots
+// and takes back exactly the same keys when Undrain is called. If all objects are
+// draining, Get still returns the selected one. It returns false if the object is not in
+// the hash.
+func (this *Hash) Drain(obj interface{}) bool {
+	return this.setDrained(obj, true)
+}
+
+// Undrain stops draining the object. It returns false if the object is not in the hash.
+func (this *Hash) Undrain(obj interface{}) bool {
+	return this.setDrained(obj, false)
+}
+
+// Drained reports whether the object is draining.
+func (this *Hash) Drained(obj interface{}) bool {
+	if this == nil || obj == nil {
+		return false
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	n, ok := this.nodes[this.id(obj)]
+	return ok && n.drained
+}
+
+func (this *Hash) setDrained(obj interface{}, drained bool) bool {
+	if this == nil || obj == nil {
+		return false
+	}
+
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	n, ok := this.nodes[this.id(obj)]
+	if !ok {
+		return false
+	}
+	if !drained && n.undrain > 0 {
+		// 停止DrainOver，恢复逐步排空前的份额
+		this.setShare(n, n.undrain)
+		n.undrain = 0
+		this.retire()
+	}
+	if n.drained != drained {
+		n.drained = drained
+		if drained {
+			this.drained++
+		} else {
+			this.drained--
+		}
+		this.retire()
+	}
+	return true
+}
+
+// DrainOver drains the object gradually: over d, in steps equal slices of its keys
+// deterministically move to their next candidates, the last step draining it completely as
+// Drain does. Every step emits EventDrain, so that dependent caches can pre-fetch the slice
+// about to migrate; the keys leaving at step i of n are those Share would stop accepting
+// when lowered to 1 - i/n of the original share, so the slices are the same in every process.
+// Undrain stops the remaining steps and gives the object back its share from before
+// DrainOver, as does the returned function, which reports whether any step was left.
+// SetShare stops the remaining steps too, keeping the share it sets. Since the steps happen
+// on their own goroutine, it does nothing on a hash created by NewHashWithoutLock.
+func (this *Hash) DrainOver(obj interface{}, d time.Duration, steps int) (cancel func() bool) {
+	if this == nil || obj == nil || !this.lock {
+		return func() bool { return false }
+	}
+	if steps < 1 {
+		steps = 1
+	}
+
+	this.mu.Lock()
+	this.race.lockWrite()
+	id := this.id(obj)
+	n, ok := this.nodes[id]
+	var base, restore float64
+	if ok {
+		base, restore = n.share, n.share
+		if base == 0 {
+			base, restore = float64(n.weight)/float64(len(this.compact.a)), 1
+		}
+		// 重复调用时保留最初的份额
+		if n.undrain == 0 {
+			n.undrain = restore
+		} else {
+			restore = n.undrain
+		}
+	}
+	this.race.unlockWrite()
+	this.mu.Unlock()
+	if !ok {
+		return func() bool { return false }
+	}
+
+	s := &drainSchedule{hash: this, id: id, base: base, restore: restore, steps: steps, interval: d / time.Duration(steps)}
+	s.mu.Lock()
+	s.stop = afterFunc(s.interval, s.next)
+	s.mu.Unlock()
+	return s.cancel
+}
+
+// DrainOver的进度
+type drainSchedule struct {
+	hash     *Hash
+	id       interface{}
+	base     float64 // 开始时的份额
+	restore  float64 // 完全排空后恢复的份额，完整的节点为1
+	steps    int
+	interval time.Duration
+
+	mu   sync.Mutex
+	step int
+	stop func() bool // 为空表示已经结束
+}
+
+func (this *drainSchedule) next() {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	if this.stop == nil {
+		return
+	}
+	this.step++
+	if !this.hash.drainStep(this, this.step) || this.step == this.steps {
+		this.stop = nil
+		return
+	}
+	this.stop = afterFunc(this.interval, this.next)
+}
+
+func (this *drainSchedule) cancel() bool {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	if this.stop == nil {
+		return false
+	}
+	this.stop()
+	this.stop = nil
+	this.hash.undrainStep(this)
+	return true
+}
+
+// 应用第step步，节点已经不在哈希中时返回false
+func (this *Hash) drainStep(s *drainSchedule, step int) bool {
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	n, ok := this.nodes[s.id]
+	// 被Undrain或SetShare停止
+	if !ok || n.undrain == 0 {
+		return false
+	}
+	if step < s.steps {
+		this.setShare(n, s.base*(1-float64(step)/float64(s.steps)))
+	} else {
+		// 完全排空后恢复原来的份额，Undrain时收回全部KEY
+		this.setShare(n, s.restore)
+		n.undrain = 0
+		if !n.drained {
+			n.drained = true
+			this.drained++
+		}
+	}
+	this.retire()
+	this.emit(Event{Type: EventDrain, Node: n.obj})
+	return true
+}
+
+// 取消剩余的步骤，恢复原来的份额
+func (this *Hash) undrainStep(s *drainSchedule) {
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	n, ok := this.nodes[s.id]
+	if !ok || n.undrain == 0 {
+		return
+	}
+	this.setShare(n, n.undrain)
+	n.undrain = 0
+	this.retire()
+}
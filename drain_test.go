@@ -0,0 +1,160 @@
+package doublejump
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHash_Drain(t *testing.T) {
+	h := NewHash()
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+	if h.Drain(100) {
+		t.Fatal("h.Drain(100) should fail")
+	}
+
+	before := make(map[uint64]interface{})
+	for key := uint64(0); key < 1000; key++ {
+		before[key] = h.Get(key)
+	}
+
+	if !h.Drain(3) {
+		t.Fatal("h.Drain(3) failed")
+	}
+	if !h.Drained(3) || h.Drained(4) {
+		t.Fatal("unexpected drained state")
+	}
+	if h.Len() != 10 {
+		t.Fatal("h.Len() != 10")
+	}
+	for key, obj := range before {
+		got := h.Get(key)
+		if got == 3 {
+			t.Fatalf("h.Get(%d) returned the draining node", key)
+		}
+		if obj != 3 && got != obj {
+			t.Fatalf("h.Get(%d) moved from %v to %v", key, obj, got)
+		}
+	}
+
+	s := h.Snapshot()
+	defer s.Release()
+	if s.Get(0) == 3 {
+		t.Fatal("the snapshot should keep draining")
+	}
+
+	h.Undrain(3)
+	for key, obj := range before {
+		if h.Get(key) != obj {
+			t.Fatalf("h.Get(%d) should be restored after Undrain", key)
+		}
+	}
+
+	h.Drain(3)
+	h.Remove(3)
+	if h.drained != 0 {
+		t.Fatal("h.drained != 0")
+	}
+
+	one := NewHash()
+	one.Add(1)
+	one.Drain(1)
+	if one.Get(0) != 1 {
+		t.Fatal("the only node should still be returned while draining")
+	}
+}
+
+func TestHash_DrainOver(t *testing.T) {
+	var fs []func()
+	var ds []time.Duration
+	afterFunc = func(d time.Duration, f func()) func() bool {
+		fs = append(fs, f)
+		ds = append(ds, d)
+		return func() bool { return true }
+	}
+	defer func() { afterFunc = func(d time.Duration, f func()) func() bool { return time.AfterFunc(d, f).Stop } }()
+
+	h := NewHash()
+	for i := 0; i < 4; i++ {
+		h.Add(i)
+	}
+	var events []Event
+	h.Subscribe(func(ev Event) {
+		events = append(events, ev)
+	})
+
+	owned := make(map[uint64]bool)
+	for key := uint64(0); key < 20000; key++ {
+		if h.Get(key) == 1 {
+			owned[key] = true
+		}
+	}
+
+	h.DrainOver(1, 4*time.Minute, 4)
+	left := owned
+	for step := 1; step <= 4; step++ {
+		if len(fs) != step || ds[step-1] != time.Minute {
+			t.Fatal("the steps should be scheduled every minute")
+		}
+		fs[step-1]()
+
+		now := make(map[uint64]bool)
+		for key := uint64(0); key < 20000; key++ {
+			if h.Get(key) == 1 {
+				if !left[key] {
+					t.Fatalf("key %d should not come back to 1", key)
+				}
+				now[key] = true
+			}
+		}
+		want := len(owned) * (4 - step) / 4
+		if d := len(now) - want; d < -300 || d > 300 {
+			t.Fatalf("step %d should leave about %d keys on 1. n: %d", step, want, len(now))
+		}
+		left = now
+	}
+	if len(fs) != 4 || len(events) != 4 || events[3].Type != EventDrain || events[3].Node != 1 {
+		t.Fatalf("unexpected events: %v", events)
+	}
+	if !h.Drained(1) || h.Share(1) != 1 {
+		t.Fatal("1 should be drained completely")
+	}
+
+	h.Undrain(1)
+	for key := range owned {
+		if h.Get(key) != 1 {
+			t.Fatal("1 should take back all its keys")
+		}
+	}
+
+	cancel := h.DrainOver(2, time.Minute, 2)
+	fs[4]()
+	if h.Share(2) > 0.2 {
+		t.Fatal("2 should be partially drained")
+	}
+	if !cancel() || cancel() || h.Drained(2) || h.Share(2) != 1 {
+		t.Fatal("cancel should stop the remaining steps and restore the share")
+	}
+
+	// Undrain停止剩余的步骤并收回全部KEY
+	h.DrainOver(1, time.Minute, 2)
+	fs[5]()
+	h.Undrain(1)
+	fs[6]()
+	if h.Drained(1) || h.Share(1) != 1 || len(fs) != 7 {
+		t.Fatal("Undrain should stop the remaining steps and restore the share")
+	}
+	for key := range owned {
+		if h.Get(key) != 1 {
+			t.Fatal("1 should take back all its keys")
+		}
+	}
+
+	// 不加锁的哈希不能在定时器中修改份额
+	h2 := NewHashWithoutLock()
+	h2.Add(1)
+	if h2.DrainOver(1, time.Minute, 2)() || len(fs) != 7 || h2.Share(1) != 1 {
+		t.Fatal("an unlocked hash should refuse DrainOver")
+	}
+}
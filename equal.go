@@ -0,0 +1,112 @@
+package doublejump
+
+// Equal reports whether the two hashes contain the same set of objects with the same weights.
+func (this *Hash) Equal(other *Hash) bool {
+	if this == other {
+		return true
+	}
+	if this == nil || other == nil {
+		return false
+	}
+
+	// 先复制另一个哈希的状态再加锁比较，避免同时持有两把锁
+	_, ids := other.layout()
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	if len(ids) != len(this.compact.a) {
+		return false
+	}
+	for _, id := range ids {
+		if _, ok := this.compact.m[id]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// EqualLayout reports whether the two hashes have the same slot layout in both inner
+// holders, which means that they map every key to the same object.
+func (this *Hash) EqualLayout(other *Hash) bool {
+	if this == other {
+		return true
+	}
+	if this == nil || other == nil {
+		return false
+	}
+
+	loose, compact := other.layout()
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	return equalIDs(loose, this.loose.a) && equalIDs(compact, this.compact.a)
+}
+
+// 复制两个holder中的节点标识
+func (this *Hash) layout() (loose, compact []interface{}) {
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	loose = append([]interface{}(nil), this.loose.a...)
+	compact = append([]interface{}(nil), this.compact.a...)
+	return loose, compact
+}
+
+func equalIDs(a, b []interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Checksum returns a stable hash of the slot layout of both inner holders, which covers the
+// objects, their weights and their slots. Replicas applying the same mutations compute the
+// same checksum in every process, so they can gossip it to detect divergence cheaply before
+// comparing their full states. The objects should be plain values such as strings, numbers
+// or structs of them, since pointers are hashed by address.
+func (this *Hash) Checksum() uint64 {
+	if this == nil {
+		return 0
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	w := anyHasher{h: fnvOffset64}
+	for _, a := range [][]interface{}{this.loose.a, this.compact.a} {
+		w.uint64(uint64(len(a)))
+		for _, slot := range a {
+			if slot == nil {
+				w.byte(0)
+				continue
+			}
+			w.byte(1)
+			w.any(owner(slot))
+			w.uint64(uint64(replicaOf(slot)))
+		}
+	}
+	return Murmur3Mixer(w.h)
+}
@@ -0,0 +1,84 @@
+package doublejump
+
+import "testing"
+
+func TestHash_Equal(t *testing.T) {
+	h1 := NewHash()
+	h2 := NewHashWithoutLock()
+	if !h1.Equal(h2) || !h1.EqualLayout(h2) {
+		t.Fatal("empty hashes should be equal")
+	}
+
+	for i := 0; i < 5; i++ {
+		h1.Add(i)
+	}
+	for i := 4; i >= 0; i-- {
+		h2.Add(i)
+	}
+	if !h1.Equal(h2) || !h2.Equal(h1) {
+		t.Fatal("hashes with the same nodes should be equal")
+	}
+	if h1.EqualLayout(h2) {
+		t.Fatal("hashes with different slot orders should not have the same layout")
+	}
+
+	h3 := NewHash()
+	for i := 0; i < 5; i++ {
+		h3.Add(i)
+	}
+	if !h1.EqualLayout(h3) {
+		t.Fatal("hashes which applied the same operations should have the same layout")
+	}
+
+	h1.Remove(2)
+	if h1.Equal(h3) || h1.EqualLayout(h3) {
+		t.Fatal("hashes with different nodes should not be equal")
+	}
+
+	var h4 *Hash
+	if h1.Equal(h4) || h4.Equal(h1) || !h4.Equal(nil) {
+		t.Fatal("something is wrong with Equal on nil hash")
+	}
+}
+
+func TestHash_Checksum(t *testing.T) {
+	var h0 *Hash
+	if h0.Checksum() != 0 {
+		t.Fatal("h0.Checksum() != 0")
+	}
+
+	build := func(order ...string) *Hash {
+		h := NewHash()
+		for _, obj := range order {
+			h.Add(obj)
+		}
+		h.Remove("b")
+		return h
+	}
+	h1, h2 := build("a", "b", "c", "d"), build("a", "b", "c", "d")
+	if h1.Checksum() != h2.Checksum() {
+		t.Fatal("the same mutations should give the same checksum")
+	}
+	if build("a", "b", "d", "c").Checksum() == h1.Checksum() {
+		t.Fatal("a different layout should give a different checksum")
+	}
+
+	sum := h1.Checksum()
+	h1.SetVirtualSlots("a", 2)
+	if h1.Checksum() == sum {
+		t.Fatal("a different weight should give a different checksum")
+	}
+	h1.SetVirtualSlots("a", 1)
+	if h1.Checksum() != sum {
+		t.Fatal("the same layout should give the same checksum again")
+	}
+	h1.Shrink()
+	if h1.Checksum() == sum {
+		t.Fatal("Shrink should change the checksum")
+	}
+
+	// 校验和在不同进程间保持不变
+	if sum != 0x200cd26b77a512bb {
+		t.Fatalf("the checksum is not stable. sum: %#x", sum)
+	}
+}
@@ -0,0 +1,78 @@
+// Package etcd persists doublejump hashes in etcd, or any other key-value store, with
+// doublejump.WithStore:
+//
+//	s := etcd.NewStore(kv, "/rings/cache")
+//	h, err := doublejump.NewHashFromStore(s)
+//
+// The package depends on no etcd client. KV is the small part of it the store needs, and
+// is met by a few lines of glue around clientv3.KV.
+package etcd
+
+import (
+	"context"
+	"time"
+
+	"github.com/gnat88/doublejump"
+)
+
+// KV is a key-value store.
+type KV interface {
+	// Get returns the value of key, or nil if the key does not exist.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Put sets the value of key.
+	Put(ctx context.Context, key string, value []byte) error
+}
+
+// DefaultTimeout is the default time limit of every request to the KV.
+const DefaultTimeout = 5 * time.Second
+
+// Store is a doublejump.Store keeping the state under a key of a KV, encoded as the Ring
+// message defined in doublejump.proto.
+type Store struct {
+	kv  KV
+	key string
+
+	// Timeout limits every request to the KV, DefaultTimeout if not positive.
+	Timeout time.Duration
+}
+
+// NewStore creates a store keeping the state under key in kv.
+func NewStore(kv KV, key string) *Store {
+	return &Store{kv: kv, key: key, Timeout: DefaultTimeout}
+}
+
+// Save puts the encoded state under the key.
+func (this *Store) Save(s *doublejump.State) error {
+	data, err := s.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := this.context()
+	defer cancel()
+	return this.kv.Put(ctx, this.key, data)
+}
+
+// Load gets the state under the key. It returns nil if the key does not exist.
+func (this *Store) Load() (*doublejump.State, error) {
+	ctx, cancel := this.context()
+	defer cancel()
+	data, err := this.kv.Get(ctx, this.key)
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	s := new(doublejump.State)
+	if err := s.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (this *Store) context() (context.Context, context.CancelFunc) {
+	d := this.Timeout
+	if d <= 0 {
+		d = DefaultTimeout
+	}
+	return context.WithTimeout(context.Background(), d)
+}
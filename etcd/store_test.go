@@ -0,0 +1,58 @@
+package etcd
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/gnat88/doublejump"
+)
+
+type memKV struct {
+	mu sync.Mutex
+	m  map[string][]byte
+}
+
+func (this *memKV) Get(ctx context.Context, key string) ([]byte, error) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.m[key], nil
+}
+
+func (this *memKV) Put(ctx context.Context, key string, value []byte) error {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.m[key] = value
+	return nil
+}
+
+func TestStore(t *testing.T) {
+	kv := &memKV{m: make(map[string][]byte)}
+	s := NewStore(kv, "/rings/a")
+
+	h, err := doublejump.NewHashFromStore(s)
+	if err != nil || h.Len() != 0 {
+		t.Fatalf("the new hash should be empty. err: %v", err)
+	}
+	for _, id := range []string{"a", "b", "c", "d"} {
+		h.Add(id)
+	}
+	h.Remove("b")
+	if err := h.Persist(); err != nil {
+		t.Fatal(err)
+	}
+	if kv.m["/rings/a"] == nil {
+		t.Fatal("the state should be saved under the key")
+	}
+
+	h2, err := doublejump.NewHashFromStore(s)
+	if err != nil || !h2.EqualLayout(h) || h2.Generation() != h.Generation() {
+		t.Fatalf("the restored hash should have the same layout. err: %v", err)
+	}
+
+	kv.m["/rings/a"] = []byte{0xff}
+	if _, err := s.Load(); !errors.Is(err, doublejump.ErrBadProto) {
+		t.Fatalf("err != doublejump.ErrBadProto. err: %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package doublejump
+
+// EventType is the type of a change of the hash.
+type EventType int
+
+const (
+	// EventAdd means an object was added.
+	EventAdd EventType = iota + 1
+	// EventRemove means an object was removed.
+	EventRemove
+	// EventShrink means the empty slots were removed.
+	EventShrink
+	// EventWeight means the number of slots of an object was changed.
+	EventWeight
+	// EventDrain means a step of DrainOver moved a slice of the keys of an object away.
+	EventDrain
+)
+
+func (this EventType) String() string {
+	switch this {
+	case EventAdd:
+		return "add"
+	case EventRemove:
+		return "remove"
+	case EventShrink:
+		return "shrink"
+	case EventWeight:
+		return "weight"
+	case EventDrain:
+		return "drain"
+	}
+	return "unknown"
+}
+
+// Event describes a change of the hash.
+type Event struct {
+	Type EventType
+	// Node is the object added, removed, reweighted or drained, nil for EventShrink.
+	Node interface{}
+	// Generation is the generation of the hash after the change.
+	Generation uint64
+}
+
+type listener struct {
+	f func(Event)
+}
+
+// Subscribe registers f to be called on every change of the hash, and returns a function
+// to cancel the subscription. f is called with the hash locked, so it must not call back
+// into the hash.
+func (this *Hash) Subscribe(f func(ev Event)) (cancel func()) {
+	if this == nil || f == nil {
+		return func() {}
+	}
+
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	l := &listener{f: f}
+	this.listeners = append(this.listeners, l)
+	return func() {
+		if this.lock {
+			this.mu.Lock()
+			defer this.mu.Unlock()
+		}
+		this.race.lockWrite()
+		defer this.race.unlockWrite()
+
+		for i, v := range this.listeners {
+			if v == l {
+				this.listeners = append(this.listeners[:i:i], this.listeners[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// 调用方负责加锁
+func (this *Hash) emit(ev Event) {
+	if len(this.listeners) == 0 {
+		return
+	}
+
+	ev.Generation = this.eventGen()
+	for _, l := range this.listeners {
+		l.f(ev)
+	}
+}
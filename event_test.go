@@ -0,0 +1,31 @@
+package doublejump
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestHash_Subscribe(t *testing.T) {
+	h := NewHash()
+	var evs []Event
+	cancel := h.Subscribe(func(ev Event) {
+		evs = append(evs, ev)
+	})
+
+	h.Add(1)
+	h.Add(1)
+	h.Add(2)
+	h.Remove(1)
+	h.Shrink()
+	want := "[{add 1 1} {add 2 2} {remove 1 3} {shrink <nil> 4}]"
+	if fmt.Sprint(evs) != want {
+		t.Fatalf("the events are wrong. evs: %v, want: %s", evs, want)
+	}
+
+	cancel()
+	cancel()
+	h.Add(3)
+	if len(evs) != 4 {
+		t.Fatal("no event should be received after cancel")
+	}
+}
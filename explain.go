@@ -0,0 +1,76 @@
+package doublejump
+
+// Explanation describes how Get routed a key, see Explain.
+type Explanation struct {
+	// Key is the key used for the selection, after WithTimeBucket if set.
+	Key uint64
+	// Pinned tells whether the key is pinned to Candidate by Pin.
+	Pinned bool
+	// LooseIndex is the index of the slot in the loose holder the key fell on, or -1 if the
+	// loose holder was not consulted.
+	LooseIndex int
+	// LooseEmpty tells whether that slot was empty, so the compact holder was consulted.
+	LooseEmpty bool
+	// CompactIndex is the index of the slot in the compact holder the key fell on, or -1 if
+	// the compact holder was not consulted.
+	CompactIndex int
+	// Candidate is the object owning the slot, before filters such as Drain, Penalize or
+	// the bounded loads redirect the key.
+	Candidate interface{}
+	// Node is the object Get returns for the key.
+	Node interface{}
+	// Generation is the generation of the hash the explanation belongs to.
+	Generation uint64
+}
+
+// Redirected tells whether the key was sent to another object than the owner of its slot.
+func (this Explanation) Redirected() bool {
+	return this.Candidate != this.Node
+}
+
+// Explain describes how Get routes key at the moment, e.g. to answer why a key went to a
+// node.
+func (this *Hash) Explain(key uint64) Explanation {
+	e := Explanation{LooseIndex: -1, CompactIndex: -1}
+	if this == nil {
+		return e
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	e.Key = this.windowKey(key)
+	e.Generation = this.gen
+	if !this.ready() {
+		return e
+	}
+
+	var id interface{}
+	if len(this.pins) > 0 {
+		id, e.Pinned = this.pinned(e.Key)
+	}
+	if !e.Pinned {
+		e.LooseIndex = this.loose.index(e.Key)
+		if e.LooseIndex < 0 {
+			return e
+		}
+		slot := this.loose.a[e.LooseIndex]
+		if slot == nil {
+			e.LooseEmpty = true
+			e.CompactIndex = this.compact.index(e.Key)
+			if e.CompactIndex < 0 {
+				return e
+			}
+			slot = this.compact.a[e.CompactIndex]
+		}
+		id = owner(slot)
+	}
+
+	e.Candidate = this.value(id)
+	e.Node = this.get(key)
+	return e
+}
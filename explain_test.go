@@ -0,0 +1,55 @@
+package doublejump
+
+import "testing"
+
+func TestHash_Explain(t *testing.T) {
+	h := NewHash()
+	e := h.Explain(1)
+	if e.LooseIndex != -1 || e.CompactIndex != -1 || e.Node != nil {
+		t.Fatalf("unexpected explanation of an empty hash: %+v", e)
+	}
+
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+	h.Remove(3)
+	h.Remove(7)
+
+	var loose, empty bool
+	for key := uint64(0); key < 1000; key++ {
+		e := h.Explain(key)
+		if e.Node != h.Get(key) || e.Candidate != e.Node || e.Generation != h.Generation() {
+			t.Fatalf("unexpected explanation of key %d: %+v", key, e)
+		}
+		if e.LooseEmpty {
+			empty = true
+			if e.LooseIndex != 3 && e.LooseIndex != 7 || e.CompactIndex < 0 {
+				t.Fatalf("unexpected explanation of key %d: %+v", key, e)
+			}
+		} else {
+			loose = true
+			if e.LooseIndex != e.Node.(int) || e.CompactIndex != -1 {
+				t.Fatalf("unexpected explanation of key %d: %+v", key, e)
+			}
+		}
+	}
+	if !loose || !empty {
+		t.Fatal("both holders should be consulted")
+	}
+
+	h.Pin(1, 5)
+	if e := h.Explain(1); !e.Pinned || e.LooseIndex != -1 || e.Node != 5 {
+		t.Fatalf("unexpected explanation of a pinned key: %+v", e)
+	}
+
+	for key := uint64(0); ; key++ {
+		if h.Get(key) == 2 {
+			h.Drain(2)
+			e := h.Explain(key)
+			if e.Candidate != 2 || e.Node == 2 || !e.Redirected() {
+				t.Fatalf("unexpected explanation of a drained key: %+v", e)
+			}
+			break
+		}
+	}
+}
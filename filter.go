@@ -0,0 +1,47 @@
+package doublejump
+
+// 是否有需要Get绕开的节点，调用方负责加锁
+func (this *Hash) filtering() bool {
+	return this.load != nil && (this.capped > 0 || this.bounded > 0) || this.drained > 0 || this.canaries > 0 ||
+		this.breakers > 0 || this.penalized > 0
+}
+
+// 判断Get是否应该绕开该节点，调用方负责加锁
+func (this *Hash) skip(key uint64, id interface{}) bool {
+	n := this.nodes[id]
+	if n == nil {
+		return false
+	}
+	if n.drained || n.reinstate != nil || n.breaker != nil && n.breaker.Open() {
+		return true
+	}
+	if n.share > 0 && !this.accepts(n, key) {
+		return true
+	}
+	if this.load == nil {
+		return false
+	}
+	if n.capacity > 0 && this.load(n.obj) >= n.capacity {
+		return true
+	}
+	return this.bounded > 0 && this.overBound(n)
+}
+
+// 选中的节点需要绕开时，沿着候选序列确定性地找到下一个可用的节点，
+// 所有节点都不可用时仍然返回原来的节点
+func (this *Hash) avoid(key uint64, id interface{}) interface{} {
+	if !this.skip(key, id) {
+		return id
+	}
+
+	c := this.candidates(key)
+	for {
+		next, ok := c.next()
+		if !ok {
+			return id
+		}
+		if next != id && !this.skip(key, next) {
+			return next
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package doublejump
+
+import "sync"
+
+// 与golang.org/x/sync/singleflight相同的思路，以KEY为单位合并并发的调用
+type flightCall struct {
+	wg   sync.WaitGroup
+	val  interface{}
+	err  error
+	dups int
+}
+
+type flightGroup struct {
+	mu sync.Mutex
+	m  map[uint64]*flightCall
+}
+
+func (this *flightGroup) do(key uint64, fn func() (interface{}, error)) (interface{}, error, bool) {
+	this.mu.Lock()
+	if this.m == nil {
+		this.m = make(map[uint64]*flightCall)
+	}
+	if c, ok := this.m[key]; ok {
+		c.dups++
+		this.mu.Unlock()
+		c.wg.Wait()
+		return c.val, c.err, true
+	}
+
+	c := &flightCall{}
+	c.wg.Add(1)
+	this.m[key] = c
+	this.mu.Unlock()
+
+	// fn发生panic时也要唤醒等待者并清理
+	defer func() {
+		c.wg.Done()
+		this.mu.Lock()
+		if this.m[key] == c {
+			delete(this.m, key)
+		}
+		this.mu.Unlock()
+	}()
+
+	c.val, c.err = fn()
+	return c.val, c.err, c.dups > 0
+}
+
+func (this *flightGroup) forget(key uint64) {
+	this.mu.Lock()
+	delete(this.m, key)
+	this.mu.Unlock()
+}
+
+// Flight pairs a hash with per-key call deduplication, like golang.org/x/sync/singleflight:
+// concurrent calls of Do for the same key collapse into one call of fn against the object
+// selected for the key, and all callers share its result. It is threadsafe.
+type Flight struct {
+	hash  *Hash
+	group flightGroup
+}
+
+// NewFlight creates a Flight on the hash.
+func NewFlight(h *Hash) *Flight {
+	return &Flight{hash: h}
+}
+
+// Do selects the object for the key and calls fn with it, unless a call for the same key is
+// already in flight, in which case it waits for that call and returns its result. shared
+// reports whether the result was given to multiple callers. It returns ErrEmpty without
+// calling fn if the hash has no object.
+func (this *Flight) Do(key uint64, fn func(obj interface{}) (interface{}, error)) (v interface{}, err error, shared bool) {
+	return this.group.do(key, func() (interface{}, error) {
+		obj := this.hash.Get(key)
+		if obj == nil {
+			return nil, ErrEmpty
+		}
+		return fn(obj)
+	})
+}
+
+// Forget makes the next Do for the key call fn instead of waiting for the call in flight.
+func (this *Flight) Forget(key uint64) {
+	this.group.forget(key)
+}
+
+// GetOrLoad selects the object for the key and calls loader with it, e.g. to read the value
+// of the key through the cache server the key belongs to. Concurrent calls for the same key
+// collapse into one call of loader like Flight.Do, and share its result. It returns ErrEmpty
+// without calling loader if the hash has no object. loader is called without the hash
+// locked, so it may call back into the hash.
+func (this *Hash) GetOrLoad(key uint64, loader func(obj interface{}) (interface{}, error)) (interface{}, error) {
+	if this == nil {
+		return nil, ErrNilHash
+	}
+
+	v, err, _ := this.flight.do(key, func() (interface{}, error) {
+		obj := this.Get(key)
+		if obj == nil {
+			return nil, ErrEmpty
+		}
+		return loader(obj)
+	})
+	return v, err
+}
@@ -0,0 +1,128 @@
+package doublejump
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFlight(t *testing.T) {
+	h := NewHash()
+	f := NewFlight(h)
+	if _, err, _ := f.Do(1, func(interface{}) (interface{}, error) { return nil, nil }); err != ErrEmpty {
+		t.Fatalf("Do should return ErrEmpty when the hash has no node at all. err: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+
+	var calls int32
+	start := make(chan struct{})
+	release := make(chan struct{})
+	fn := func(obj interface{}) (interface{}, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(start)
+		}
+		<-release
+		return obj, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]interface{}, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v, err, _ := f.Do(42, fn)
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = v
+		}(i)
+	}
+	<-start
+	// 等待其他调用进入等待状态
+	for {
+		f.group.mu.Lock()
+		dups := f.group.m[42].dups
+		f.group.mu.Unlock()
+		if dups == len(results)-1 {
+			break
+		}
+	}
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("concurrent calls for the same key should collapse into one. calls: %d", calls)
+	}
+	for _, v := range results {
+		if v != h.Get(42) {
+			t.Fatalf("all callers should share the result against the selected node. v: %v", v)
+		}
+	}
+
+	e := errors.New("e")
+	if _, err, shared := f.Do(42, func(interface{}) (interface{}, error) { return nil, e }); err != e || shared {
+		t.Fatalf("Do should call fn again after the previous call finished. err: %v", err)
+	}
+}
+
+func TestHash_GetOrLoad(t *testing.T) {
+	h := NewHash()
+	load := func(obj interface{}) (interface{}, error) { return obj.(int) * 10, nil }
+	if _, err := h.GetOrLoad(1, load); err != ErrEmpty {
+		t.Fatalf("GetOrLoad should return ErrEmpty when the hash has no node at all. err: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+
+	var calls int32
+	start := make(chan struct{})
+	release := make(chan struct{})
+	slow := func(obj interface{}) (interface{}, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(start)
+		}
+		<-release
+		// 加载函数可以访问哈希
+		return h.Contains(obj), nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := h.GetOrLoad(7, slow); err != nil || v != true {
+				t.Errorf("unexpected result. v: %v, err: %v", v, err)
+			}
+		}()
+	}
+	<-start
+	for {
+		h.flight.mu.Lock()
+		dups := h.flight.m[7].dups
+		h.flight.mu.Unlock()
+		if dups == 4 {
+			break
+		}
+	}
+	close(release)
+	wg.Wait()
+	if calls != 1 {
+		t.Fatalf("concurrent loads for the same key should collapse into one. calls: %d", calls)
+	}
+
+	if v, err := h.GetOrLoad(3, load); err != nil || v != h.Get(3).(int)*10 {
+		t.Fatalf("unexpected result. v: %v, err: %v", v, err)
+	}
+	var nilHash *Hash
+	if _, err := nilHash.GetOrLoad(0, load); err != ErrNilHash {
+		t.Fatal("err != ErrNilHash")
+	}
+}
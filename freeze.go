@@ -0,0 +1,67 @@
+package doublejump
+
+import "errors"
+
+// ErrFrozen is returned when the membership of a frozen hash is changed, see Freeze.
+var ErrFrozen = errors.New("doublejump: hash is frozen")
+
+// Freeze makes the hash read-only until Thaw is called, e.g. during a failover or a data
+// migration where accidental membership changes from a misbehaving controller must be
+// blocked. While frozen, Add, Remove, Set, Shrink, SetVirtualSlots, Pin, Unpin and the like
+// change nothing and report no change, and the Strict view and CompareAndSet return ErrFrozen.
+// Drain, capacities and the other routing controls still work.
+func (this *Hash) Freeze() {
+	this.setFrozen(true)
+}
+
+// Thaw makes the frozen hash writable again.
+func (this *Hash) Thaw() {
+	this.setFrozen(false)
+}
+
+// Frozen reports whether the hash is frozen.
+func (this *Hash) Frozen() bool {
+	if this == nil {
+		return false
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	return this.frozen
+}
+
+func (this *Hash) setFrozen(frozen bool) {
+	if this == nil {
+		return
+	}
+
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	this.frozen = frozen
+}
+
+// 在写锁中调用f，哈希被冻结时返回ErrFrozen
+func (this *Hash) mutate(f func()) error {
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	if this.frozen {
+		return ErrFrozen
+	}
+	f()
+	return nil
+}
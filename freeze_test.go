@@ -0,0 +1,42 @@
+package doublejump
+
+import "testing"
+
+func TestHash_Freeze(t *testing.T) {
+	h := NewHash()
+	for i := 0; i < 5; i++ {
+		h.Add(i)
+	}
+	h.Remove(4)
+	gen := h.Generation()
+
+	h.Freeze()
+	if !h.Frozen() {
+		t.Fatal("h.Frozen() should be true")
+	}
+	if h.Add(10) || h.Remove(1) || h.AddWeighted(11, 2) || h.SetVirtualSlots(2, 3) || h.SetMeta(1, "meta") {
+		t.Fatal("a frozen hash should reject mutations")
+	}
+	h.Shrink()
+	h.Set([]interface{}{1})
+	if h.Generation() != gen || h.Len() != 4 || h.EmptySlots() != 1 {
+		t.Fatal("a frozen hash should not change")
+	}
+	if err := h.CompareAndSet(gen, nil); err != ErrFrozen {
+		t.Fatalf("err != ErrFrozen. err: %v", err)
+	}
+	if err := h.Strict().Add(10); err != ErrFrozen {
+		t.Fatalf("err != ErrFrozen. err: %v", err)
+	}
+	if err := h.Strict().Remove(1); err != ErrFrozen {
+		t.Fatalf("err != ErrFrozen. err: %v", err)
+	}
+	if !h.Drain(1) || !h.Drained(1) {
+		t.Fatal("a frozen hash should still drain")
+	}
+
+	h.Thaw()
+	if h.Frozen() || !h.Add(10) || h.Strict().Remove(10) != nil || h.Contains(10) {
+		t.Fatal("a thawed hash should accept mutations")
+	}
+}
@@ -1,8 +1,3 @@
 module github.com/gnat88/doublejump
 
-require (
-	github.com/dgryski/go-jump v0.0.0-20170409065014-e1f439676b57
-	github.com/serialx/hashring v0.0.0-20180504054112-49a4782e9908
-)
-
-go 1.13
+go 1.21
@@ -0,0 +1,77 @@
+package doublejump
+
+// 最近若干代的状态，按代数递增排列
+type history struct {
+	size   int
+	hashes []*Hash
+}
+
+// WithHistory makes the hash retain the states of its last n generations, so that
+// MovedSince can compare the current state with them. Every change of the mapping then
+// copies the state of the hash, so n should be small and the hash should not change often.
+func WithHistory(n int) Option {
+	return func(h *Hash) {
+		if n > 0 {
+			h.history = &history{size: n}
+		}
+	}
+}
+
+// 记录当前的状态，调用方持有写锁
+func (this *history) record(h *Hash) {
+	if len(this.hashes) == this.size {
+		recycle(this.hashes[0])
+		copy(this.hashes, this.hashes[1:])
+		this.hashes = this.hashes[:len(this.hashes)-1]
+	}
+	this.hashes = append(this.hashes, h.clone())
+}
+
+func (this *history) find(gen uint64) *Hash {
+	for _, h := range this.hashes {
+		if h.gen == gen {
+			return h
+		}
+	}
+	return nil
+}
+
+// MovedSince estimates the fraction of keys whose object has changed since the generation
+// gen, by sampling the given number of keys, e.g. to decide whether to drop a whole cache
+// after a burst of churn. It needs WithHistory, and returns 1 if the state of gen is not
+// retained anymore, as if every key had moved. samples <= 0 means 1024.
+func (this *Hash) MovedSince(gen uint64, samples int) float64 {
+	if this == nil {
+		return 1
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	if gen == this.gen {
+		return 0
+	}
+	if this.history == nil {
+		return 1
+	}
+	old := this.history.find(gen)
+	if old == nil {
+		return 1
+	}
+
+	if samples <= 0 {
+		samples = 1024
+	}
+	moved := 0
+	for i := 0; i < samples; i++ {
+		key := SplitMix64(uint64(i))
+		if this.getID(key) != old.getID(key) {
+			moved++
+		}
+	}
+	return float64(moved) / float64(samples)
+}
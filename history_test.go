@@ -0,0 +1,36 @@
+package doublejump
+
+import "testing"
+
+func TestHash_MovedSince(t *testing.T) {
+	h := NewHash(WithHistory(3))
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+	gen := h.Generation()
+	if h.MovedSince(gen, 0) != 0 {
+		t.Fatal("no key should have moved")
+	}
+
+	h.Remove(3)
+	if f := h.MovedSince(gen, 10000); f < 0.07 || f > 0.13 {
+		t.Fatalf("about 1/10 keys should have moved. f: %f", f)
+	}
+	h.Add(3)
+	if f := h.MovedSince(gen, 10000); f != 0 {
+		t.Fatalf("the keys should have moved back. f: %f", f)
+	}
+
+	h.Add(10)
+	h.Add(11)
+	if len(h.history.hashes) != 3 || h.MovedSince(gen, 0) != 1 {
+		t.Fatal("the generation should not be retained anymore")
+	}
+	if h.MovedSince(h.Generation()-1, 10000) == 0 {
+		t.Fatal("some keys should have moved to 11")
+	}
+
+	if NewHash().MovedSince(100, 0) != 1 {
+		t.Fatal("the hash without history should return 1")
+	}
+}
@@ -0,0 +1,99 @@
+package doublejump
+
+import (
+	"sync/atomic"
+)
+
+type hotKeys struct {
+	isHot func(key uint64) bool
+	seq   uint64
+}
+
+// WithHotKeys makes Get spread each key reported hot by isHot across its first two
+// candidates, alternating between them, so a single viral key cannot melt one object.
+// Other keys are not affected, nor are keys pinned by Pin. isHot is called on every Get, so
+// it must be cheap and threadsafe; HotKeySketch.Observe is a built-in detector.
+func WithHotKeys(isHot func(key uint64) bool) Option {
+	return func(h *Hash) {
+		if isHot != nil {
+			h.hot = &hotKeys{isHot: isHot}
+		}
+	}
+}
+
+// 调用方负责加锁，热点KEY轮流返回前两个候选节点，被固定的KEY仍然返回固定的节点
+func (this *hotKeys) getID(hash *Hash, key uint64) interface{} {
+	if len(hash.pins) > 0 {
+		if id, ok := hash.pinned(key); ok {
+			return id
+		}
+	}
+	c := hash.candidates(key)
+	first, _ := c.next()
+	second, ok := c.next()
+	if !ok || atomic.AddUint64(&this.seq, 1)&1 == 0 {
+		return first
+	}
+	return second
+}
+
+const (
+	sketchDepth = 4
+	sketchWidth = 1024
+)
+
+// HotKeySketch is a count-min sketch detecting hot keys, which is threadsafe. The counts are
+// halved every window observations, so keys that cool down stop being reported as hot.
+type HotKeySketch struct {
+	threshold uint32
+	window    uint64
+	total     uint64
+	counts    [sketchDepth][sketchWidth]uint32
+}
+
+// NewHotKeySketch creates a sketch reporting a key as hot once it has been observed about
+// threshold times within the recent window observations.
+func NewHotKeySketch(threshold uint32, window uint64) *HotKeySketch {
+	if window == 0 {
+		window = 1 << 20
+	}
+	return &HotKeySketch{threshold: threshold, window: window}
+}
+
+// Observe records an occurrence of the key and reports whether the key is hot.
+// It can be passed to WithHotKeys directly.
+func (this *HotKeySketch) Observe(key uint64) bool {
+	if atomic.AddUint64(&this.total, 1)%this.window == 0 {
+		this.decay()
+	}
+
+	min := ^uint32(0)
+	for i := 0; i < sketchDepth; i++ {
+		j := JumpHash(key^(uint64(i)*0x9e3779b97f4a7c15), sketchWidth)
+		if c := atomic.AddUint32(&this.counts[i][j], 1); c < min {
+			min = c
+		}
+	}
+	return min >= this.threshold
+}
+
+// Count returns the estimated number of occurrences of the key.
+func (this *HotKeySketch) Count(key uint64) uint32 {
+	min := ^uint32(0)
+	for i := 0; i < sketchDepth; i++ {
+		j := JumpHash(key^(uint64(i)*0x9e3779b97f4a7c15), sketchWidth)
+		if c := atomic.LoadUint32(&this.counts[i][j]); c < min {
+			min = c
+		}
+	}
+	return min
+}
+
+// 所有计数减半，并发的Observe可能丢失少量计数，对估计没有影响
+func (this *HotKeySketch) decay() {
+	for i := range this.counts {
+		for j := range this.counts[i] {
+			atomic.StoreUint32(&this.counts[i][j], atomic.LoadUint32(&this.counts[i][j])/2)
+		}
+	}
+}
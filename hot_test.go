@@ -0,0 +1,62 @@
+package doublejump
+
+import "testing"
+
+func TestHash_WithHotKeys(t *testing.T) {
+	hot := uint64(42)
+	h := NewHash(WithHotKeys(func(key uint64) bool { return key == hot }))
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+
+	want := h.GetN(hot, 2)
+	m := make(map[interface{}]int)
+	for i := 0; i < 100; i++ {
+		m[h.Get(hot)]++
+	}
+	if len(m) != 2 || m[want[0]] != 50 || m[want[1]] != 50 {
+		t.Fatalf("the hot key should be spread across its first two candidates. m: %v, want: %v", m, want)
+	}
+
+	for key := uint64(0); key < 100; key++ {
+		if key != hot && h.Get(key) != h.GetN(key, 1)[0] {
+			t.Fatalf("other keys should not be affected. key: %d", key)
+		}
+	}
+
+	// 被固定的热点KEY总是返回固定的节点
+	h.Pin(hot, want[1])
+	for i := 0; i < 10; i++ {
+		if h.Get(hot) != want[1] {
+			t.Fatal("a pinned hot key should stick to its pin")
+		}
+	}
+
+	h2 := NewHash(WithHotKeys(func(uint64) bool { return true }))
+	h2.Add(1)
+	if h2.Get(hot) != 1 || h2.Get(hot) != 1 {
+		t.Fatal("the only node should always be returned")
+	}
+}
+
+func TestHotKeySketch(t *testing.T) {
+	s := NewHotKeySketch(100, 1000)
+	for i := 0; i < 99; i++ {
+		if s.Observe(7) {
+			t.Fatalf("the key should not be hot yet. i: %d", i)
+		}
+	}
+	if !s.Observe(7) {
+		t.Fatal("the key should be hot after 100 observations")
+	}
+	if s.Count(8) != 0 {
+		t.Fatalf("other keys should not be counted. count: %d", s.Count(8))
+	}
+
+	for i := uint64(0); i < 900; i++ {
+		s.Observe(1000 + i)
+	}
+	if c := s.Count(7); c != 50 {
+		t.Fatalf("the counts should be halved after a window. count: %d", c)
+	}
+}
@@ -0,0 +1,80 @@
+package doublejump
+
+import "time"
+
+// 记录空位置的累计时间和上次Shrink的时间，以及浪费过多时的告警
+type hygiene struct {
+	since   time.Time // 上次记账的时间
+	empty   int       // 上次记账时的空位置数量
+	seconds float64   // 累计的空位置秒数
+	shrunk  time.Time // 上次Shrink的时间，没有Shrink过时为创建哈希的时间
+
+	// 见WithShrinkAlert
+	threshold float64
+	after     time.Duration
+	alert     func(s Stats)
+	stop      func() bool // 正在等待告警时不为空
+	seq       int         // 每次安排告警时加1，用来识别过时的告警
+}
+
+// WithShrinkAlert calls alert when the share of empty slots in the loose holder stays above
+// threshold for d, e.g. to page the owner of a ring which is never shrunk, or simply to call
+// Shrink. While the waste stays above threshold, alert is called again every d. alert is
+// called on its own goroutine without the hash locked, so the hash must be threadsafe.
+func WithShrinkAlert(threshold float64, d time.Duration, alert func(s Stats)) Option {
+	return func(h *Hash) {
+		h.hygiene.threshold = threshold
+		h.hygiene.after = d
+		h.hygiene.alert = alert
+	}
+}
+
+// 空位置的数量可能变化，调用方持有写锁
+func (this *Hash) account() {
+	// 空位置的数量不变时不需要记账
+	h := &this.hygiene
+	if n := len(this.loose.emptyPoses); n != h.empty {
+		t := now()
+		if !h.since.IsZero() {
+			h.seconds += float64(h.empty) * t.Sub(h.since).Seconds()
+		}
+		h.since = t
+		h.empty = n
+	}
+
+	if h.alert == nil {
+		return
+	}
+	if this.wasted() {
+		if h.stop == nil {
+			this.armAlert()
+		}
+	} else if h.stop != nil {
+		h.stop()
+		h.stop = nil
+	}
+}
+
+// 空位置的比例是否超过告警阈值，调用方负责加锁
+func (this *Hash) wasted() bool {
+	n := len(this.loose.a)
+	return n > 0 && float64(len(this.loose.emptyPoses))/float64(n) > this.hygiene.threshold
+}
+
+// 调用方持有写锁
+func (this *Hash) armAlert() {
+	h := &this.hygiene
+	h.seq++
+	seq := h.seq
+	h.stop = afterFunc(h.after, func() {
+		this.mu.Lock()
+		if h.seq != seq || !this.wasted() {
+			this.mu.Unlock()
+			return
+		}
+		s := this.stats()
+		this.armAlert()
+		this.mu.Unlock()
+		h.alert(s)
+	})
+}
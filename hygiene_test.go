@@ -0,0 +1,96 @@
+package doublejump
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHash_StatsHygiene(t *testing.T) {
+	cur := time.Unix(1000, 0)
+	now = func() time.Time { return cur }
+	defer func() { now = time.Now }()
+
+	h := NewHash()
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+	cur = cur.Add(time.Minute)
+	h.Remove(1)
+	h.Remove(2)
+	cur = cur.Add(10 * time.Second)
+	h.Remove(3)
+	cur = cur.Add(10 * time.Second)
+
+	s := h.Stats()
+	if s.SinceShrink != 80*time.Second {
+		t.Fatalf("unexpected SinceShrink: %v", s.SinceShrink)
+	}
+	if s.EmptySlotSeconds != 2*10+3*10 {
+		t.Fatalf("unexpected EmptySlotSeconds: %f", s.EmptySlotSeconds)
+	}
+
+	h.Shrink()
+	cur = cur.Add(time.Second)
+	s = h.Stats()
+	if s.SinceShrink != time.Second || s.EmptySlotSeconds != 50 {
+		t.Fatalf("unexpected stats after Shrink: %+v", s)
+	}
+}
+
+func TestWithShrinkAlert(t *testing.T) {
+	var fs []func()
+	afterFunc = func(d time.Duration, f func()) func() bool {
+		i := len(fs)
+		fs = append(fs, f)
+		return func() bool {
+			if fs[i] == nil {
+				return false
+			}
+			fs[i] = nil
+			return true
+		}
+	}
+	defer func() { afterFunc = func(d time.Duration, f func()) func() bool { return time.AfterFunc(d, f).Stop } }()
+	fire := func() {
+		for i, f := range fs {
+			if f != nil {
+				fs[i] = nil
+				f()
+			}
+		}
+	}
+
+	var alerts []Stats
+	h := NewHash(WithShrinkAlert(0.2, time.Hour, func(s Stats) { alerts = append(alerts, s) }))
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+	h.Remove(1)
+	h.Remove(2)
+	if len(fs) != 0 {
+		t.Fatal("the alert should not be armed below the threshold")
+	}
+
+	h.Remove(3)
+	if len(fs) != 1 {
+		t.Fatal("the alert should be armed above the threshold")
+	}
+	h.Remove(4)
+	if len(fs) != 1 {
+		t.Fatal("the alert should be armed only once")
+	}
+	fire()
+	if len(alerts) != 1 || alerts[0].EmptySlots != 4 {
+		t.Fatalf("unexpected alerts: %+v", alerts)
+	}
+	fire()
+	if len(alerts) != 2 {
+		t.Fatal("the alert should repeat while the waste persists")
+	}
+
+	h.Shrink()
+	fire()
+	if len(alerts) != 2 {
+		t.Fatal("the alert should be cancelled after Shrink")
+	}
+}
@@ -0,0 +1,90 @@
+package doublejump
+
+import "sort"
+
+// 估算影响时采样的KEY数量
+const impactSamples = 1 << 14
+
+// Impact estimates how a removal remaps the keyspace.
+type Impact struct {
+	// Moved is the estimated fraction of keys whose object changes.
+	Moved float64
+	// Heirs are the objects the moved keys go to, in descending order of their shares.
+	Heirs []Heir
+}
+
+// Heir is an object inheriting keys in an Impact.
+type Heir struct {
+	Node interface{}
+	// Share is the estimated fraction of all keys the object inherits.
+	Share float64
+}
+
+// RemovalImpact estimates the impact of removing the object without removing it, by
+// sampling keys, so that automation can deny removals which would move too much data at
+// once. The impact is zero if the object is not in the hash.
+func (this *Hash) RemovalImpact(obj interface{}) Impact {
+	if this == nil || obj == nil {
+		return Impact{}
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	return this.removalImpact(this.id(obj))
+}
+
+// RemoveWithReport is like Remove, but also returns the estimated impact of the removal,
+// see RemovalImpact, for logging it.
+func (this *Hash) RemoveWithReport(obj interface{}) (Impact, bool) {
+	if this == nil || obj == nil {
+		return Impact{}, false
+	}
+
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	id := this.id(obj)
+	impact := this.removalImpact(id)
+	return impact, this.removeNode(id)
+}
+
+// 在副本上删除节点后比较采样KEY的选择结果，调用方负责加锁
+func (this *Hash) removalImpact(id interface{}) Impact {
+	if _, ok := this.nodes[id]; !ok {
+		return Impact{}
+	}
+
+	c := this.clone()
+	defer recycle(c)
+	c.removeNode(id)
+
+	moved := 0
+	heirs := make(map[interface{}]int)
+	for i := 0; i < impactSamples; i++ {
+		key := SplitMix64(uint64(i))
+		if to := c.getID(key); to != this.getID(key) {
+			moved++
+			heirs[to]++
+		}
+	}
+
+	impact := Impact{Moved: float64(moved) / impactSamples}
+	for to, n := range heirs {
+		if to != nil {
+			impact.Heirs = append(impact.Heirs, Heir{Node: this.value(to), Share: float64(n) / impactSamples})
+		}
+	}
+	sort.Slice(impact.Heirs, func(i, j int) bool {
+		return impact.Heirs[i].Share > impact.Heirs[j].Share
+	})
+	return impact
+}
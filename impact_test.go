@@ -0,0 +1,38 @@
+package doublejump
+
+import "testing"
+
+func TestHash_RemoveWithReport(t *testing.T) {
+	h := NewHash()
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+	h.Remove(4)
+
+	before := h.Generation()
+	dry := h.RemovalImpact(7)
+	if h.Generation() != before || !h.Contains(7) {
+		t.Fatal("RemovalImpact should not change the hash")
+	}
+	if dry.Moved < 0.09 || dry.Moved > 0.13 {
+		t.Fatalf("about 1/9 keys should move. moved: %f", dry.Moved)
+	}
+	sum := 0.0
+	for i, heir := range dry.Heirs {
+		if heir.Node == 7 || i > 0 && heir.Share > dry.Heirs[i-1].Share {
+			t.Fatalf("unexpected heirs: %v", dry.Heirs)
+		}
+		sum += heir.Share
+	}
+	if sum != dry.Moved {
+		t.Fatalf("the shares of the heirs should sum up to the moved fraction. sum: %f", sum)
+	}
+
+	impact, ok := h.RemoveWithReport(7)
+	if !ok || h.Contains(7) || impact.Moved != dry.Moved || len(impact.Heirs) != len(dry.Heirs) {
+		t.Fatal("RemoveWithReport should remove the node and report the same impact")
+	}
+	if impact, ok := h.RemoveWithReport(7); ok || impact.Moved != 0 || impact.Heirs != nil {
+		t.Fatal("removing a missing node should have no impact")
+	}
+}
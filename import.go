@@ -0,0 +1,53 @@
+package doublejump
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// AddFromReader reads newline-delimited node definitions from r, turns each non-blank line
+// into an object by parse, and adds all the objects under a single lock acquisition, e.g.
+// to load a large ring from an inventory dump. parse may also return a Node to give the
+// weight and metadata. It returns the number of newly inserted objects. The whole input is
+// parsed before the hash is locked, so on a read or parse error nothing is added.
+func (this *Hash) AddFromReader(r io.Reader, parse func(line []byte) (interface{}, error)) (int, error) {
+	if this == nil {
+		return 0, ErrNilHash
+	}
+
+	var nodes []Node
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		b := bytes.TrimSpace(sc.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+		obj, err := parse(b)
+		if err != nil {
+			return 0, fmt.Errorf("doublejump: line %d: %w", line, err)
+		}
+		n, ok := obj.(Node)
+		if !ok {
+			n = Node{Value: obj}
+		}
+		if n.Value == nil {
+			return 0, fmt.Errorf("doublejump: line %d: %w", line, ErrNilNode)
+		}
+		nodes = append(nodes, n)
+	}
+	if err := sc.Err(); err != nil {
+		return 0, err
+	}
+
+	added := 0
+	err := this.mutate(func() {
+		for _, n := range nodes {
+			if this.addNode(n) {
+				added++
+			}
+		}
+	})
+	return added, err
+}
@@ -0,0 +1,51 @@
+package doublejump
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestHash_AddFromReader(t *testing.T) {
+	parse := func(line []byte) (interface{}, error) {
+		f := strings.Fields(string(line))
+		if len(f) == 1 {
+			return f[0], nil
+		}
+		w, err := strconv.Atoi(f[1])
+		if err != nil {
+			return nil, err
+		}
+		return Node{Value: f[0], Weight: w}, nil
+	}
+
+	h := NewHash()
+	h.Add("a")
+	n, err := h.AddFromReader(strings.NewReader("a\nb 3\n\n  c  \n"), parse)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || h.Len() != 3 || h.Weight("b") != 3 || !h.Contains("c") {
+		t.Fatalf("n: %d, nodes: %v", n, h.Nodes())
+	}
+
+	gen := h.Generation()
+	n, err = h.AddFromReader(strings.NewReader("d\ne x\n"), parse)
+	if n != 0 || !errors.Is(err, strconv.ErrSyntax) || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("n: %d, err: %v", n, err)
+	}
+	if h.Len() != 3 || h.Generation() != gen {
+		t.Fatal("nothing should be added on a parse error")
+	}
+
+	nilParse := func(line []byte) (interface{}, error) { return nil, nil }
+	if _, err := h.AddFromReader(strings.NewReader("x\n"), nilParse); !errors.Is(err, ErrNilNode) {
+		t.Fatalf("err != ErrNilNode. err: %v", err)
+	}
+
+	h.Freeze()
+	if _, err := h.AddFromReader(strings.NewReader("f\n"), parse); err != ErrFrozen {
+		t.Fatalf("err != ErrFrozen. err: %v", err)
+	}
+}
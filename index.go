@@ -0,0 +1,44 @@
+package doublejump
+
+// GetIndex is like Get, but returns the index of the inner loose slot of the selected
+// object instead of the object itself. An object keeps its index until it is removed or
+// Shrink is called, while the removal of other objects does not change it, so callers can
+// keep per-object data in a slice indexed by it. The maximum index is LooseLen() - 1.
+// ok is false if the hash is empty.
+func (this *Hash) GetIndex(key uint64) (idx int, ok bool) {
+	if this == nil {
+		return 0, false
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	id := this.selectID(this.windowKey(key))
+	if id == nil {
+		return 0, false
+	}
+	idx, ok = this.loose.m[id]
+	return idx, ok
+}
+
+// Index returns the index of the inner loose slot of obj, see GetIndex.
+// ok is false if obj is not in the hash.
+func (this *Hash) Index(obj interface{}) (idx int, ok bool) {
+	if this == nil || obj == nil {
+		return 0, false
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	idx, ok = this.loose.m[this.id(obj)]
+	return idx, ok
+}
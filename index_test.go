@@ -0,0 +1,50 @@
+package doublejump
+
+import "testing"
+
+func TestHash_GetIndex(t *testing.T) {
+	h := NewHash()
+	if _, ok := h.GetIndex(1); ok {
+		t.Fatal("GetIndex should fail on an empty hash")
+	}
+
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+	h.Remove(4)
+	for key := uint64(0); key < 1000; key++ {
+		idx, ok := h.GetIndex(key)
+		if !ok {
+			t.Fatalf("h.GetIndex(%d) failed", key)
+		}
+		if idx < 0 || idx >= h.LooseLen() {
+			t.Fatalf("the index is out of range. idx: %d", idx)
+		}
+		obj := h.Get(key)
+		if i, _ := h.Index(obj); i != idx {
+			t.Fatalf("h.Index(%v) != %d. i: %d", obj, idx, i)
+		}
+	}
+
+	// 删除其他节点不影响索引
+	idx, _ := h.Index(9)
+	h.Remove(0)
+	if i, _ := h.Index(9); i != idx {
+		t.Fatalf("the index of 9 changed from %d to %d", idx, i)
+	}
+	if _, ok := h.Index(0); ok {
+		t.Fatal("h.Index(0) should fail after Remove")
+	}
+
+	w := NewHash()
+	w.addNode(Node{Value: "a", Weight: 3})
+	w.Add("b")
+	ia, _ := w.Index("a")
+	ib, _ := w.Index("b")
+	for key := uint64(0); key < 100; key++ {
+		idx, _ := w.GetIndex(key)
+		if idx != ia && idx != ib {
+			t.Fatalf("GetIndex should return the index of the primary slot. idx: %d", idx)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package doublejump
+
+// Iterator yields objects one by one.
+type Iterator interface {
+	// Next returns the next object, or false if there are no more objects.
+	Next() (interface{}, bool)
+}
+
+// 在快照上遍历KEY的候选节点，首先是Get的结果
+type iter struct {
+	snap    *Snapshot
+	key     uint64
+	first   interface{}
+	started bool
+	c       candidates
+}
+
+// Iter returns an iterator over the deterministic sequence of candidates for the key: first
+// the object Get returns, then the next best objects, each object once. Retry loops can walk
+// it instead of re-deriving the objects to exclude on every attempt. The iterator works on
+// a snapshot of the current state, so later changes of the hash do not affect it.
+func (this *Hash) Iter(key uint64) Iterator {
+	return this.Snapshot().Iter(key)
+}
+
+// Iter returns an iterator over the candidates for the key, see Hash.Iter.
+func (this *Snapshot) Iter(key uint64) Iterator {
+	it := &iter{snap: this, key: key}
+	if this != nil {
+		it.key = this.hash.windowKey(key)
+		it.c = this.hash.candidates(it.key)
+	}
+	return it
+}
+
+// Next returns the next candidate.
+func (this *iter) Next() (interface{}, bool) {
+	if this.snap == nil {
+		return nil, false
+	}
+
+	h := this.snap.hash
+	if !this.started {
+		this.started = true
+		this.first = h.selectID(this.key)
+		if this.first == nil {
+			this.snap = nil
+			return nil, false
+		}
+		return h.value(this.first), true
+	}
+
+	for {
+		id, ok := this.c.next()
+		if !ok {
+			this.snap = nil
+			return nil, false
+		}
+		if id != this.first {
+			return h.value(id), true
+		}
+	}
+}
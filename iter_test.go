@@ -0,0 +1,49 @@
+package doublejump
+
+import "testing"
+
+func TestHash_Iter(t *testing.T) {
+	h := NewHash()
+	for i := 0; i < 8; i++ {
+		h.Add(i)
+	}
+	h.Remove(5)
+	h.Drain(3)
+
+	for key := uint64(0); key < 1000; key++ {
+		it := h.Iter(key)
+		var a []interface{}
+		for {
+			obj, ok := it.Next()
+			if !ok {
+				break
+			}
+			if contains(a, obj) {
+				t.Fatalf("the candidates should be distinct. key: %d, a: %v", key, a)
+			}
+			a = append(a, obj)
+		}
+		if len(a) != 7 || a[0] != h.Get(key) {
+			t.Fatalf("unexpected candidates. key: %d, a: %v", key, a)
+		}
+		if _, ok := it.Next(); ok {
+			t.Fatal("an exhausted iterator should stay exhausted")
+		}
+	}
+
+	// 迭代器不受之后的变化影响
+	it := h.Iter(42)
+	first, _ := it.Next()
+	h.Remove(first)
+	if second, ok := it.Next(); !ok || second == first {
+		t.Fatal("the iterator should keep working on its snapshot")
+	}
+
+	if _, ok := NewHash().Iter(0).Next(); ok {
+		t.Fatal("an empty hash should yield nothing")
+	}
+	var nilHash *Hash
+	if _, ok := nilHash.Iter(0).Next(); ok {
+		t.Fatal("a nil hash should yield nothing")
+	}
+}
@@ -0,0 +1,17 @@
+package doublejump
+
+// JumpHash is Google's jump consistent hash from "A Fast, Minimal Memory, Consistent Hash
+// Algorithm" by John Lamping and Eric Veach (2014). It chooses one of n buckets for the
+// key, n must be >= 1, and returns the same results as github.com/dgryski/go-jump, from
+// which it is derived (MIT License, Copyright (c) 2014 Damian Gryski).
+func JumpHash(key uint64, n int) int32 {
+	var b int64 = -1
+	var j int64
+
+	for j < int64(n) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int32(b)
+}
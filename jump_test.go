@@ -0,0 +1,51 @@
+package doublejump
+
+import (
+	"testing"
+)
+
+func TestJumpHash(t *testing.T) {
+	// 由论文中的C++实现生成
+	tests := []struct {
+		key     uint64
+		buckets []int32
+	}{
+		{0, []int32{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+		{1, []int32{0, 0, 0, 0, 0, 0, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 17, 17}},
+		{0xdeadbeef, []int32{0, 1, 2, 3, 3, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 16, 16, 16}},
+		{0x0ddc0ffeebadf00d, []int32{0, 1, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 15, 15, 15, 15}},
+	}
+	for _, tt := range tests {
+		for i, v := range tt.buckets {
+			if g := JumpHash(tt.key, i+1); g != v {
+				t.Fatalf("JumpHash(%d, %d) != %d. got: %d", tt.key, i+1, v, g)
+			}
+		}
+	}
+
+	// 来自Guava
+	golden := []int32{0, 55, 62, 8, 45, 59, 86, 97, 82, 59, 73, 37, 17, 56, 86, 21, 90, 37, 38, 83}
+	for i, v := range golden {
+		if g := JumpHash(uint64(i), 100); g != v {
+			t.Fatalf("JumpHash(%d, 100) != %d. got: %d", i, v, g)
+		}
+	}
+
+	compat := []struct {
+		key     uint64
+		buckets int
+		out     int32
+	}{
+		{10863919174838991, 11, 6},
+		{2016238256797177309, 11, 3},
+		{1673758223894951030, 11, 5},
+		{2, 100001, 80343},
+		{2201, 100001, 22152},
+		{2202, 100001, 15018},
+	}
+	for _, tt := range compat {
+		if g := JumpHash(tt.key, tt.buckets); g != tt.out {
+			t.Fatalf("JumpHash(%d, %d) != %d. got: %d", tt.key, tt.buckets, tt.out, g)
+		}
+	}
+}
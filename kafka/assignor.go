@@ -0,0 +1,88 @@
+package kafka
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/gnat88/doublejump"
+)
+
+// Plan maps member IDs to topics to the partitions assigned to the member, the same
+// shape as sarama.BalanceStrategyPlan.
+type Plan map[string]map[string][]int32
+
+// Assignor assigns the partitions of a consumer group to its members with a doublejump
+// hash over the members, so that when a member joins or leaves, only about the share of
+// that member moves, instead of most partitions as with the range and round-robin
+// strategies. A partition is assigned to the first member in its candidate sequence which
+// subscribes to the topic.
+//
+// The assignor remembers the members of the last plan, so that the next plan reuses their
+// slots. The memory is lost when another member becomes the group leader, which then
+// costs a larger movement once. Member IDs change whenever a consumer rejoins, so use
+// static membership, i.e. group instance IDs, as the member IDs for the least movement.
+// Assignor is threadsafe.
+type Assignor struct {
+	mu   sync.Mutex
+	hash *doublejump.Hash
+}
+
+// NewAssignor creates an assignor.
+func NewAssignor() *Assignor {
+	return &Assignor{hash: doublejump.NewHashWithoutLock()}
+}
+
+// Name returns the name of the strategy, which every member of the group must agree on.
+func (this *Assignor) Name() string {
+	return "doublejump"
+}
+
+// Plan assigns the partitions of the topics to the members. members maps the member IDs
+// to the topics they subscribe to. Partitions of topics which nobody subscribes to are
+// left unassigned. The partitions of each member are sorted.
+func (this *Assignor) Plan(members map[string][]string, topics map[string][]int32) Plan {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	// 新成员按ID排序后加入，保证相同历史的leader得到相同的结果
+	ids := make([]string, 0, len(members))
+	for id := range members {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	nodes := make([]interface{}, len(ids))
+	subs := make(map[string]map[string]bool, len(ids))
+	for i, id := range ids {
+		nodes[i] = id
+		subs[id] = make(map[string]bool, len(members[id]))
+		for _, topic := range members[id] {
+			subs[id][topic] = true
+		}
+	}
+	this.hash.Set(nodes)
+
+	plan := make(Plan, len(ids))
+	for topic, partitions := range topics {
+		subscribed := func(obj interface{}) bool {
+			return subs[obj.(string)][topic]
+		}
+		for _, p := range partitions {
+			obj := this.hash.GetWhere(doublejump.Key(topic, p), subscribed)
+			if obj == nil {
+				continue
+			}
+			id := obj.(string)
+			if plan[id] == nil {
+				plan[id] = make(map[string][]int32)
+			}
+			plan[id][topic] = append(plan[id][topic], p)
+		}
+	}
+
+	for _, m := range plan {
+		for _, a := range m {
+			sort.Slice(a, func(i, j int) bool { return a[i] < a[j] })
+		}
+	}
+	return plan
+}
@@ -0,0 +1,91 @@
+package kafka
+
+import (
+	"fmt"
+	"testing"
+)
+
+// 返回分区到成员的映射
+func owners(plan Plan) map[string]string {
+	m := make(map[string]string)
+	for id, topics := range plan {
+		for topic, partitions := range topics {
+			for _, p := range partitions {
+				m[fmt.Sprintf("%s/%d", topic, p)] = id
+			}
+		}
+	}
+	return m
+}
+
+func TestAssignor(t *testing.T) {
+	a := NewAssignor()
+	if a.Name() != "doublejump" {
+		t.Fatal("unexpected name")
+	}
+
+	partitions := make([]int32, 100)
+	for i := range partitions {
+		partitions[i] = int32(i)
+	}
+	topics := map[string][]int32{"orders": partitions, "audit": partitions[:10]}
+	members := map[string][]string{
+		"c1": {"orders", "audit"},
+		"c2": {"orders"},
+		"c3": {"orders"},
+		"c4": {"orders"},
+	}
+
+	m1 := owners(a.Plan(members, topics))
+	if len(m1) != 110 {
+		t.Fatalf("every partition should be assigned. len: %d", len(m1))
+	}
+	for i := 0; i < 10; i++ {
+		if m1[fmt.Sprintf("audit/%d", i)] != "c1" {
+			t.Fatal("only c1 subscribes to audit")
+		}
+	}
+
+	// 成员加入时，只有分配给新成员的分区移动
+	members["c5"] = []string{"orders"}
+	m2 := owners(a.Plan(members, topics))
+	moved := 0
+	for k, id := range m2 {
+		if id != m1[k] {
+			moved++
+			if id != "c5" {
+				t.Fatalf("%s moved from %s to %s", k, m1[k], id)
+			}
+		}
+	}
+	if moved == 0 || moved > 40 {
+		t.Fatalf("unexpected movement. moved: %d", moved)
+	}
+
+	// 成员离开时，只有该成员的分区移动
+	delete(members, "c2")
+	m3 := owners(a.Plan(members, topics))
+	for k, id := range m3 {
+		if m2[k] != "c2" && id != m2[k] {
+			t.Fatalf("%s moved from %s to %s", k, m2[k], id)
+		}
+		if id == "c2" {
+			t.Fatal("c2 left the group")
+		}
+	}
+
+	// 相同历史的leader得到相同的结果
+	b := NewAssignor()
+	b.Plan(map[string][]string{"c1": {"orders", "audit"}, "c2": {"orders"}, "c3": {"orders"}, "c4": {"orders"}}, topics)
+	b.Plan(map[string][]string{"c1": {"orders", "audit"}, "c2": {"orders"}, "c3": {"orders"}, "c4": {"orders"}, "c5": {"orders"}}, topics)
+	m4 := owners(b.Plan(members, topics))
+	for k, id := range m3 {
+		if m4[k] != id {
+			t.Fatal("the plan should be deterministic")
+		}
+	}
+
+	if plan := a.Plan(members, map[string][]int32{"nobody": {0}}); len(owners(plan)) != 0 {
+		t.Fatal("partitions of topics without subscribers should be left unassigned")
+	}
+}
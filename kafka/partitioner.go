@@ -0,0 +1,93 @@
+// Package kafka adapts doublejump to Kafka clients. The package depends on no Kafka
+// client; the types mirror the interfaces of github.com/IBM/sarama, so that wiring them
+// takes a few lines, e.g. for the producer partitioner:
+//
+//	type partitioner struct{ p *kafka.Partitioner }
+//
+//	func (this partitioner) Partition(msg *sarama.ProducerMessage, n int32) (int32, error) {
+//		var key []byte
+//		if msg.Key != nil {
+//			var err error
+//			if key, err = msg.Key.Encode(); err != nil {
+//				return -1, err
+//			}
+//		}
+//		return this.p.Partition(key, n)
+//	}
+//
+//	func (this partitioner) RequiresConsistency() bool { return this.p.RequiresConsistency() }
+//
+//	config.Producer.Partitioner = func(topic string) sarama.Partitioner {
+//		return partitioner{kafka.NewPartitioner()}
+//	}
+//
+// Assignor plans the partitions of a consumer group, and is wired the same way into a
+// sarama.BalanceStrategy by converting the member metadata to the subscribed topics.
+package kafka
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gnat88/doublejump"
+)
+
+// ErrNoPartitions is returned when a topic has no partition.
+var ErrNoPartitions = errors.New("kafka: no partitions")
+
+// Partitioner selects the partition of a message by its key with a doublejump hash over
+// the partition IDs. When partitions are added to a topic, only the keys moving to the new
+// partitions change partition, unlike the modulo of the default hash partitioner.
+// Messages without a key are spread round-robin. Partitioner is threadsafe.
+type Partitioner struct {
+	mu    sync.RWMutex
+	rings map[int32]*doublejump.Hash // 分区数量到哈希的映射
+	rr    uint32
+}
+
+// NewPartitioner creates a partitioner.
+func NewPartitioner() *Partitioner {
+	return &Partitioner{rings: make(map[int32]*doublejump.Hash)}
+}
+
+// Partition returns the partition of a message with the key among numPartitions partitions.
+func (this *Partitioner) Partition(key []byte, numPartitions int32) (int32, error) {
+	if numPartitions <= 0 {
+		return -1, ErrNoPartitions
+	}
+	if key == nil {
+		return int32(atomic.AddUint32(&this.rr, 1) % uint32(numPartitions)), nil
+	}
+
+	p := this.ring(numPartitions).Get(doublejump.KeyBytes(key))
+	return p.(int32), nil
+}
+
+// RequiresConsistency reports that messages with the same key must go to the same
+// partition, so clients must not redirect them to another partition on failure.
+func (this *Partitioner) RequiresConsistency() bool {
+	return true
+}
+
+// 分区依次加入哈希，没有空位置，所以结果和直接使用jump hash一样
+func (this *Partitioner) ring(n int32) *doublejump.Hash {
+	this.mu.RLock()
+	h, ok := this.rings[n]
+	this.mu.RUnlock()
+	if ok {
+		return h
+	}
+
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if h, ok = this.rings[n]; !ok {
+		b := doublejump.NewBuilder()
+		for p := int32(0); p < n; p++ {
+			b.Add(p)
+		}
+		h = b.Build()
+		this.rings[n] = h
+	}
+	return h
+}
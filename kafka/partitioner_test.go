@@ -0,0 +1,64 @@
+package kafka
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestPartitioner(t *testing.T) {
+	p := NewPartitioner()
+	if !p.RequiresConsistency() {
+		t.Fatal("the partitioner should require consistency")
+	}
+	if _, err := p.Partition([]byte("k"), 0); err != ErrNoPartitions {
+		t.Fatal("err != ErrNoPartitions")
+	}
+
+	const n = 10000
+	counts := make(map[int32]int)
+	before := make([]int32, n)
+	for i := 0; i < n; i++ {
+		key := []byte(strconv.Itoa(i))
+		part, err := p.Partition(key, 8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if again, _ := p.Partition(key, 8); again != part {
+			t.Fatal("the partition should be stable")
+		}
+		before[i] = part
+		counts[part]++
+	}
+	if len(counts) != 8 {
+		t.Fatalf("len(counts) != 8. len: %d", len(counts))
+	}
+	for part, c := range counts {
+		if c < n/8/2 || c > n/8*2 {
+			t.Fatalf("the partitions are not balanced. partition: %d, count: %d", part, c)
+		}
+	}
+
+	// 增加分区时，KEY只会移动到新的分区
+	moved := 0
+	for i := 0; i < n; i++ {
+		part, _ := p.Partition([]byte(strconv.Itoa(i)), 10)
+		if part != before[i] {
+			moved++
+			if part < 8 {
+				t.Fatalf("key %d moved between the old partitions", i)
+			}
+		}
+	}
+	if moved < n/5/2 || moved > n/5*2 {
+		t.Fatalf("about 1/5 of the keys should move. moved: %d", moved)
+	}
+
+	seen := make(map[int32]bool)
+	for i := 0; i < 4; i++ {
+		part, _ := p.Partition(nil, 4)
+		seen[part] = true
+	}
+	if len(seen) != 4 {
+		t.Fatal("messages without a key should be spread round-robin")
+	}
+}
@@ -0,0 +1,196 @@
+// Package ketama reproduces the key to server mapping of twemproxy's ketama distribution,
+// so that a fleet can move from twemproxy to an embedded doublejump hash in steps: first
+// route the same keys to the same servers as twemproxy does with this package, keeping the
+// cached data valid, then compare the two placements with doublejump.Shadow and migrate.
+//
+// The mapping follows nc_ketama.c of twemproxy: every server gets 160 points on the
+// continuum per its share of the total weight, rounded down to a multiple of 4 in float32
+// arithmetic as twemproxy does, 4 points from each MD5 digest of "<name>-<i>", and a key
+// goes to the first point at or after its hash, wrapping around. The name of a server is
+// what twemproxy uses, i.e. the name given in the server line, or else "host:port", or
+// only "host" if the port is 11211, see DefaultName.
+package ketama
+
+import (
+	"crypto/md5"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+const (
+	pointsPerServer = 160
+	pointsPerHash   = 4
+	// 与libmemcached兼容，twemproxy对这个端口的服务器只用主机名做哈希
+	defaultPort = 11211
+)
+
+// DefaultName returns the name twemproxy hashes a server without a name in its server line
+// by: "host:port", or only host if port is 11211, for compatibility with libmemcached.
+func DefaultName(host string, port int) string {
+	if port == defaultPort {
+		return host
+	}
+	return host + ":" + strconv.Itoa(port)
+}
+
+// Server is a server of the continuum.
+type Server struct {
+	// Name is the name of the server, see the package doc.
+	Name string
+	// Weight is the weight of the server. Servers with zero or negative weight get no point.
+	Weight int
+}
+
+// HashFunc hashes a key to a point of the continuum.
+type HashFunc func(key []byte) uint32
+
+type point struct {
+	value uint32
+	index int
+}
+
+// Continuum maps keys to servers. It is immutable and threadsafe. Build a new one when
+// the servers change, as twemproxy does when it ejects or restores a server.
+type Continuum struct {
+	servers []Server
+	points  []point
+	hash    HashFunc
+}
+
+// New creates a continuum over the servers, hashing keys with FNV1a64, which is the
+// default hash of twemproxy.
+func New(servers []Server) *Continuum {
+	return NewWithHash(servers, FNV1a64)
+}
+
+// NewWithHash creates a continuum over the servers, hashing keys with hash, which must be
+// the same as the hash setting of the twemproxy pool.
+func NewWithHash(servers []Server, hash HashFunc) *Continuum {
+	this := &Continuum{servers: append([]Server(nil), servers...), hash: hash}
+
+	live, total := 0, 0
+	for _, s := range servers {
+		if s.Weight > 0 {
+			live++
+			total += s.Weight
+		}
+	}
+	if live == 0 {
+		return this
+	}
+
+	for i, s := range servers {
+		if s.Weight <= 0 {
+			continue
+		}
+
+		n := points(s.Weight, total, live)
+		for j := 1; j <= n/pointsPerHash; j++ {
+			digest := md5.Sum([]byte(fmt.Sprintf("%s-%d", s.Name, j-1)))
+			for x := 0; x < pointsPerHash; x++ {
+				this.points = append(this.points, point{value: ketamaHash(digest, x), index: i})
+			}
+		}
+	}
+	// 值相同的点按加入的顺序排列
+	sort.SliceStable(this.points, func(i, j int) bool {
+		return this.points[i].value < this.points[j].value
+	})
+	return this
+}
+
+// 与nc_ketama.c一样用float32计算服务器的点数: floorf(pct*160/4*nlive+1e-10)*4，
+// 其中1e-10是double，加法在double中进行，再转换回float。每一步都显式转换，避免融合运算
+func points(weight, total, live int) int {
+	pct := float32(weight) / float32(total)
+	v := float32(pct * pointsPerServer)
+	v = float32(v / pointsPerHash)
+	v = float32(v * float32(live))
+	v = float32(float64(v) + 0.0000000001)
+	return int(math.Floor(float64(v))) * pointsPerHash
+}
+
+// 取MD5摘要中的第alignment组4个字节，按小端序组成32位整数
+func ketamaHash(digest [md5.Size]byte, alignment int) uint32 {
+	b := digest[alignment*4:]
+	return uint32(b[3])<<24 | uint32(b[2])<<16 | uint32(b[1])<<8 | uint32(b[0])
+}
+
+// Len returns the number of points on the continuum.
+func (this *Continuum) Len() int {
+	return len(this.points)
+}
+
+// Dispatch returns the index of the server owning the hash of a key, or -1 if there is
+// no server with a positive weight.
+func (this *Continuum) Dispatch(hash uint32) int {
+	if len(this.points) == 0 {
+		return -1
+	}
+
+	i := sort.Search(len(this.points), func(i int) bool {
+		return this.points[i].value >= hash
+	})
+	if i == len(this.points) {
+		i = 0
+	}
+	return this.points[i].index
+}
+
+// GetIndex returns the index of the server for the key, or -1 if there is none.
+func (this *Continuum) GetIndex(key []byte) int {
+	return this.Dispatch(this.hash(key))
+}
+
+// Get returns the server for the key. ok is false if there is none.
+func (this *Continuum) Get(key []byte) (s Server, ok bool) {
+	i := this.GetIndex(key)
+	if i < 0 {
+		return Server{}, false
+	}
+	return this.servers[i], true
+}
+
+// GetString is like Get, but takes a string key.
+func (this *Continuum) GetString(key string) (s Server, ok bool) {
+	return this.Get([]byte(key))
+}
+
+// twemproxy将char转换为uint32_t，x86等平台上char是有符号的，大于0x7f的字节会做符号扩展
+func signExtend(c byte) uint32 {
+	return uint32(int32(int8(c)))
+}
+
+// FNV1a64 is the fnv1a_64 hash of twemproxy, which computes the 64-bit FNV-1a with 32-bit
+// arithmetic, i.e. with the offset basis and prime truncated to 32 bits, so it is the low
+// 32 bits of the 64-bit FNV-1a for ASCII keys. Like twemproxy built for x86, where char is
+// signed, bytes above 0x7f are sign-extended.
+func FNV1a64(key []byte) uint32 {
+	const (
+		offset = uint32(0xcbf29ce484222325 & 0xffffffff)
+		prime  = uint32(0x100000001b3 & 0xffffffff)
+	)
+	h := offset
+	for _, c := range key {
+		h ^= signExtend(c)
+		h *= prime
+	}
+	return h
+}
+
+// FNV1a32 is the fnv1a_32 hash of twemproxy. Bytes above 0x7f are sign-extended as in FNV1a64.
+func FNV1a32(key []byte) uint32 {
+	h := uint32(2166136261)
+	for _, c := range key {
+		h ^= signExtend(c)
+		h *= 16777619
+	}
+	return h
+}
+
+// MD5 is the md5 hash of twemproxy, the first 4 bytes of the MD5 digest in little-endian order.
+func MD5(key []byte) uint32 {
+	return ketamaHash(md5.Sum(key), 0)
+}
@@ -0,0 +1,180 @@
+package ketama
+
+import (
+	"crypto/md5"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"testing"
+)
+
+func TestHashFuncs(t *testing.T) {
+	// FNV-1a的公开测试向量，fnv1a_64取64位结果的低32位
+	vectors := []struct {
+		key    string
+		fnv32  uint32
+		fnv64  uint64
+		md5Low uint32
+	}{
+		{"", 0x811c9dc5, 0xcbf29ce484222325, 0xd98c1dd4},
+		{"a", 0xe40c292c, 0xaf63dc4c8601ec8c, 0xb975c10c},
+		{"foobar", 0xbf9cf968, 0x85944171f73967e8, 0x22f65838},
+	}
+	for _, v := range vectors {
+		if h := FNV1a32([]byte(v.key)); h != v.fnv32 {
+			t.Fatalf("FNV1a32(%q) != %#x. h: %#x", v.key, v.fnv32, h)
+		}
+		if h := FNV1a64([]byte(v.key)); h != uint32(v.fnv64) {
+			t.Fatalf("FNV1a64(%q) != %#x. h: %#x", v.key, uint32(v.fnv64), h)
+		}
+		if h := MD5([]byte(v.key)); h != v.md5Low {
+			t.Fatalf("MD5(%q) != %#x. h: %#x", v.key, v.md5Low, h)
+		}
+	}
+
+	for i := 0; i < 1000; i++ {
+		key := []byte(fmt.Sprintf("key:%d", i))
+		h := fnv.New64a()
+		h.Write(key)
+		if FNV1a64(key) != uint32(h.Sum64()) {
+			t.Fatalf("FNV1a64 should be the low 32 bits of FNV-1a 64 for ASCII keys. key: %s", key)
+		}
+	}
+
+	// 与twemproxy一样对大于0x7f的字节做符号扩展
+	want := uint32(0x84222325) ^ 0xffffff80
+	want *= 0x1b3
+	if h := FNV1a64([]byte{0x80}); h != want {
+		t.Fatalf("FNV1a64 should sign-extend the bytes. h: %#x, want: %#x", h, want)
+	}
+}
+
+func TestContinuum(t *testing.T) {
+	servers := []Server{
+		{Name: "10.0.0.1:11211", Weight: 1},
+		{Name: "10.0.0.2:11211", Weight: 1},
+		{Name: "10.0.0.3:11211", Weight: 2},
+		{Name: "10.0.0.4:11211", Weight: 0},
+	}
+	c := New(servers)
+
+	// 每个服务器按权重占比分到160*3个点中的一部分，每4个点一组
+	if c.Len() != 120+120+240 {
+		t.Fatalf("c.Len() != 480. len: %d", c.Len())
+	}
+	for i := 1; i < len(c.points); i++ {
+		if c.points[i-1].value > c.points[i].value {
+			t.Fatal("the points are not sorted")
+		}
+	}
+
+	// 第一个摘要的4组字节都是该服务器的点
+	digest := md5.Sum([]byte("10.0.0.1:11211-0"))
+	for x := 0; x < 4; x++ {
+		if c.Dispatch(ketamaHash(digest, x)) != 0 {
+			t.Fatal("the points of the first digest should belong to the first server")
+		}
+	}
+
+	// 超过最后一个点时回到第一个点
+	if c.Dispatch(math.MaxUint32) != c.points[0].index && c.points[len(c.points)-1].value != math.MaxUint32 {
+		t.Fatal("the continuum should wrap around")
+	}
+
+	counts := make(map[string]int)
+	const n = 100000
+	for i := 0; i < n; i++ {
+		key := fmt.Sprintf("key:%d", i)
+		s, ok := c.GetString(key)
+		if !ok {
+			t.Fatal("c.GetString() failed")
+		}
+		if c.GetIndex([]byte(key)) != c.Dispatch(FNV1a64([]byte(key))) {
+			t.Fatal("c.GetIndex() != c.Dispatch(FNV1a64())")
+		}
+		counts[s.Name]++
+	}
+	if counts["10.0.0.4:11211"] != 0 {
+		t.Fatal("a server without weight should get no key")
+	}
+	if r := float64(counts["10.0.0.3:11211"]) / n; r < 0.4 || r > 0.6 {
+		t.Fatalf("the keys should follow the weights. r: %f", r)
+	}
+
+	empty := New(nil)
+	if _, ok := empty.GetString("a"); ok || empty.Dispatch(1) != -1 {
+		t.Fatal("an empty continuum should select nothing")
+	}
+}
+
+func TestDefaultName(t *testing.T) {
+	if n := DefaultName("10.0.0.1", 11211); n != "10.0.0.1" {
+		t.Fatalf("the port 11211 should be dropped. n: %s", n)
+	}
+	if n := DefaultName("10.0.0.1", 11212); n != "10.0.0.1:11212" {
+		t.Fatalf("n != 10.0.0.1:11212. n: %s", n)
+	}
+}
+
+func TestContinuum_Vectors(t *testing.T) {
+	// 由twemproxy的nc_ketama.c(ketama_update、ketama_dispatch)和hash_fnv1a_64在x86-64上
+	// 用gcc编译后计算得到，服务器行为10.0.0.1:11211:29、10.0.0.2:11212:15和
+	// 10.0.0.3:11211:16 cache3
+	c := New([]Server{
+		{Name: DefaultName("10.0.0.1", 11211), Weight: 29},
+		{Name: DefaultName("10.0.0.2", 11212), Weight: 15},
+		{Name: "cache3", Weight: 16},
+	})
+	if c.Len() != 476 {
+		t.Fatalf("c.Len() != 476. len: %d", c.Len())
+	}
+	vectors := []struct {
+		key   string
+		index int
+	}{
+		{"foo", 2},
+		{"bar", 0},
+		{"baz", 0},
+		{"user:1", 2},
+		{"user:2", 2},
+		{"user:3", 2},
+		{"session:42", 0},
+		{"a", 2},
+		{"hello world", 2},
+		{"key:1000", 2},
+		{"你好", 0},
+		{"", 0},
+		{"user:7919", 0},
+		{"user:15838", 1},
+		{"session:abc", 2},
+		{"session:xyz", 0},
+		{"cart:1", 0},
+		{"cart:99", 1},
+		{"zzz", 1},
+		{"memcached", 1},
+	}
+	for _, v := range vectors {
+		if i := c.GetIndex([]byte(v.key)); i != v.index {
+			t.Fatalf("c.GetIndex(%q) != %d. i: %d", v.key, v.index, i)
+		}
+	}
+
+	// float32与float64的取整结果不同的权重，点数同样来自nc_ketama.c
+	weights := []struct {
+		weights []int
+		points  int
+	}{
+		{[]int{29, 15, 16}, 476},
+		{[]int{29, 84, 7}, 476},
+		{[]int{58, 34, 28}, 476},
+	}
+	for _, v := range weights {
+		var servers []Server
+		for i, w := range v.weights {
+			servers = append(servers, Server{Name: fmt.Sprint(i), Weight: w})
+		}
+		if n := New(servers).Len(); n != v.points {
+			t.Fatalf("New(%v).Len() != %d. n: %d", v.weights, v.points, n)
+		}
+	}
+}
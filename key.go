@@ -0,0 +1,184 @@
+package doublejump
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+)
+
+// Fold128 folds a 128-bit key into 64 bits, so that every bit of the input affects the
+// result. It is the Hash128to64 function of CityHash.
+func Fold128(hi, lo uint64) uint64 {
+	const kMul = 0x9ddfea08eb382d69
+	a := (lo ^ hi) * kMul
+	a ^= a >> 47
+	b := (hi ^ a) * kMul
+	b ^= b >> 47
+	return b * kMul
+}
+
+// Get128 returns an object according to the 128-bit key provided.
+func (this *Hash) Get128(hi, lo uint64) interface{} {
+	return this.Get(Fold128(hi, lo))
+}
+
+// Get16 returns an object according to the 16-byte key provided, such as a UUID or a
+// 128-bit content hash. The bytes are read in big-endian order.
+func (this *Hash) Get16(b [16]byte) interface{} {
+	return this.Get(Fold128(binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])))
+}
+
+// GetAny returns an object according to the key provided, which can be any value made of
+// booleans, numbers, strings, byte slices, arrays and structs of them, e.g. struct{ tenant, shard }.
+// The key is hashed deterministically by its contents, so that every process maps the same key
+// to the same object. hash/maphash is deliberately not used, since its seeds are per process.
+// Pointers, channels and functions are hashed by their addresses, which is only stable within
+// the process.
+func (this *Hash) GetAny(key interface{}) interface{} {
+	return this.Get(hashAny(key))
+}
+
+// Key combines several fields, e.g. a tenant and an entity ID, into a well-mixed key.
+// The fields are hashed like GetAny does, each with its type and length, so that
+// Key("ab", "c"), Key("a", "bc") and Key("abc") all differ, unlike concatenated strings.
+// The result is deterministic across processes.
+func Key(parts ...interface{}) uint64 {
+	w := anyHasher{h: fnvOffset64}
+	w.uint64(uint64(len(parts)))
+	for _, part := range parts {
+		w.any(part)
+	}
+	return Murmur3Mixer(w.h)
+}
+
+// KeyString is the same as Key with string fields, without boxing them.
+func KeyString(parts ...string) uint64 {
+	w := anyHasher{h: fnvOffset64}
+	w.uint64(uint64(len(parts)))
+	for _, part := range parts {
+		w.byte(byte(reflect.String))
+		w.string(part)
+	}
+	return Murmur3Mixer(w.h)
+}
+
+// KeyBytes is the same as Key with []byte fields, without boxing them.
+func KeyBytes(parts ...[]byte) uint64 {
+	w := anyHasher{h: fnvOffset64}
+	w.uint64(uint64(len(parts)))
+	for _, part := range parts {
+		w.byte(byte(reflect.Slice))
+		w.bytes(part)
+	}
+	return Murmur3Mixer(w.h)
+}
+
+const (
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+)
+
+// 对值的内容做FNV-1a哈希，每个值前面加上类型标记，避免不同类型的值拼接后相同
+type anyHasher struct {
+	h uint64
+}
+
+func hashAny(v interface{}) uint64 {
+	w := anyHasher{h: fnvOffset64}
+	w.any(v)
+	return Murmur3Mixer(w.h)
+}
+
+func (this *anyHasher) byte(b byte) {
+	this.h ^= uint64(b)
+	this.h *= fnvPrime64
+}
+
+func (this *anyHasher) uint64(v uint64) {
+	for i := 0; i < 8; i++ {
+		this.byte(byte(v >> (8 * i)))
+	}
+}
+
+func (this *anyHasher) string(s string) {
+	this.uint64(uint64(len(s)))
+	for i := 0; i < len(s); i++ {
+		this.byte(s[i])
+	}
+}
+
+func (this *anyHasher) bytes(b []byte) {
+	this.uint64(uint64(len(b)))
+	for _, c := range b {
+		this.byte(c)
+	}
+}
+
+func (this *anyHasher) any(v interface{}) {
+	switch x := v.(type) {
+	case nil:
+		this.byte(byte(reflect.Invalid))
+	case string:
+		this.byte(byte(reflect.String))
+		this.string(x)
+	case []byte:
+		this.byte(byte(reflect.Slice))
+		this.bytes(x)
+	case int:
+		this.byte(byte(reflect.Int))
+		this.uint64(uint64(x))
+	case int64:
+		this.byte(byte(reflect.Int64))
+		this.uint64(uint64(x))
+	case uint64:
+		this.byte(byte(reflect.Uint64))
+		this.uint64(x)
+	default:
+		this.value(reflect.ValueOf(v))
+	}
+}
+
+func (this *anyHasher) value(v reflect.Value) {
+	k := v.Kind()
+	this.byte(byte(k))
+	switch k {
+	case reflect.Bool:
+		if v.Bool() {
+			this.byte(1)
+		} else {
+			this.byte(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		this.uint64(uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		this.uint64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		this.uint64(math.Float64bits(v.Float()))
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		this.uint64(math.Float64bits(real(c)))
+		this.uint64(math.Float64bits(imag(c)))
+	case reflect.String:
+		this.string(v.String())
+	case reflect.Array, reflect.Slice:
+		n := v.Len()
+		this.uint64(uint64(n))
+		for i := 0; i < n; i++ {
+			this.value(v.Index(i))
+		}
+	case reflect.Struct:
+		n := v.NumField()
+		this.uint64(uint64(n))
+		for i := 0; i < n; i++ {
+			this.value(v.Field(i))
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			this.byte(byte(reflect.Invalid))
+		} else {
+			this.value(v.Elem())
+		}
+	case reflect.Ptr, reflect.Chan, reflect.Func, reflect.UnsafePointer, reflect.Map:
+		this.uint64(uint64(v.Pointer()))
+	}
+}
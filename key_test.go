@@ -0,0 +1,141 @@
+package doublejump
+
+import (
+	"math/bits"
+	"testing"
+)
+
+func TestFold128(t *testing.T) {
+	m := make(map[uint64]bool)
+	for i := uint64(0); i < 64; i++ {
+		m[Fold128(1<<i, 0)] = true
+		m[Fold128(0, 1<<i)] = true
+	}
+	if len(m) != 128 {
+		t.Fatalf("every bit of the 128-bit key should affect the result. len(m): %d", len(m))
+	}
+	if Fold128(1, 2) == Fold128(2, 1) {
+		t.Fatal("Fold128 should not be symmetric")
+	}
+}
+
+func TestHash_Get128(t *testing.T) {
+	h := NewHash()
+	if h.Get128(1, 2) != nil {
+		t.Fatal("Get128 should return nil when the hash has no node at all")
+	}
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+
+	// 只有高位不同的KEY也应该分散到不同的节点
+	m := make(map[interface{}]int)
+	for i := uint64(0); i < 1000; i++ {
+		m[h.Get128(i, 42)]++
+	}
+	if len(m) != 10 {
+		t.Fatalf("keys differing only in the high bits should spread. len(m): %d", len(m))
+	}
+
+	b := [16]byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2}
+	if h.Get16(b) != h.Get128(1, 2) {
+		t.Fatal("Get16 should read the bytes in big-endian order")
+	}
+}
+
+func TestHashAny(t *testing.T) {
+	type shard struct {
+		tenant string
+		shard  int
+	}
+
+	if hashAny(shard{"a", 1}) != hashAny(shard{"a", 1}) {
+		t.Fatal("hashAny should be deterministic")
+	}
+
+	m := make(map[uint64]interface{})
+	for _, v := range []interface{}{
+		nil, "", "a", "ab", []byte("a"), 0, 1, int64(1), uint64(1), int32(1), 1.0, true, false,
+		shard{"a", 1}, shard{"a", 2}, shard{"b", 1}, shard{"", 1},
+		[2]string{"ab", ""}, [2]string{"a", "b"}, struct{ a, b string }{"ab", ""}, complex(1, 2),
+	} {
+		k := hashAny(v)
+		if old, ok := m[k]; ok {
+			t.Fatalf("different keys should have different hashes. v: %#v, old: %#v", v, old)
+		}
+		m[k] = v
+	}
+
+	// 通过interface{}字段嵌套的值按照其动态值来哈希
+	type wrapper struct{ v interface{} }
+	if hashAny(wrapper{"a"}) == hashAny(wrapper{"b"}) || hashAny(wrapper{"a"}) != hashAny(wrapper{"a"}) {
+		t.Fatal("hashAny should hash the dynamic values of interface fields")
+	}
+}
+
+func TestHash_GetAny(t *testing.T) {
+	type shard struct {
+		tenant string
+		shard  int
+	}
+
+	h := NewHash()
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+
+	m := make(map[interface{}]int)
+	for i := 0; i < 1000; i++ {
+		obj := h.GetAny(shard{"tenant", i})
+		if obj != h.GetAny(shard{"tenant", i}) {
+			t.Fatal("GetAny should be deterministic")
+		}
+		m[obj]++
+	}
+	if len(m) != 10 {
+		t.Fatalf("the keys should spread over all the nodes. len(m): %d", len(m))
+	}
+}
+
+func TestKey(t *testing.T) {
+	if Key("a", 1) != Key("a", 1) {
+		t.Fatal("Key should be deterministic")
+	}
+
+	m := map[uint64]bool{
+		Key("ab", "c"):       true,
+		Key("a", "bc"):       true,
+		Key("abc"):           true,
+		Key("a", "b", "c"):   true,
+		Key():                true,
+		Key(""):              true,
+		Key("", ""):          true,
+		Key(1, 2):            true,
+		Key(2, 1):            true,
+		Key(int64(1), 2):     true,
+		Key([]byte("ab"), 1): true,
+		Key("ab", 1):         true,
+	}
+	if len(m) != 12 {
+		t.Fatalf("the keys should differ. len(m): %d", len(m))
+	}
+
+	if KeyString("tenant", "42") != Key("tenant", "42") {
+		t.Fatal("KeyString() != Key()")
+	}
+	if KeyBytes([]byte("tenant"), nil) != Key([]byte("tenant"), []byte(nil)) {
+		t.Fatal("KeyBytes() != Key()")
+	}
+	if KeyString() != Key() {
+		t.Fatal("KeyString() != Key()")
+	}
+
+	// 相邻的输入也应该充分混合
+	ones := 0
+	for i := 0; i < 1000; i++ {
+		ones += bits.OnesCount64(Key("t", i) ^ Key("t", i+1))
+	}
+	if avg := float64(ones) / 1000; avg < 28 || avg > 36 {
+		t.Fatalf("the keys are not well mixed. avg: %f", avg)
+	}
+}
@@ -0,0 +1,115 @@
+package doublejump
+
+// AddLabeled adds an object with string labels, e.g. gpu=true or zone=us-east-1a, so that
+// GetSelect can restrict the candidates of a key to the objects matching a selector. It
+// reports whether the object was newly inserted.
+func (this *Hash) AddLabeled(obj interface{}, labels map[string]string) bool {
+	if this == nil || obj == nil {
+		return false
+	}
+
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	if !this.add(obj) {
+		return false
+	}
+	this.nodes[this.id(obj)].labels = copyLabels(labels)
+	return true
+}
+
+// SetLabels replaces the labels of the object. It returns false if the object is not in
+// the hash.
+func (this *Hash) SetLabels(obj interface{}, labels map[string]string) bool {
+	if this == nil || obj == nil {
+		return false
+	}
+
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	n, ok := this.nodes[this.id(obj)]
+	if ok {
+		// 替换而不是修改原来的map，快照中的节点仍然引用它
+		n.labels = copyLabels(labels)
+		this.retire()
+	}
+	return ok
+}
+
+// Labels returns a copy of the labels of the object, nil if it has none or is not in the
+// hash.
+func (this *Hash) Labels(obj interface{}) map[string]string {
+	if this == nil || obj == nil {
+		return nil
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	if n, ok := this.nodes[this.id(obj)]; ok {
+		return copyLabels(n.labels)
+	}
+	return nil
+}
+
+// GetSelect returns the first object in the deterministic candidate sequence of the key
+// whose labels contain every label of selector, so that one ring can serve several routing
+// policies without duplicating its membership. An empty selector matches every object. It
+// returns nil if no object matches. Keys routed to an object by Get also stay with it under
+// every selector it matches.
+func (this *Hash) GetSelect(key uint64, selector map[string]string) interface{} {
+	if this == nil {
+		return nil
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	c := this.candidates(this.windowKey(key))
+	for {
+		id, ok := c.next()
+		if !ok {
+			return nil
+		}
+		if matches(this.nodes[id].labels, selector) {
+			return this.value(id)
+		}
+	}
+}
+
+func matches(labels, selector map[string]string) bool {
+	for k, v := range selector {
+		if l, ok := labels[k]; !ok || l != v {
+			return false
+		}
+	}
+	return true
+}
+
+func copyLabels(labels map[string]string) map[string]string {
+	if len(labels) == 0 {
+		return nil
+	}
+	m := make(map[string]string, len(labels))
+	for k, v := range labels {
+		m[k] = v
+	}
+	return m
+}
@@ -0,0 +1,51 @@
+package doublejump
+
+import "testing"
+
+func TestHash_GetSelect(t *testing.T) {
+	h := NewHash()
+	for i := 0; i < 10; i++ {
+		labels := map[string]string{"zone": "a"}
+		if i%3 == 0 {
+			labels["gpu"] = "true"
+		}
+		if !h.AddLabeled(i, labels) {
+			t.Fatalf("h.AddLabeled(%d) should return true", i)
+		}
+	}
+	if h.AddLabeled(1, nil) || h.Labels(1)["zone"] != "a" {
+		t.Fatal("adding an existing object should not change its labels")
+	}
+
+	gpu := map[string]string{"gpu": "true"}
+	counts := make(map[interface{}]int)
+	for key := uint64(0); key < 1000; key++ {
+		obj := h.GetSelect(key, gpu)
+		if obj.(int)%3 != 0 {
+			t.Fatalf("%v does not match the selector", obj)
+		}
+		counts[obj]++
+		if g := h.Get(key); g.(int)%3 == 0 && g != obj {
+			t.Fatalf("key %d should stay with %v under the selector", key, g)
+		}
+		if h.GetSelect(key, nil) != h.Get(key) {
+			t.Fatal("an empty selector should match every object")
+		}
+	}
+	if len(counts) != 4 {
+		t.Fatalf("the keys should spread over the matching objects. counts: %v", counts)
+	}
+
+	if h.GetSelect(1, map[string]string{"zone": "b"}) != nil {
+		t.Fatal("no object should match zone=b")
+	}
+
+	labels := map[string]string{"zone": "b"}
+	if !h.SetLabels(2, labels) || h.SetLabels(100, labels) {
+		t.Fatal("SetLabels should report whether the object is in the hash")
+	}
+	labels["zone"] = "c"
+	if h.GetSelect(1, map[string]string{"zone": "b"}) != 2 || h.Labels(2)["zone"] != "b" {
+		t.Fatal("the labels should be copied")
+	}
+}
@@ -0,0 +1,138 @@
+package doublejump
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// 延迟的指数加权平均值的平滑系数
+const ewmaAlpha = 0.2
+
+// 节点延迟的指数加权平均值，单位为纳秒，快照和哈希共享同一个值
+type ewma struct {
+	bits uint64
+}
+
+func (this *ewma) load() float64 {
+	if this == nil {
+		return 0
+	}
+	return math.Float64frombits(atomic.LoadUint64(&this.bits))
+}
+
+func (this *ewma) observe(d float64) {
+	for {
+		old := atomic.LoadUint64(&this.bits)
+		v := math.Float64frombits(old)
+		if old == 0 {
+			v = d
+		} else {
+			v += ewmaAlpha * (d - v)
+		}
+		if atomic.CompareAndSwapUint64(&this.bits, old, math.Float64bits(v)) {
+			return
+		}
+	}
+}
+
+// WithLatencyAware makes Get select, among the first k candidates of a key, the first one
+// which is not slower than slack times the fastest of them, according to the latencies
+// reported by ReportLatency. Keys thus stick to their consistent object as long as it is
+// about as fast as the others, and move away from markedly slow objects. Objects without
+// any report are assumed to be fast. Keys pinned by Pin stay on their pins. k < 2 disables
+// it; slack < 1 means 1.
+func WithLatencyAware(k int, slack float64) Option {
+	return func(h *Hash) {
+		if slack < 1 {
+			slack = 1
+		}
+		h.latencyK = k
+		h.slack = slack
+	}
+}
+
+// ReportLatency feeds back a latency observed on the object, which updates its
+// exponentially weighted moving average. It is cheap enough to be called on every request.
+// It returns false if the object is not in the hash.
+func (this *Hash) ReportLatency(obj interface{}, d time.Duration) bool {
+	if this == nil || obj == nil {
+		return false
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	// 平均值是原子更新的，读锁就够了
+	n, ok := this.nodes[this.id(obj)]
+	if !ok {
+		return false
+	}
+	n.latency.observe(float64(d))
+	return true
+}
+
+// Latency returns the moving average of the latencies reported for the object, 0 if none.
+func (this *Hash) Latency(obj interface{}) time.Duration {
+	if this == nil || obj == nil {
+		return 0
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	if n, ok := this.nodes[this.id(obj)]; ok {
+		return time.Duration(n.latency.load())
+	}
+	return 0
+}
+
+// 在前k个可用的候选节点中选择足够快的节点，被固定的KEY只做过滤，调用方负责加锁
+func (this *Hash) fastest(key uint64, id interface{}) interface{} {
+	if len(this.pins) > 0 {
+		if _, ok := this.pinned(key); ok {
+			if this.filtering() {
+				return this.avoid(key, id)
+			}
+			return id
+		}
+	}
+
+	var buf [8]interface{}
+	ids := buf[:0]
+	filtering := this.filtering()
+	c := this.candidates(key)
+	for len(ids) < this.latencyK {
+		next, ok := c.next()
+		if !ok {
+			break
+		}
+		if !filtering || !this.skip(key, next) {
+			ids = append(ids, next)
+		}
+	}
+	if len(ids) == 0 {
+		return id
+	}
+
+	min := math.Inf(1)
+	for _, id := range ids {
+		if v := this.nodes[id].latency.load(); v > 0 && v < min {
+			min = v
+		}
+	}
+	for _, id := range ids {
+		if v := this.nodes[id].latency.load(); v == 0 || v <= min*this.slack {
+			return id
+		}
+	}
+	return ids[0]
+}
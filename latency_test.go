@@ -0,0 +1,79 @@
+package doublejump
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHash_WithLatencyAware(t *testing.T) {
+	h := NewHash(WithLatencyAware(2, 1.5))
+	plain := NewHash()
+	for i := 0; i < 5; i++ {
+		h.Add(i)
+		plain.Add(i)
+	}
+
+	// 没有报告时与一致性哈希的结果相同
+	for key := uint64(0); key < 1000; key++ {
+		if h.Get(key) != plain.Get(key) {
+			t.Fatalf("h.Get(%d) != plain.Get(%d)", key, key)
+		}
+	}
+
+	for i := 0; i < 5; i++ {
+		h.ReportLatency(i, 10*time.Millisecond)
+	}
+	h.ReportLatency(1, 12*time.Millisecond)
+	if l := h.Latency(1); l <= 10*time.Millisecond || l >= 12*time.Millisecond {
+		t.Fatalf("unexpected moving average: %v", l)
+	}
+	for key := uint64(0); key < 1000; key++ {
+		if h.Get(key) != plain.Get(key) {
+			t.Fatal("a slightly slower node should keep its keys")
+		}
+	}
+
+	for i := 0; i < 20; i++ {
+		h.ReportLatency(1, 100*time.Millisecond)
+	}
+	moved := 0
+	for key := uint64(0); key < 1000; key++ {
+		obj := h.Get(key)
+		if obj == 1 {
+			t.Fatalf("the slow node should be avoided. key: %d", key)
+		}
+		if obj != plain.Get(key) {
+			moved++
+			if a := plain.GetN(key, 2); a[0] != 1 || a[1] != obj {
+				t.Fatalf("the keys should move to their second candidates. key: %d", key)
+			}
+		}
+	}
+	if moved == 0 {
+		t.Fatal("the keys of the slow node should move")
+	}
+
+	// 被固定的KEY不换
+	h.Pin(7, 1)
+	if h.Get(7) != 1 {
+		t.Fatal("a pinned key should stay on its pin")
+	}
+	h.Drain(1)
+	if h.Get(7) == 1 {
+		t.Fatal("a drained pin should still be avoided")
+	}
+	h.Undrain(1)
+	h.Unpin(7)
+
+	if h.ReportLatency(100, time.Second) || h.Latency(100) != 0 {
+		t.Fatal("ReportLatency should ignore unknown nodes")
+	}
+
+	// 报告之前取的快照也能看到之后的报告
+	h.Add(10)
+	s := h.Snapshot()
+	h.ReportLatency(10, time.Second)
+	if s.hash.Latency(10) != time.Second {
+		t.Fatalf("the snapshot should share the latency with the hash. latency: %v", s.hash.Latency(10))
+	}
+}
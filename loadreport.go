@@ -0,0 +1,49 @@
+package doublejump
+
+// LoadReporter reports the live load of objects, e.g. their number of in-flight requests or
+// their CPU usage. See WithLoadReporter.
+type LoadReporter interface {
+	// Load returns the current load of the object. It is called with the hash locked, so
+	// it must not call back into the hash.
+	Load(obj interface{}) float64
+}
+
+// WithLoadReporter makes Get compare the load of the selected object with the load of the
+// next candidate of the key, and select the next candidate instead when the selected object
+// is grossly overloaded, i.e. its load exceeds factor times the load of the next candidate.
+// Keys thus keep their consistent object in the normal case, and spill over to a stable
+// second choice under hot spots. Keys pinned by Pin stay on their pins. It costs two calls
+// of Load per Get. factor <= 1 means 2.
+func WithLoadReporter(r LoadReporter, factor float64) Option {
+	return func(h *Hash) {
+		if factor <= 1 {
+			factor = 2
+		}
+		h.reporter = r
+		h.factor = factor
+	}
+}
+
+// 选中的节点负载过高时换成下一个可用的候选节点，被固定的KEY不换，调用方负责加锁
+func (this *Hash) lighter(key uint64, id interface{}) interface{} {
+	if len(this.pins) > 0 {
+		if _, ok := this.pinned(key); ok {
+			return id
+		}
+	}
+	filtering := this.filtering()
+	c := this.candidates(key)
+	for {
+		next, ok := c.next()
+		if !ok {
+			return id
+		}
+		if next == id || filtering && this.skip(key, next) {
+			continue
+		}
+		if this.reporter.Load(this.value(id)) > this.factor*this.reporter.Load(this.value(next)) {
+			return next
+		}
+		return id
+	}
+}
@@ -0,0 +1,54 @@
+package doublejump
+
+import "testing"
+
+type loads map[interface{}]float64
+
+func (this loads) Load(obj interface{}) float64 {
+	return this[obj]
+}
+
+func TestHash_WithLoadReporter(t *testing.T) {
+	l := loads{}
+	h := NewHash(WithLoadReporter(l, 0))
+	for i := 0; i < 5; i++ {
+		h.Add(i)
+		l[i] = 10
+	}
+
+	for key := uint64(0); key < 1000; key++ {
+		if h.Get(key) != h.GetN(key, 1)[0] {
+			t.Fatal("the keys should stay on their nodes under even load")
+		}
+	}
+
+	l[2] = 25
+	for key := uint64(0); key < 1000; key++ {
+		a := h.GetN(key, 2)
+		if obj := h.Get(key); a[0] == 2 && obj != a[1] || a[0] != 2 && obj != a[0] {
+			t.Fatalf("the keys of the overloaded node should spill over to their second candidates. key: %d", key)
+		}
+	}
+
+	// 被固定的KEY不换
+	h.Pin(7, 2)
+	if h.Get(7) != 2 {
+		t.Fatal("a pinned key should stay on its pin")
+	}
+	h.Unpin(7)
+
+	// 下一个候选节点同样过载时不换
+	l[3] = 20
+	for key := uint64(0); key < 1000; key++ {
+		if a := h.GetN(key, 2); a[0] == 2 && a[1] == 3 && h.Get(key) != 2 {
+			t.Fatalf("the key should stay if the next node is loaded too. key: %d", key)
+		}
+	}
+
+	h.Drain(4)
+	for key := uint64(0); key < 1000; key++ {
+		if h.Get(key) == 4 {
+			t.Fatal("the drained node should not be selected")
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package doublejump
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 记录锁的等待时间和写锁的持有时间，见WithLockProfile
+type lockProfile struct {
+	waits    uint64
+	waitTime int64
+	writes   uint64
+	holdTime int64
+	lockedAt time.Time // 获得写锁的时间，由写锁保护
+}
+
+// 可以统计等待时间的读写锁，未开启统计时和sync.RWMutex相同
+type rwMutex struct {
+	sync.RWMutex
+	prof *lockProfile
+}
+
+// WithLockProfile makes a threadsafe hash measure how long its callers wait for the lock
+// and how long writers hold it. The numbers are reported by Stats, so that one can tell
+// whether the lock is really a bottleneck before switching to NewHashWithoutLock.
+// Each lock acquisition costs two extra clock reads.
+func WithLockProfile() Option {
+	return func(h *Hash) {
+		h.mu.prof = &lockProfile{}
+	}
+}
+
+func (this *rwMutex) Lock() {
+	if this.prof == nil {
+		this.RWMutex.Lock()
+		return
+	}
+
+	start := now()
+	this.RWMutex.Lock()
+	this.prof.lockedAt = now()
+	this.prof.waited(this.prof.lockedAt.Sub(start))
+}
+
+func (this *rwMutex) TryLock() bool {
+	if !this.RWMutex.TryLock() {
+		return false
+	}
+	if this.prof != nil {
+		this.prof.lockedAt = now()
+		this.prof.waited(0)
+	}
+	return true
+}
+
+func (this *rwMutex) Unlock() {
+	if this.prof != nil {
+		atomic.AddUint64(&this.prof.writes, 1)
+		atomic.AddInt64(&this.prof.holdTime, int64(now().Sub(this.prof.lockedAt)))
+	}
+	this.RWMutex.Unlock()
+}
+
+func (this *rwMutex) RLock() {
+	if this.prof == nil {
+		this.RWMutex.RLock()
+		return
+	}
+
+	start := now()
+	this.RWMutex.RLock()
+	this.prof.waited(now().Sub(start))
+}
+
+func (this *rwMutex) TryRLock() bool {
+	if !this.RWMutex.TryRLock() {
+		return false
+	}
+	if this.prof != nil {
+		this.prof.waited(0)
+	}
+	return true
+}
+
+func (this *lockProfile) waited(d time.Duration) {
+	atomic.AddUint64(&this.waits, 1)
+	atomic.AddInt64(&this.waitTime, int64(d))
+}
+
+// 读取统计数据，可以不加锁调用
+func (this *lockProfile) fill(s *Stats) {
+	s.LockAcquisitions = atomic.LoadUint64(&this.waits)
+	s.LockWaitTime = time.Duration(atomic.LoadInt64(&this.waitTime))
+	s.WriteLocks = atomic.LoadUint64(&this.writes)
+	s.WriteHoldTime = time.Duration(atomic.LoadInt64(&this.holdTime))
+}
@@ -0,0 +1,52 @@
+package doublejump
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHash_LockProfile(t *testing.T) {
+	// 每次读取时间前进1ms
+	cur := time.Unix(0, 0)
+	now = func() time.Time {
+		cur = cur.Add(time.Millisecond)
+		return cur
+	}
+	defer func() { now = time.Now }()
+
+	h := NewHash(WithLockProfile())
+	h.Add(1)
+	s := h.Stats() // Stats本身也会获取一次读锁
+	if s.LockAcquisitions != 2 {
+		t.Fatalf("s.LockAcquisitions != 2. n: %d", s.LockAcquisitions)
+	}
+	if s.LockWaitTime != 2*time.Millisecond {
+		t.Fatalf("s.LockWaitTime != 2ms. d: %v", s.LockWaitTime)
+	}
+	if s.WriteLocks != 1 {
+		t.Fatalf("s.WriteLocks != 1. n: %d", s.WriteLocks)
+	}
+	if s.WriteHoldTime != time.Millisecond {
+		t.Fatalf("s.WriteHoldTime != 1ms. d: %v", s.WriteHoldTime)
+	}
+
+	if added, ok := h.TryAdd(2); !added || !ok {
+		t.Fatal("h.TryAdd(2) should succeed")
+	}
+	if s = h.Stats(); s.WriteLocks != 2 || s.WriteHoldTime != 2*time.Millisecond {
+		t.Fatalf("unexpected write lock profile. n: %d, d: %v", s.WriteLocks, s.WriteHoldTime)
+	}
+	// 获取成功的TryRLock和TryLock也计入次数，不需要等待
+	if _, ok := h.TryGet(1); !ok {
+		t.Fatal("h.TryGet(1) should succeed")
+	}
+	if s = h.Stats(); s.LockAcquisitions != 6 || s.LockWaitTime != 4*time.Millisecond {
+		t.Fatalf("unexpected lock profile. n: %d, d: %v", s.LockAcquisitions, s.LockWaitTime)
+	}
+
+	h2 := NewHash()
+	h2.Add(1)
+	if s := h2.Stats(); s.LockAcquisitions != 0 || s.WriteLocks != 0 {
+		t.Fatal("the lock should not be profiled without WithLockProfile")
+	}
+}
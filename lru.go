@@ -0,0 +1,66 @@
+package doublejump
+
+import "container/list"
+
+// 以KEY为索引的定长LRU缓存，非线程安全，由调用方负责加锁
+type lru struct {
+	size int
+	ll   *list.List
+	m    map[uint64]*list.Element
+}
+
+type lruEntry struct {
+	key   uint64
+	value interface{}
+}
+
+func newLRU(size int) *lru {
+	if size <= 0 {
+		size = 1
+	}
+	return &lru{
+		size: size,
+		ll:   list.New(),
+		m:    make(map[uint64]*list.Element),
+	}
+}
+
+func (this *lru) get(key uint64) (interface{}, bool) {
+	if e, ok := this.m[key]; ok {
+		this.ll.MoveToFront(e)
+		return e.Value.(*lruEntry).value, true
+	}
+	return nil, false
+}
+
+// 超出容量时淘汰最久未使用的条目
+func (this *lru) add(key uint64, value interface{}) {
+	if e, ok := this.m[key]; ok {
+		this.ll.MoveToFront(e)
+		e.Value.(*lruEntry).value = value
+		return
+	}
+
+	this.m[key] = this.ll.PushFront(&lruEntry{key: key, value: value})
+	if this.ll.Len() > this.size {
+		e := this.ll.Back()
+		this.ll.Remove(e)
+		delete(this.m, e.Value.(*lruEntry).key)
+	}
+}
+
+func (this *lru) remove(key uint64) {
+	if e, ok := this.m[key]; ok {
+		this.ll.Remove(e)
+		delete(this.m, key)
+	}
+}
+
+func (this *lru) len() int {
+	return this.ll.Len()
+}
+
+func (this *lru) clear() {
+	this.ll.Init()
+	this.m = make(map[uint64]*list.Element)
+}
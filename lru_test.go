@@ -0,0 +1,35 @@
+package doublejump
+
+import "testing"
+
+func TestLRU(t *testing.T) {
+	c := newLRU(2)
+	c.add(1, "a")
+	c.add(2, "b")
+	if v, ok := c.get(1); !ok || v.(string) != "a" {
+		t.Fatalf("c.get(1) is wrong. v: %v, ok: %v", v, ok)
+	}
+
+	c.add(3, "c")
+	if _, ok := c.get(2); ok {
+		t.Fatal("2 should have been evicted")
+	}
+	if c.len() != 2 {
+		t.Fatalf("c.len() != 2. len: %d", c.len())
+	}
+
+	c.add(1, "aa")
+	if v, _ := c.get(1); v.(string) != "aa" {
+		t.Fatalf("c.get(1) should be updated. v: %v", v)
+	}
+
+	c.remove(1)
+	if _, ok := c.get(1); ok {
+		t.Fatal("1 should have been removed")
+	}
+
+	c.clear()
+	if _, ok := c.get(3); ok || c.len() != 0 {
+		t.Fatal("c.clear() should remove all entries")
+	}
+}
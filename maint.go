@@ -0,0 +1,162 @@
+package doublejump
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// ErrBadInterval is returned by Run and Maintain when a periodic task has an interval <= 0.
+var ErrBadInterval = errors.New("doublejump: interval must be positive")
+
+// MaintOption adds a background task to Run.
+type MaintOption func(m *maint)
+
+// 待执行的任务，run返回距离下次执行的时间，返回0表示任务结束
+type maintTask struct {
+	next time.Time
+	run  func(ctx context.Context) time.Duration
+}
+
+type maint struct {
+	h     *Hash
+	tasks []*maintTask
+	err   error // 第一个无效的任务，Run不执行任何任务就返回它
+}
+
+func (this *maint) add(at time.Time, run func(ctx context.Context) time.Duration) {
+	this.tasks = append(this.tasks, &maintTask{next: at, run: run})
+}
+
+// 周期任务的间隔必须为正，否则run返回0时任务就结束了
+func (this *maint) every(interval time.Duration) bool {
+	if interval <= 0 {
+		if this.err == nil {
+			this.err = ErrBadInterval
+		}
+		return false
+	}
+	return true
+}
+
+// MaintShrink shrinks the hash every interval when the share of empty slots in the loose
+// holder is above threshold.
+func MaintShrink(interval time.Duration, threshold float64) MaintOption {
+	return func(m *maint) {
+		if !m.every(interval) {
+			return
+		}
+		h := m.h
+		m.add(time.Now().Add(interval), func(ctx context.Context) time.Duration {
+			s := h.Stats()
+			if s.LooseLen > 0 && float64(s.EmptySlots)/float64(s.LooseLen) > threshold {
+				h.Shrink()
+			}
+			return interval
+		})
+	}
+}
+
+// MaintExpire checks every object every interval and removes the ones for which expired
+// returns true, e.g. the ones whose lease has not been renewed within their TTL.
+func MaintExpire(interval time.Duration, expired func(obj interface{}) bool) MaintOption {
+	return func(m *maint) {
+		if !m.every(interval) {
+			return
+		}
+		h := m.h
+		m.add(time.Now().Add(interval), func(ctx context.Context) time.Duration {
+			for _, obj := range h.Nodes() {
+				if expired(obj) {
+					h.Remove(obj)
+				}
+			}
+			return interval
+		})
+	}
+}
+
+// MaintRemoveAt removes obj at t. Unlike RemoveAfter, the removal does not happen if Run
+// returns before t.
+func MaintRemoveAt(obj interface{}, t time.Time) MaintOption {
+	return func(m *maint) {
+		h := m.h
+		m.add(t, func(ctx context.Context) time.Duration {
+			h.Remove(obj)
+			return 0
+		})
+	}
+}
+
+// MaintRefresher reconciles the hash to the membership fetched from r every interval, with
+// the same jitter and backoff as Maintain.
+func MaintRefresher(r Refresher, interval time.Duration) MaintOption {
+	return func(m *maint) {
+		if !m.every(interval) {
+			return
+		}
+		h := m.h
+		backoff := 1
+		m.add(time.Now(), func(ctx context.Context) time.Duration {
+			nodes, err := r.Fetch(ctx)
+			if err == nil && len(nodes) > 0 {
+				h.SetNodes(nodes)
+				backoff = 1
+			} else if ctx.Err() == nil && backoff < maxBackoff {
+				backoff *= 2
+			}
+
+			d := time.Duration(backoff) * interval
+			return d + time.Duration((rand.Float64()*0.2-0.1)*float64(d))
+		})
+	}
+}
+
+// Run runs the background maintenance of the hash given by opts on the calling goroutine,
+// one task at a time, until ctx is done or no task is left, then it returns ctx.Err() or
+// nil. Nothing keeps running after Run returns, so a single cancel stops all of them. It
+// returns ErrBadInterval at once if a periodic task has an interval <= 0.
+func (this *Hash) Run(ctx context.Context, opts ...MaintOption) error {
+	if this == nil {
+		return ErrNilHash
+	}
+
+	m := &maint{h: this}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.err != nil {
+		return m.err
+	}
+
+	for len(m.tasks) > 0 {
+		// 取出最早到期的任务
+		next := 0
+		for i, t := range m.tasks {
+			if t.next.Before(m.tasks[next].next) {
+				next = i
+			}
+		}
+		task := m.tasks[next]
+
+		if d := time.Until(task.next); d > 0 {
+			t := time.NewTimer(d)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return ctx.Err()
+			case <-t.C:
+			}
+		} else if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if d := task.run(ctx); d > 0 {
+			task.next = time.Now().Add(d)
+		} else {
+			m.tasks = append(m.tasks[:next], m.tasks[next+1:]...)
+		}
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,87 @@
+package doublejump
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHash_Run(t *testing.T) {
+	h := NewHash()
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+	for i := 0; i < 6; i++ {
+		h.Remove(i)
+	}
+
+	var fetches int32
+	r := RefresherFunc(func(ctx context.Context) ([]Node, error) {
+		atomic.AddInt32(&fetches, 1)
+		return nil, nil
+	})
+	var expired int32
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := h.Run(ctx,
+		MaintShrink(5*time.Millisecond, 0.4),
+		MaintExpire(5*time.Millisecond, func(obj interface{}) bool {
+			if obj == 9 {
+				atomic.AddInt32(&expired, 1)
+				return true
+			}
+			return false
+		}),
+		MaintRemoveAt(8, time.Now().Add(20*time.Millisecond)),
+		MaintRemoveAt(7, time.Now().Add(time.Hour)),
+		MaintRefresher(r, 10*time.Millisecond),
+	)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("err != context.DeadlineExceeded. err: %v", err)
+	}
+
+	if h.Len() != 2 || !h.Contains(6) || !h.Contains(7) {
+		t.Fatalf("unexpected nodes: %v", h.Nodes())
+	}
+	if h.LooseLen() != 2 {
+		t.Fatalf("the hash should be shrunk. h.LooseLen(): %d", h.LooseLen())
+	}
+	if atomic.LoadInt32(&expired) != 1 || atomic.LoadInt32(&fetches) < 2 {
+		t.Fatalf("expired: %d, fetches: %d", expired, fetches)
+	}
+}
+
+func TestHash_Run_NoTask(t *testing.T) {
+	h := NewHash()
+	if err := h.Run(context.Background(), MaintRemoveAt(1, time.Now())); err != nil {
+		t.Fatal(err)
+	}
+	if err := (*Hash)(nil).Run(context.Background()); err != ErrNilHash {
+		t.Fatalf("err != ErrNilHash. err: %v", err)
+	}
+}
+
+func TestHash_Run_BadInterval(t *testing.T) {
+	h := NewHash()
+	h.Add(1)
+	r := RefresherFunc(func(ctx context.Context) ([]Node, error) {
+		t.Fatal("no task should run")
+		return nil, nil
+	})
+	for _, opt := range []MaintOption{
+		MaintRefresher(r, 0),
+		MaintShrink(-time.Second, 0.5),
+		MaintExpire(0, func(interface{}) bool { return true }),
+	} {
+		if err := h.Run(context.Background(), opt); err != ErrBadInterval {
+			t.Fatalf("err != ErrBadInterval. err: %v", err)
+		}
+	}
+	if err := h.Maintain(context.Background(), r, 0); err != ErrBadInterval {
+		t.Fatalf("err != ErrBadInterval. err: %v", err)
+	}
+	if h.Len() != 1 {
+		t.Fatal("h.Len() != 1")
+	}
+}
@@ -0,0 +1,165 @@
+package doublejump
+
+import (
+	"sort"
+	"sync"
+)
+
+// Manager owns many named hashes, which are created lazily on first use. It is threadsafe.
+type Manager struct {
+	opts []Option
+
+	mu      sync.Mutex
+	rings   map[string]*managedRing
+	tenants map[string]*usage // 见SetQuota
+
+	subMu sync.RWMutex
+	subs  []*managerListener
+}
+
+type managedRing struct {
+	hash   *Hash
+	cancel func()
+	tenant *usage // 由TenantRing创建时不为空
+}
+
+type managerListener struct {
+	f func(name string, ev Event)
+}
+
+// NewManager creates a manager. The options are applied to every hash it creates.
+func NewManager(opts ...Option) *Manager {
+	return &Manager{
+		opts:    opts,
+		rings:   make(map[string]*managedRing),
+		tenants: make(map[string]*usage),
+	}
+}
+
+// Ring returns the threadsafe hash with the given name, creating it if it does not exist.
+// A name of the form tenant + "/" + name, where the tenant has a quota or a hash, creates the
+// hash on behalf of the tenant as TenantRing does, so its objects count against the quota.
+// If the tenant already has MaxRings hashes, Ring returns nil; use TenantRing to get
+// ErrQuotaExceeded instead.
+func (this *Manager) Ring(name string) *Hash {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	if r, ok := this.rings[name]; ok {
+		return r.hash
+	}
+	u := this.tenantOf(name)
+	if u != nil {
+		if u.quota.MaxRings > 0 && u.rings >= u.quota.MaxRings {
+			return nil
+		}
+		u.rings++
+	}
+	return this.create(name, u).hash
+}
+
+// 调用方持有mu
+func (this *Manager) create(name string, tenant *usage) *managedRing {
+	opts := this.opts
+	if tenant != nil {
+		opts = append(opts[:len(opts):len(opts)], func(h *Hash) { h.usage = tenant })
+	}
+
+	h := NewHash(opts...)
+	cancel := h.Subscribe(func(ev Event) {
+		this.dispatch(name, ev)
+	})
+	r := &managedRing{hash: h, cancel: cancel, tenant: tenant}
+	this.rings[name] = r
+	return r
+}
+
+// Lookup returns the hash with the given name without creating it.
+func (this *Manager) Lookup(name string) (*Hash, bool) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	if r, ok := this.rings[name]; ok {
+		return r.hash, true
+	}
+	return nil, false
+}
+
+// Delete removes the hash with the given name from the manager. It reports whether the hash existed.
+func (this *Manager) Delete(name string) bool {
+	this.mu.Lock()
+	r, ok := this.rings[name]
+	delete(this.rings, name)
+	if ok && r.tenant != nil {
+		r.tenant.rings--
+	}
+	this.mu.Unlock()
+
+	if ok {
+		r.cancel()
+		if r.tenant != nil {
+			r.hash.detach()
+		}
+	}
+	return ok
+}
+
+// Names returns the names of all hashes in sorted order.
+func (this *Manager) Names() []string {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	names := make([]string, 0, len(this.rings))
+	for name := range this.rings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Stats returns the stats of all hashes by their names.
+func (this *Manager) Stats() map[string]Stats {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	m := make(map[string]Stats, len(this.rings))
+	for name, r := range this.rings {
+		m[name] = r.hash.Stats()
+	}
+	return m
+}
+
+// Subscribe registers f to be called on every change of every hash in the manager, and
+// returns a function to cancel the subscription. f is called with the changed hash locked,
+// so it must not call back into that hash.
+func (this *Manager) Subscribe(f func(name string, ev Event)) (cancel func()) {
+	if f == nil {
+		return func() {}
+	}
+
+	this.subMu.Lock()
+	defer this.subMu.Unlock()
+
+	l := &managerListener{f: f}
+	this.subs = append(this.subs, l)
+	return func() {
+		this.subMu.Lock()
+		defer this.subMu.Unlock()
+
+		for i, v := range this.subs {
+			if v == l {
+				this.subs = append(this.subs[:i:i], this.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (this *Manager) dispatch(name string, ev Event) {
+	this.subMu.RLock()
+	defer this.subMu.RUnlock()
+
+	for _, l := range this.subs {
+		l.f(name, ev)
+	}
+}
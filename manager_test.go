@@ -0,0 +1,50 @@
+package doublejump
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestManager(t *testing.T) {
+	m := NewManager()
+	if _, ok := m.Lookup("a"); ok {
+		t.Fatal("m.Lookup should not create a ring")
+	}
+
+	a := m.Ring("a")
+	if m.Ring("a") != a {
+		t.Fatal("m.Ring should return the same ring for the same name")
+	}
+	m.Ring("b").Add(1)
+	a.Add(1)
+	a.Add(2)
+
+	if fmt.Sprint(m.Names()) != "[a b]" {
+		t.Fatalf("m.Names() is wrong. names: %v", m.Names())
+	}
+	stats := m.Stats()
+	if stats["a"].Len != 2 || stats["b"].Len != 1 {
+		t.Fatalf("m.Stats() is wrong. stats: %v", stats)
+	}
+
+	var names []string
+	cancel := m.Subscribe(func(name string, ev Event) {
+		names = append(names, fmt.Sprintf("%s:%v:%v", name, ev.Type, ev.Node))
+	})
+	a.Remove(1)
+	m.Ring("b").Add(2)
+	m.Ring("c").Add(3)
+	if fmt.Sprint(names) != "[a:remove:1 b:add:2 c:add:3]" {
+		t.Fatalf("the events are wrong. names: %v", names)
+	}
+
+	if !m.Delete("a") || m.Delete("a") {
+		t.Fatal("something is wrong with Delete")
+	}
+	a.Add(5)
+	cancel()
+	m.Ring("b").Add(6)
+	if len(names) != 3 {
+		t.Fatalf("no event should be received from deleted rings or after cancel. names: %v", names)
+	}
+}
@@ -0,0 +1,209 @@
+package doublejump
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+var (
+	// ErrBadMapped is returned when the data of a mapped ring is malformed.
+	ErrBadMapped = errors.New("doublejump: malformed mapped ring")
+	// ErrNotMappable is returned when the placement of the hash depends on WithMixer,
+	// WithJumpFunc or WithTimeBucket, which cannot be written along with the ring.
+	ErrNotMappable = errors.New("doublejump: custom mixer, jump function or time window cannot be mapped")
+)
+
+// 布局: 4字节魔数，松散位置、紧凑位置与节点的数量各4字节，然后依次是
+// 松散位置(节点序号+1，0表示空位置)、紧凑位置(节点序号)、节点名的偏移(节点数量+1个)
+// 和所有节点名，整数都是小端序的uint32
+const (
+	mappedMagic  = "DJM1"
+	mappedHeader = 16
+)
+
+// WriteMapped writes the ring in the flat layout read by Mapped, to a file which
+// OpenMapped maps into memory. Like State, the objects must be strings, otherwise it returns
+// ErrNotString.
+func (this *Hash) WriteMapped(w io.Writer) error {
+	if this == nil {
+		return ErrNilHash
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	if this.compact.mix != nil || this.compact.jump != nil || this.loose.jump != nil || this.window > 0 {
+		return ErrNotMappable
+	}
+	s, err := this.state()
+	if err != nil {
+		return err
+	}
+
+	size := mappedHeader + 4*(len(s.Loose)+len(s.Compact)+len(s.Nodes)+1)
+	for _, n := range s.Nodes {
+		size += len(n.ID)
+	}
+	b := make([]byte, 0, size)
+	b = append(b, mappedMagic...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(s.Loose)))
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(s.Compact)))
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(s.Nodes)))
+	for _, slot := range s.Loose {
+		b = binary.LittleEndian.AppendUint32(b, uint32(slot.Node+1))
+	}
+	for _, slot := range s.Compact {
+		b = binary.LittleEndian.AppendUint32(b, uint32(slot.Node))
+	}
+	off := 0
+	for _, n := range s.Nodes {
+		b = binary.LittleEndian.AppendUint32(b, uint32(off))
+		off += len(n.ID)
+	}
+	b = binary.LittleEndian.AppendUint32(b, uint32(off))
+	for _, n := range s.Nodes {
+		b = append(b, n.ID...)
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+// Mapped is a read-only ring over the flat layout written by WriteMapped. It reads the data
+// in place, without building any Go map, so sidecar processes which only query a big ring
+// can share one mapped file with minimal memory. It selects the same objects as Get of the
+// hash it was written from, without the filters, pins and the like which only exist in a
+// live hash. It is threadsafe.
+type Mapped struct {
+	data             []byte
+	nl, nc, nn       int
+	compact, offsets int // 各部分在data中的起始位置
+	unmap            func() error
+}
+
+// LoadMapped creates a read-only ring over data, which must not be modified while the ring
+// is in use. It returns ErrBadMapped if the data is malformed.
+func LoadMapped(data []byte) (*Mapped, error) {
+	if len(data) < mappedHeader || string(data[:4]) != mappedMagic {
+		return nil, ErrBadMapped
+	}
+
+	this := &Mapped{
+		data: data,
+		nl:   int(le32(data, 4)),
+		nc:   int(le32(data, 8)),
+		nn:   int(le32(data, 12)),
+	}
+	this.compact = mappedHeader + 4*this.nl
+	this.offsets = this.compact + 4*this.nc
+	blob := this.offsets + 4*(this.nn+1)
+	if this.nl < 0 || this.nc < 0 || this.nn < 0 || blob > len(data) {
+		return nil, ErrBadMapped
+	}
+
+	// 预先校验，查找时不必再检查越界
+	for i := 0; i < this.nl; i++ {
+		if int(le32(data, mappedHeader+4*i)) > this.nn {
+			return nil, ErrBadMapped
+		}
+	}
+	for i := 0; i < this.nc; i++ {
+		if int(le32(data, this.compact+4*i)) >= this.nn {
+			return nil, ErrBadMapped
+		}
+	}
+	prev := 0
+	for i := 0; i <= this.nn; i++ {
+		off := int(le32(data, this.offsets+4*i))
+		if off < prev || blob+off > len(data) {
+			return nil, ErrBadMapped
+		}
+		prev = off
+	}
+	// 删除所有节点而没有Shrink时，松散位置都是空位置，紧凑位置为空
+	if this.nl == 0 && this.nc > 0 {
+		return nil, ErrBadMapped
+	}
+	if this.nc == 0 {
+		for i := 0; i < this.nl; i++ {
+			if le32(data, mappedHeader+4*i) != 0 {
+				return nil, ErrBadMapped
+			}
+		}
+	}
+	return this, nil
+}
+
+// OpenMapped maps the file written by WriteMapped into memory read-only, see LoadMapped.
+// Close releases the mapping.
+func OpenMapped(path string) (*Mapped, error) {
+	data, unmap, err := mmapFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	this, err := LoadMapped(data)
+	if err != nil {
+		unmap()
+		return nil, err
+	}
+	this.unmap = unmap
+	return this, nil
+}
+
+// Close releases the mapping of OpenMapped. The ring must not be used afterwards.
+func (this *Mapped) Close() error {
+	if this == nil || this.unmap == nil {
+		return nil
+	}
+	unmap := this.unmap
+	this.unmap = nil
+	this.data, this.nl, this.nc, this.nn = nil, 0, 0, 0
+	return unmap()
+}
+
+// Len returns the number of objects in the ring.
+func (this *Mapped) Len() int {
+	if this == nil {
+		return 0
+	}
+	return this.nn
+}
+
+// Get returns the object according to the key provided, which is a string, or nil if the
+// ring is empty.
+func (this *Mapped) Get(key uint64) interface{} {
+	if s, ok := this.GetString(key); ok {
+		return s
+	}
+	return nil
+}
+
+// GetString is like Get, but returns the object as a string. ok is false if the ring is empty.
+func (this *Mapped) GetString(key uint64) (s string, ok bool) {
+	if this == nil || this.nc == 0 {
+		return "", false
+	}
+
+	i := int(le32(this.data, mappedHeader+4*int(JumpHash(key, this.nl))))
+	if i == 0 {
+		// 与compactHolder相同的变换
+		key *= 0xc6a4a7935bd1e995
+		i = int(le32(this.data, this.compact+4*int(JumpHash(key, this.nc))))
+	} else {
+		i--
+	}
+	return this.node(i), true
+}
+
+func (this *Mapped) node(i int) string {
+	blob := this.offsets + 4*(this.nn+1)
+	from := int(le32(this.data, this.offsets+4*i))
+	to := int(le32(this.data, this.offsets+4*i+4))
+	return string(this.data[blob+from : blob+to])
+}
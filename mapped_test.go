@@ -0,0 +1,109 @@
+package doublejump
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHash_WriteMapped(t *testing.T) {
+	h := NewHash()
+	for i := 0; i < 20; i++ {
+		h.Add(fmt.Sprintf("node%d", i))
+	}
+	h.AddWeighted("heavy", 3)
+	for i := 0; i < 20; i += 3 {
+		h.Remove(fmt.Sprintf("node%d", i))
+	}
+
+	path := filepath.Join(t.TempDir(), "ring")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.WriteMapped(f); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	m, err := OpenMapped(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if m.Len() != h.Len() {
+		t.Fatal("m.Len() != h.Len()")
+	}
+	for key := uint64(0); key < 10000; key++ {
+		if m.Get(key) != h.Get(key) {
+			t.Fatalf("m.Get(%d) != h.Get(%d)", key, key)
+		}
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	NewHash().WriteMapped(&buf)
+	m, err = LoadMapped(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Get(1) != nil {
+		t.Fatal("m.Get(1) != nil")
+	}
+
+	// 删除所有节点而没有Shrink
+	h2 := NewHash()
+	h2.Add("a")
+	h2.Add("b")
+	h2.Remove("a")
+	h2.Remove("b")
+	buf.Reset()
+	if err := h2.WriteMapped(&buf); err != nil {
+		t.Fatal(err)
+	}
+	m, err = LoadMapped(buf.Bytes())
+	if err != nil {
+		t.Fatalf("an emptied ring which was never shrunk should load. err: %v", err)
+	}
+	if m.Len() != 0 || m.Get(1) != nil {
+		t.Fatal("the emptied ring should select nothing")
+	}
+}
+
+func TestLoadMapped_Malformed(t *testing.T) {
+	h := NewHash()
+	h.Add("a")
+	h.Add("b")
+	var buf bytes.Buffer
+	h.WriteMapped(&buf)
+	data := buf.Bytes()
+
+	for _, b := range [][]byte{nil, []byte("DJM0"), data[:len(data)-1], append(append([]byte(nil), data[:16]...), 9, 0, 0, 0)} {
+		if _, err := LoadMapped(b); err != ErrBadMapped {
+			t.Fatalf("LoadMapped should return ErrBadMapped. err: %v", err)
+		}
+	}
+
+	// 没有紧凑位置时松散位置必须都是空的
+	b := []byte("DJM1\x01\x00\x00\x00\x00\x00\x00\x00\x01\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\x00\x01\x00\x00\x00a")
+	if _, err := LoadMapped(b); err != ErrBadMapped {
+		t.Fatalf("LoadMapped should reject loose slots without compact slots. err: %v", err)
+	}
+
+	h2 := NewHash()
+	h2.Add(1)
+	if err := h2.WriteMapped(&buf); err != ErrNotString {
+		t.Fatalf("WriteMapped should return ErrNotString. err: %v", err)
+	}
+	if err := NewHash(WithMixer(SplitMix64)).WriteMapped(&buf); err != ErrNotMappable {
+		t.Fatalf("WriteMapped should return ErrNotMappable. err: %v", err)
+	}
+	if err := NewHash(WithTimeBucket(time.Minute)).WriteMapped(&buf); err != ErrNotMappable {
+		t.Fatalf("WriteMapped should return ErrNotMappable with a time window. err: %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package doublejump
+
+// Merge adds all objects of other which are not in the hash yet, with their weights and
+// metadata, in the slot order of other, e.g. to combine per-zone rings into a global ring
+// during a failover. The objects of other are copied first, then added under one lock, so
+// concurrent lookups see either none or all of them. It returns the number of objects added.
+func (this *Hash) Merge(other *Hash) int {
+	if this == nil || other == nil {
+		return 0
+	}
+
+	// 先复制other的节点再加锁，避免两个哈希相互合并时死锁
+	nodes := other.nodeList()
+
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	added := 0
+	for _, n := range nodes {
+		if this.addNode(n) {
+			added++
+		}
+	}
+	return added
+}
+
+// 按位置顺序返回全部节点的信息
+func (this *Hash) nodeList() []Node {
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	a := make([]Node, 0, len(this.nodes))
+	for _, slot := range this.loose.a {
+		if isPrimary(slot) {
+			n := this.nodes[slot]
+			a = append(a, Node{Value: n.obj, Weight: n.weight, Meta: n.meta})
+		}
+	}
+	return a
+}
@@ -0,0 +1,34 @@
+package doublejump
+
+import "testing"
+
+func TestHash_Merge(t *testing.T) {
+	a := NewHash()
+	a.Add("a1")
+	a.Add("shared")
+	b := NewHash()
+	b.AddNode(Node{Value: "b1", Weight: 3, Meta: "zone-b"})
+	b.Add("shared")
+	b.Add("b2")
+	b.Remove("b2")
+
+	var events []Event
+	a.Subscribe(func(ev Event) { events = append(events, ev) })
+	if n := a.Merge(b); n != 1 {
+		t.Fatalf("only b1 should be added. n: %d", n)
+	}
+	if a.Len() != 3 || a.Weight("b1") != 3 || a.Meta("b1") != "zone-b" {
+		t.Fatal("the weights and the metadata should be preserved")
+	}
+	if len(events) != 1 || events[0].Node != "b1" {
+		t.Fatalf("unexpected events: %v", events)
+	}
+
+	if a.Merge(a) != 0 || a.Merge(nil) != 0 {
+		t.Fatal("merging itself should add nothing")
+	}
+	b.Merge(a)
+	if b.Len() != 3 || !b.Contains("a1") {
+		t.Fatal("b should contain all nodes")
+	}
+}
@@ -0,0 +1,44 @@
+package doublejump
+
+// The key mixers for WithMixer. The compact holder must select objects independently of the
+// loose holder, otherwise keys falling into empty slots would crowd onto a few objects.
+
+// DefaultMixer multiplies the key by the MurmurHash2 constant. It is the mixer used by the
+// compact holder when no WithMixer option is given.
+func DefaultMixer(key uint64) uint64 {
+	return key * 0xc6a4a7935bd1e995
+}
+
+// SplitMix64 is the finalizer of the SplitMix64 generator.
+func SplitMix64(key uint64) uint64 {
+	key += 0x9e3779b97f4a7c15
+	key = (key ^ (key >> 30)) * 0xbf58476d1ce4e5b9
+	key = (key ^ (key >> 27)) * 0x94d049bb133111eb
+	return key ^ (key >> 31)
+}
+
+// Murmur3Mixer is the 64-bit finalizer (fmix64) of MurmurHash3.
+func Murmur3Mixer(key uint64) uint64 {
+	key ^= key >> 33
+	key *= 0xff51afd7ed558ccd
+	key ^= key >> 33
+	key *= 0xc4ceb9fe1a85ec53
+	return key ^ (key >> 33)
+}
+
+// XXHashMixer is the avalanche step of XXH64.
+func XXHashMixer(key uint64) uint64 {
+	key ^= key >> 33
+	key *= 0xc2b2ae3d27d4eb4f
+	key ^= key >> 29
+	key *= 0x165667b19e3779f9
+	return key ^ (key >> 32)
+}
+
+// Mixer returns the key mixer used by the compact holder.
+func (this *Hash) Mixer() func(key uint64) uint64 {
+	if this == nil || this.compact.mix == nil {
+		return DefaultMixer
+	}
+	return this.compact.mix
+}
@@ -0,0 +1,59 @@
+package doublejump
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHash_WithMixer(t *testing.T) {
+	for _, mix := range []func(uint64) uint64{DefaultMixer, SplitMix64, Murmur3Mixer, XXHashMixer} {
+		h := NewHash(WithMixer(mix))
+		for i := 0; i < 100; i++ {
+			h.Add(i)
+		}
+		for i := 0; i < 100; i += 2 {
+			h.Remove(i)
+		}
+		always(h, t)
+
+		for key := uint64(0); key < 1000; key++ {
+			obj := h.Get(key)
+			if obj == nil || obj.(int)%2 == 0 {
+				t.Fatalf("h.Get should return an existing node. obj: %v", obj)
+			}
+			if h.loose.get(key) == nil && h.compact.get(key) != h.compact.a[JumpHash(mix(key), len(h.compact.a))] {
+				t.Fatal("the compact holder should use the mixer")
+			}
+		}
+		if h.Snapshot().hash.compact.mix == nil {
+			t.Fatal("the snapshot should keep the mixer")
+		}
+	}
+
+	if NewHash().Mixer()(3) != DefaultMixer(3) || NewHash(WithMixer(SplitMix64)).Mixer()(3) != SplitMix64(3) {
+		t.Fatal("something is wrong with Mixer")
+	}
+}
+
+// 对落在同一个loose位置的KEY，compact的选择应该是均匀的
+func TestMixer_Independent(t *testing.T) {
+	for _, mix := range []func(uint64) uint64{DefaultMixer, SplitMix64, Murmur3Mixer, XXHashMixer} {
+		nl, nc := 10, 9
+		counts := make([]int, nc)
+		total := 0
+		for key := uint64(0); key < 200000; key++ {
+			if JumpHash(key, nl) != 0 {
+				continue
+			}
+			counts[JumpHash(mix(key), nc)]++
+			total++
+		}
+
+		avg := float64(total) / float64(nc)
+		for i, c := range counts {
+			if e := math.Abs(float64(c)/avg - 1); e > 0.1 {
+				t.Fatalf("the compact selection is not independent of the loose one. i: %d, c: %d, avg: %.1f", i, c, avg)
+			}
+		}
+	}
+}
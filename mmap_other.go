@@ -0,0 +1,14 @@
+//go:build !unix
+
+package doublejump
+
+import "os"
+
+// 不支持mmap的平台上读入整个文件
+func mmapFile(path string) (data []byte, unmap func() error, err error) {
+	data, err = os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
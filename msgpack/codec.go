@@ -0,0 +1,312 @@
+package msgpack
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/gnat88/doublejump"
+)
+
+func appendMap(b []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(b, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xde), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(b, 0xdf), uint32(n))
+}
+
+func appendArray(b []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(b, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xdc), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(b, 0xdd), uint32(n))
+}
+
+func appendString(b []byte, s string) []byte {
+	n := len(s)
+	switch {
+	case n < 32:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+	}
+	return append(b, s...)
+}
+
+// 使用能容纳该值的最短编码
+func appendUint(b []byte, v uint64) []byte {
+	switch {
+	case v < 128:
+		return append(b, byte(v))
+	case v <= math.MaxUint8:
+		return append(b, 0xcc, byte(v))
+	case v <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(v))
+	case v <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(v))
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xcf), v)
+}
+
+// 依次读取MessagePack的值，出错后不再读取，err记录第一个错误
+type reader struct {
+	b   []byte
+	err error
+}
+
+func (this *reader) fail() {
+	if this.err == nil {
+		this.err = ErrMalformed
+	}
+	this.b = nil
+}
+
+func (this *reader) byte() byte {
+	if len(this.b) < 1 {
+		this.fail()
+		return 0
+	}
+	c := this.b[0]
+	this.b = this.b[1:]
+	return c
+}
+
+func (this *reader) next(n uint64) []byte {
+	if uint64(len(this.b)) < n {
+		this.fail()
+		return nil
+	}
+	p := this.b[:n]
+	this.b = this.b[n:]
+	return p
+}
+
+func (this *reader) be(n int) uint64 {
+	p := this.next(uint64(n))
+	var v uint64
+	for _, c := range p {
+		v = v<<8 | uint64(c)
+	}
+	return v
+}
+
+func (this *reader) mapHeader() int {
+	c := this.byte()
+	switch {
+	case c&0xf0 == 0x80:
+		return int(c & 0x0f)
+	case c == 0xde:
+		return int(this.be(2))
+	case c == 0xdf:
+		return this.length(this.be(4))
+	}
+	this.fail()
+	return 0
+}
+
+func (this *reader) arrayHeader() int {
+	c := this.byte()
+	switch {
+	case c&0xf0 == 0x90:
+		return int(c & 0x0f)
+	case c == 0xdc:
+		return int(this.be(2))
+	case c == 0xdd:
+		return this.length(this.be(4))
+	}
+	this.fail()
+	return 0
+}
+
+// 元素数量不可能超过剩余的字节数，避免按错误的长度分配内存
+func (this *reader) length(n uint64) int {
+	if n > uint64(len(this.b)) {
+		this.fail()
+		return 0
+	}
+	return int(n)
+}
+
+func (this *reader) string() string {
+	c := this.byte()
+	var n uint64
+	switch {
+	case c&0xe0 == 0xa0:
+		n = uint64(c & 0x1f)
+	case c == 0xd9:
+		n = this.be(1)
+	case c == 0xda:
+		n = this.be(2)
+	case c == 0xdb:
+		n = this.be(4)
+	default:
+		this.fail()
+		return ""
+	}
+	return string(this.next(n))
+}
+
+// 读取非负整数，接受任意长度的有符号和无符号编码
+func (this *reader) uint() uint64 {
+	c := this.byte()
+	var v int64
+	switch {
+	case c < 0x80:
+		return uint64(c)
+	case c == 0xcc:
+		return this.be(1)
+	case c == 0xcd:
+		return this.be(2)
+	case c == 0xce:
+		return this.be(4)
+	case c == 0xcf:
+		return this.be(8)
+	case c == 0xd0:
+		v = int64(int8(this.be(1)))
+	case c == 0xd1:
+		v = int64(int16(this.be(2)))
+	case c == 0xd2:
+		v = int64(int32(this.be(4)))
+	case c == 0xd3:
+		v = int64(this.be(8))
+	default:
+		this.fail()
+		return 0
+	}
+	if v < 0 {
+		this.fail()
+		return 0
+	}
+	return uint64(v)
+}
+
+func (this *reader) uints() []uint64 {
+	n := this.arrayHeader()
+	a := make([]uint64, 0, n)
+	for i := 0; i < n && this.err == nil; i++ {
+		a = append(a, this.uint())
+	}
+	return a
+}
+
+func (this *reader) bool() bool {
+	switch this.byte() {
+	case 0xc2:
+		return false
+	case 0xc3:
+		return true
+	}
+	this.fail()
+	return false
+}
+
+func (this *reader) float() float64 {
+	switch this.byte() {
+	case 0xca:
+		return float64(math.Float32frombits(uint32(this.be(4))))
+	case 0xcb:
+		return math.Float64frombits(this.be(8))
+	}
+	this.fail()
+	return 0
+}
+
+func (this *reader) node() doublejump.NodeState {
+	var n doublejump.NodeState
+	m := this.mapHeader()
+	for i := 0; i < m && this.err == nil; i++ {
+		switch this.string() {
+		case "id":
+			n.ID = this.string()
+		case "w":
+			if w := this.uint(); w <= math.MaxInt32 {
+				n.Weight = int(w)
+			} else {
+				this.fail()
+			}
+		case "d":
+			n.Drained = this.bool()
+		case "s":
+			n.Share = this.float()
+		default:
+			this.skip()
+		}
+	}
+	return n
+}
+
+// 跳过任意一个值
+func (this *reader) skip() {
+	c := this.byte()
+	switch {
+	case c < 0x80 || c >= 0xe0 || c == 0xc0 || c == 0xc2 || c == 0xc3:
+	case c&0xf0 == 0x80:
+		this.skipN(2 * uint64(c&0x0f))
+	case c&0xf0 == 0x90:
+		this.skipN(uint64(c & 0x0f))
+	case c&0xe0 == 0xa0:
+		this.next(uint64(c & 0x1f))
+	case c == 0xc4 || c == 0xd9:
+		this.next(this.be(1))
+	case c == 0xc5 || c == 0xda:
+		this.next(this.be(2))
+	case c == 0xc6 || c == 0xdb:
+		this.next(this.be(4))
+	case c == 0xc7:
+		n := this.be(1)
+		this.next(1 + n)
+	case c == 0xc8:
+		n := this.be(2)
+		this.next(1 + n)
+	case c == 0xc9:
+		n := this.be(4)
+		this.next(1 + n)
+	case c == 0xca:
+		this.next(4)
+	case c == 0xcb:
+		this.next(8)
+	case c == 0xcc || c == 0xd0:
+		this.next(1)
+	case c == 0xcd || c == 0xd1:
+		this.next(2)
+	case c == 0xce || c == 0xd2:
+		this.next(4)
+	case c == 0xcf || c == 0xd3:
+		this.next(8)
+	case c == 0xd4:
+		this.next(2)
+	case c == 0xd5:
+		this.next(3)
+	case c == 0xd6:
+		this.next(5)
+	case c == 0xd7:
+		this.next(9)
+	case c == 0xd8:
+		this.next(17)
+	case c == 0xdc:
+		this.skipN(this.be(2))
+	case c == 0xdd:
+		this.skipN(this.be(4))
+	case c == 0xde:
+		this.skipN(2 * this.be(2))
+	case c == 0xdf:
+		this.skipN(2 * this.be(4))
+	default:
+		this.fail()
+	}
+}
+
+func (this *reader) skipN(n uint64) {
+	for i := uint64(0); i < n && this.err == nil; i++ {
+		this.skip()
+	}
+}
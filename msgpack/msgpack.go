@@ -0,0 +1,183 @@
+// Package msgpack encodes the routing state of a doublejump hash in MessagePack, for
+// embedding it in existing MessagePack payloads, e.g. of gossip or configuration, where
+// JSON is too bulky. The encoder and decoder are hand-written, so the package has no
+// dependency.
+//
+// The state is a map with short keys, extensible like the protobuf form: decoders skip
+// unknown keys, and reject greater versions.
+//
+//	{
+//	  "v": 1,                       // version
+//	  "g": generation,
+//	  "n": [{"id": "a", "w": 1, "d": true, "s": 0.1}, ...], // drained and share are optional
+//	  "l": [node index + 1, 0 for empty, ...], "lr": [virtual slot index, ...],
+//	  "c": [node index, ...],                  "cr": [virtual slot index, ...],
+//	  "e": [empty position, ...]
+//	}
+package msgpack
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/gnat88/doublejump"
+)
+
+const version = 1
+
+// ErrMalformed is returned when the data is not a valid encoded state.
+var ErrMalformed = errors.New("msgpack: malformed state")
+
+// Marshal encodes the routing state of h, see doublejump.Hash.State.
+func Marshal(h *doublejump.Hash) ([]byte, error) {
+	s, err := h.State()
+	if err != nil {
+		return nil, err
+	}
+	return AppendState(nil, s), nil
+}
+
+// Unmarshal creates a new threadsafe hash with options from the data produced by Marshal.
+func Unmarshal(data []byte, opts ...doublejump.Option) (*doublejump.Hash, error) {
+	s, rest, err := ReadState(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, ErrMalformed
+	}
+	return doublejump.NewHashFromState(s, opts...)
+}
+
+// AppendState appends the encoded state to b as a single MessagePack value, so it can be
+// embedded in a larger payload.
+func AppendState(b []byte, s *doublejump.State) []byte {
+	b = appendMap(b, 8)
+	b = appendString(b, "v")
+	b = appendUint(b, version)
+	b = appendString(b, "g")
+	b = appendUint(b, s.Generation)
+
+	b = appendString(b, "n")
+	b = appendArray(b, len(s.Nodes))
+	for _, n := range s.Nodes {
+		fields := 2
+		if n.Drained {
+			fields++
+		}
+		if n.Share > 0 {
+			fields++
+		}
+		b = appendMap(b, fields)
+		b = appendString(b, "id")
+		b = appendString(b, n.ID)
+		b = appendString(b, "w")
+		b = appendUint(b, uint64(n.Weight))
+		if n.Drained {
+			b = appendString(b, "d")
+			b = append(b, 0xc3)
+		}
+		if n.Share > 0 {
+			b = appendString(b, "s")
+			b = append(b, 0xcb)
+			b = binary.BigEndian.AppendUint64(b, math.Float64bits(n.Share))
+		}
+	}
+
+	b = appendString(b, "l")
+	b = appendArray(b, len(s.Loose))
+	for _, v := range s.Loose {
+		b = appendUint(b, uint64(v.Node+1))
+	}
+	b = appendString(b, "lr")
+	b = appendArray(b, len(s.Loose))
+	for _, v := range s.Loose {
+		b = appendUint(b, uint64(v.Replica))
+	}
+	b = appendString(b, "c")
+	b = appendArray(b, len(s.Compact))
+	for _, v := range s.Compact {
+		b = appendUint(b, uint64(v.Node))
+	}
+	b = appendString(b, "cr")
+	b = appendArray(b, len(s.Compact))
+	for _, v := range s.Compact {
+		b = appendUint(b, uint64(v.Replica))
+	}
+	b = appendString(b, "e")
+	b = appendArray(b, len(s.EmptyPositions))
+	for _, idx := range s.EmptyPositions {
+		b = appendUint(b, uint64(idx))
+	}
+	return b
+}
+
+// ReadState reads an encoded state from the beginning of b, and returns the rest of b.
+func ReadState(b []byte) (s *doublejump.State, rest []byte, err error) {
+	r := reader{b: b}
+	s = &doublejump.State{}
+	var v uint64
+	var loose, looseReplicas, compact, compactReplicas, empty []uint64
+
+	n := r.mapHeader()
+	for i := 0; i < n && r.err == nil; i++ {
+		switch r.string() {
+		case "v":
+			v = r.uint()
+		case "g":
+			s.Generation = r.uint()
+		case "n":
+			m := r.arrayHeader()
+			for j := 0; j < m && r.err == nil; j++ {
+				s.Nodes = append(s.Nodes, r.node())
+			}
+		case "l":
+			loose = r.uints()
+		case "lr":
+			looseReplicas = r.uints()
+		case "c":
+			compact = r.uints()
+		case "cr":
+			compactReplicas = r.uints()
+		case "e":
+			empty = r.uints()
+		default:
+			r.skip()
+		}
+	}
+	if r.err != nil {
+		return nil, nil, r.err
+	}
+	if v > version {
+		return nil, nil, fmt.Errorf("msgpack: unsupported state version %d", v)
+	}
+	if len(loose) != len(looseReplicas) || len(compact) != len(compactReplicas) {
+		return nil, nil, ErrMalformed
+	}
+
+	// 其他超出范围的值由doublejump.NewHashFromState检查
+	s.Loose = make([]doublejump.Slot, len(loose))
+	for i, x := range loose {
+		if x > uint64(len(s.Nodes)) || looseReplicas[i] > math.MaxInt32 {
+			return nil, nil, ErrMalformed
+		}
+		s.Loose[i] = doublejump.Slot{Node: int(x) - 1, Replica: int(looseReplicas[i])}
+	}
+	s.Compact = make([]doublejump.Slot, len(compact))
+	for i, x := range compact {
+		if x >= uint64(len(s.Nodes)) || compactReplicas[i] > math.MaxInt32 {
+			return nil, nil, ErrMalformed
+		}
+		s.Compact[i] = doublejump.Slot{Node: int(x), Replica: int(compactReplicas[i])}
+	}
+	s.EmptyPositions = make([]int, len(empty))
+	for i, x := range empty {
+		if x > math.MaxInt32 {
+			return nil, nil, ErrMalformed
+		}
+		s.EmptyPositions[i] = int(x)
+	}
+	return s, r.b, nil
+}
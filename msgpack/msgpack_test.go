@@ -0,0 +1,132 @@
+package msgpack
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/gnat88/doublejump"
+)
+
+func newHash() *doublejump.Hash {
+	h := doublejump.NewHash()
+	for i := 0; i < 40; i++ {
+		h.Add(fmt.Sprintf("node%d", i))
+	}
+	h.Remove("node3")
+	h.Remove("node17")
+	h.Drain("node5")
+	h.AddCanary(strings.Repeat("x", 300), 0.5)
+	return h
+}
+
+func TestMarshal(t *testing.T) {
+	h := newHash()
+	data, err := Marshal(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proto, _ := h.ToProto()
+	if len(data) > len(proto)*2 {
+		t.Fatalf("the encoding is too bulky. len: %d, proto: %d", len(data), len(proto))
+	}
+
+	h2, err := Unmarshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h2.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if !h.EqualLayout(h2) || h2.Generation() != h.Generation() {
+		t.Fatal("the state should be restored")
+	}
+	if !h2.Drained("node5") || h2.Share(strings.Repeat("x", 300)) != 0.5 {
+		t.Fatal("the drained states and the shares should be restored")
+	}
+	for key := uint64(0); key < 10000; key++ {
+		if h.Get(key) != h2.Get(key) {
+			t.Fatalf("h.Get(%d) != h2.Get(%d)", key, key)
+		}
+	}
+
+	if _, err := Marshal(doublejump.NewHashFromNodes(1)); err != doublejump.ErrNotString {
+		t.Fatal("err != doublejump.ErrNotString")
+	}
+}
+
+func TestReadState(t *testing.T) {
+	h := newHash()
+	s, _ := h.State()
+
+	// 嵌入到更大的数组中
+	b := appendArray(nil, 3)
+	b = appendString(b, "before")
+	b = AppendState(b, s)
+	b = appendString(b, "after")
+
+	r := reader{b: b}
+	if r.arrayHeader() != 3 || r.string() != "before" {
+		t.Fatal("unexpected payload")
+	}
+	s2, rest, err := ReadState(r.b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r = reader{b: rest}
+	if r.string() != "after" || len(r.b) != 0 {
+		t.Fatal("ReadState should return the rest")
+	}
+	h2, err := doublejump.NewHashFromState(s2)
+	if err != nil || !h.EqualLayout(h2) {
+		t.Fatal("the embedded state should be restored")
+	}
+
+	// 跳过未知的字段
+	data := AppendState(nil, s)
+	var extra []byte
+	extra = appendMap(extra, 9)
+	extra = appendString(extra, "x")
+	extra = appendArray(extra, 4)
+	extra = appendMap(extra, 1)
+	extra = appendString(extra, "k")
+	extra = append(extra, 0xcb, 0, 0, 0, 0, 0, 0, 0, 0)
+	extra = append(extra, 0xc4, 2, 1, 2, 0xff, 0xc0)
+	extra = append(extra, data[1:]...)
+	if h3, err := Unmarshal(extra); err != nil || !h.EqualLayout(h3) {
+		t.Fatalf("unknown keys should be skipped. err: %v", err)
+	}
+
+	for i := 0; i < len(data); i++ {
+		if _, err := Unmarshal(data[:i]); err == nil {
+			t.Fatalf("a truncated state should be rejected. len: %d", i)
+		}
+	}
+	if _, err := Unmarshal(append(data, 0)); err != ErrMalformed {
+		t.Fatal("trailing bytes should be rejected")
+	}
+
+	future := appendMap(nil, 1)
+	future = appendString(future, "v")
+	future = appendUint(future, version+1)
+	if _, _, err := ReadState(future); err == nil {
+		t.Fatal("a newer version should be rejected")
+	}
+}
+
+func TestAppendUint(t *testing.T) {
+	for _, v := range []uint64{0, 127, 128, 255, 256, 65535, 65536, 1<<32 - 1, 1 << 32, 1<<64 - 1} {
+		r := reader{b: appendUint(nil, v)}
+		if got := r.uint(); got != v || r.err != nil || len(r.b) != 0 {
+			t.Fatalf("unexpected round trip of %d: %d", v, got)
+		}
+	}
+	r := reader{b: []byte{0xd0, 0xff}}
+	if r.uint(); r.err == nil {
+		t.Fatal("negative integers should be rejected")
+	}
+	if !bytes.Equal(appendString(nil, strings.Repeat("a", 40))[:2], []byte{0xd9, 40}) {
+		t.Fatal("unexpected string header")
+	}
+}
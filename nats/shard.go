@@ -0,0 +1,119 @@
+// Package nats shards work over NATS subjects with doublejump, without a central
+// coordinator. Publishers send each message to one of a fixed number of shard subjects,
+// chosen by the key of the message, and each shard is owned by one worker, chosen by a
+// doublejump hash over the workers. Every worker subscribes to the subjects of its shards:
+//
+//	s := nats.NewSharder("orders", 64, workers...)
+//	nc.Publish(s.SubjectString(orderID), data)   // publisher
+//	for _, subj := range s.Filters(self) {       // worker
+//		nc.Subscribe(subj, handle)
+//	}
+//
+// The package depends on no NATS client. As workers come and go, only the shards of the
+// leaving workers, or the share taken by the joining ones, change owner, so workers only
+// need to adjust a few subscriptions. All processes must apply the same membership changes
+// in the same order, e.g. from a shared configuration, to agree on the owners.
+package nats
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/gnat88/doublejump"
+)
+
+// Sharder maps keys to shard subjects and shards to workers. It is threadsafe.
+type Sharder struct {
+	prefix  string
+	shards  *doublejump.Hash
+	workers *doublejump.Hash
+	n       int
+}
+
+// NewSharder creates a sharder with the subjects prefix.0 to prefix.<shards-1>, owned by
+// the workers. shards must not change once messages are published, since it decides the
+// subject of every key. shards < 1 means 1.
+func NewSharder(prefix string, shards int, workers ...string) *Sharder {
+	if shards < 1 {
+		shards = 1
+	}
+
+	nodes := make([]interface{}, shards)
+	for i := range nodes {
+		nodes[i] = i
+	}
+	return &Sharder{
+		prefix:  prefix,
+		shards:  doublejump.NewHashFromNodes(nodes...),
+		workers: doublejump.NewHashFromStrings(workers),
+		n:       shards,
+	}
+}
+
+// Shard returns the shard of the key.
+func (this *Sharder) Shard(key uint64) int {
+	return this.shards.Get(key).(int)
+}
+
+// Subject returns the subject a message with the key should be published to.
+func (this *Sharder) Subject(key uint64) string {
+	return this.subject(this.Shard(key))
+}
+
+// SubjectString is like Subject, but takes a string key.
+func (this *Sharder) SubjectString(key string) string {
+	return this.Subject(doublejump.KeyString(key))
+}
+
+func (this *Sharder) subject(shard int) string {
+	return this.prefix + "." + strconv.Itoa(shard)
+}
+
+// AddWorker adds a worker. It reports whether the worker was newly added.
+func (this *Sharder) AddWorker(worker string) bool {
+	return this.workers.Add(worker)
+}
+
+// RemoveWorker removes a worker. It reports whether the worker was there.
+func (this *Sharder) RemoveWorker(worker string) bool {
+	return this.workers.Remove(worker)
+}
+
+// SetWorkers reconciles the workers to exactly the given ones, see doublejump.Hash.Set.
+func (this *Sharder) SetWorkers(workers []string) {
+	nodes := make([]interface{}, len(workers))
+	for i, w := range workers {
+		nodes[i] = w
+	}
+	this.workers.Set(nodes)
+}
+
+// Owner returns the worker owning the shard, or "" if there is no worker.
+func (this *Sharder) Owner(shard int) string {
+	obj := this.workers.Get(doublejump.PartitionKey(shard))
+	if obj == nil {
+		return ""
+	}
+	return obj.(string)
+}
+
+// Filters returns the subjects the worker should subscribe to, ordered by shard.
+func (this *Sharder) Filters(worker string) []string {
+	return this.Assignments()[worker]
+}
+
+// Assignments returns the subjects of every worker, ordered by shard.
+func (this *Sharder) Assignments() map[string][]string {
+	m := make(map[string][]string)
+	owners := this.workers.AssignPartitions(this.n)
+	shards := make([]int, 0, len(owners))
+	for shard := range owners {
+		shards = append(shards, shard)
+	}
+	sort.Ints(shards)
+	for _, shard := range shards {
+		w := owners[shard].(string)
+		m[w] = append(m[w], this.subject(shard))
+	}
+	return m
+}
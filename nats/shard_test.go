@@ -0,0 +1,72 @@
+package nats
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSharder(t *testing.T) {
+	s := NewSharder("orders", 16, "w1", "w2", "w3")
+
+	if subj := s.SubjectString("order-1"); subj != s.SubjectString("order-1") || !strings.HasPrefix(subj, "orders.") {
+		t.Fatalf("unexpected subject: %s", subj)
+	}
+	for key := uint64(0); key < 1000; key++ {
+		if shard := s.Shard(key); shard < 0 || shard >= 16 {
+			t.Fatalf("the shard is out of range. shard: %d", shard)
+		}
+	}
+
+	a1 := s.Assignments()
+	total := 0
+	seen := make(map[string]bool)
+	for w, subjects := range a1 {
+		for _, subj := range subjects {
+			if seen[subj] {
+				t.Fatalf("%s is assigned twice", subj)
+			}
+			seen[subj] = true
+		}
+		total += len(subjects)
+		if strings.Join(s.Filters(w), ",") != strings.Join(subjects, ",") {
+			t.Fatal("s.Filters() != s.Assignments()")
+		}
+	}
+	if total != 16 {
+		t.Fatalf("every shard should be assigned. total: %d", total)
+	}
+	for shard := 0; shard < 16; shard++ {
+		found := false
+		for _, subj := range s.Filters(s.Owner(shard)) {
+			if subj == s.subject(shard) {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("the owner of shard %d does not subscribe to it", shard)
+		}
+	}
+
+	// 删除worker时，只有它的分片移动
+	owners := make([]string, 16)
+	for shard := range owners {
+		owners[shard] = s.Owner(shard)
+	}
+	s.RemoveWorker("w2")
+	for shard, w := range owners {
+		if w != "w2" && s.Owner(shard) != w {
+			t.Fatalf("shard %d moved from %s to %s", shard, w, s.Owner(shard))
+		}
+	}
+	if s.Filters("w2") != nil {
+		t.Fatal("the removed worker should subscribe to nothing")
+	}
+
+	s.SetWorkers(nil)
+	if s.Owner(0) != "" || len(s.Assignments()) != 0 {
+		t.Fatal("no shard should be owned without workers")
+	}
+	if !s.AddWorker("w4") || len(s.Filters("w4")) != 16 {
+		t.Fatal("the only worker should own every shard")
+	}
+}
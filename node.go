@@ -0,0 +1,276 @@
+package doublejump
+
+import "time"
+
+// Node describes an object together with its weight and metadata.
+type Node struct {
+	// Value is the object itself.
+	Value interface{}
+	// Weight is the number of slots the object takes in the hash, so that the object
+	// owns a share of keys proportional to it. Zero or negative means 1.
+	Weight int
+	// Meta is arbitrary metadata attached to the object.
+	Meta interface{}
+}
+
+// 哈希内部保存的节点信息
+type node struct {
+	obj      interface{}
+	weight   int
+	meta     interface{}
+	capacity int     // 为0表示没有上限
+	drained  bool    // 见Drain
+	share    float64 // 金丝雀节点接收的KEY比例，0表示普通节点，见AddCanary
+	breaker  Breaker // 见SetBreaker
+	latency  *ewma   // 见ReportLatency，加入节点时创建，快照和哈希共享
+
+	// 见Penalize，reinstate不为空表示正在惩罚，调用它取消恢复的定时器
+	reinstate func() bool
+	penalty   uint64
+	until     time.Time // 恢复的时间
+
+	labels  map[string]string // 见AddLabeled
+	undrain float64           // 见DrainOver，逐步排空期间为排空前的份额，完整的节点为1
+}
+
+// 节点的第i个虚拟位置(i >= 1)，第0个位置就是节点标识本身
+type vslot struct {
+	id interface{}
+	i  int
+}
+
+// 返回位置所属节点的标识
+func owner(slot interface{}) interface{} {
+	if v, ok := slot.(vslot); ok {
+		return v.id
+	}
+	return slot
+}
+
+// 返回节点的第i个位置
+func slotOf(id interface{}, i int) interface{} {
+	if i == 0 {
+		return id
+	}
+	return vslot{id: id, i: i}
+}
+
+// AddWeighted adds an object taking k slots of the hash, so that it owns about k times as
+// many keys as an object added by Add. The object is still a single object for Get, Nodes,
+// Len and the like. k < 1 means 1. It reports whether the object was newly inserted; the
+// weight of an existing object is left unchanged.
+func (this *Hash) AddWeighted(obj interface{}, k int) bool {
+	return this.AddNode(Node{Value: obj, Weight: k})
+}
+
+// AddNode adds an object together with its weight and metadata, see AddWeighted.
+// It reports whether the object was newly inserted.
+func (this *Hash) AddNode(n Node) bool {
+	if this == nil || n.Value == nil {
+		return false
+	}
+
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	return this.addNode(n)
+}
+
+// Weight returns the number of slots the object takes in the hash, 0 if it is not in the hash.
+func (this *Hash) Weight(obj interface{}) int {
+	if this == nil || obj == nil {
+		return 0
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	if n, ok := this.nodes[this.id(obj)]; ok {
+		return n.weight
+	}
+	return 0
+}
+
+// SetVirtualSlots changes the number of slots the object takes in the hash at runtime, for
+// gradual capacity changes. Growing appends new slots, so only the keys landing on them move
+// to the object; shrinking empties the last slots of the object, so only their keys move
+// away, the same as removing an object. k < 1 means 1. It returns false if the object is
+// not in the hash.
+func (this *Hash) SetVirtualSlots(obj interface{}, k int) bool {
+	if this == nil || obj == nil {
+		return false
+	}
+
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	return this.setVirtualSlots(this.id(obj), k)
+}
+
+// 调用方负责加锁
+func (this *Hash) setVirtualSlots(id interface{}, k int) bool {
+	n, ok := this.nodes[id]
+	if !ok || this.frozen {
+		return false
+	}
+	if k < 1 {
+		k = 1
+	}
+	if k == n.weight {
+		return true
+	}
+
+	// 与removeNode相同，按相反的顺序删除虚拟位置
+	for i := n.weight; i < k; i++ {
+		slot := slotOf(id, i)
+		this.loose.add(slot)
+		this.compact.add(slot)
+	}
+	for i := n.weight - 1; i >= k; i-- {
+		slot := slotOf(id, i)
+		this.loose.remove(slot)
+		this.compact.remove(slot)
+	}
+	n.weight = k
+	this.changed()
+	this.emit(Event{Type: EventWeight, Node: n.obj})
+	return true
+}
+
+// 调用方负责加锁
+func (this *Hash) addNode(n Node) bool {
+	if this.frozen {
+		return false
+	}
+	if this.weightOf != nil && n.Meta != nil {
+		n.Weight = this.weightOf(n.Meta)
+	}
+	if this.normalize != nil {
+		n.Value = this.normalize(n.Value)
+	}
+	id := this.keyOf(n.Value)
+	if _, ok := this.nodes[id]; ok {
+		return false
+	}
+	if this.usage != nil && !this.usage.acquire() {
+		return false
+	}
+
+	weight := n.Weight
+	if weight <= 0 {
+		weight = 1
+	}
+	for i := 0; i < weight; i++ {
+		slot := slotOf(id, i)
+		this.loose.add(slot)
+		this.compact.add(slot)
+	}
+	this.nodes[id] = &node{obj: n.Value, weight: weight, meta: n.Meta, latency: new(ewma)}
+	if this.removed != nil {
+		this.removed.forget(id)
+	}
+	this.changed()
+	this.emit(Event{Type: EventAdd, Node: n.Value})
+	return true
+}
+
+// 按相反的顺序删除虚拟位置，这样重新加入时能依次回到原来的位置
+func (this *Hash) removeNode(id interface{}) bool {
+	n, ok := this.nodes[id]
+	if !ok || this.frozen {
+		return false
+	}
+	if this.removed != nil {
+		this.removed.record(this, id, n)
+	}
+
+	for i := n.weight - 1; i >= 0; i-- {
+		slot := slotOf(id, i)
+		this.loose.remove(slot)
+		this.compact.remove(slot)
+	}
+	this.forget(n)
+	if this.usage != nil {
+		this.usage.release(1)
+	}
+	delete(this.nodes, id)
+	this.changed()
+	this.emit(Event{Type: EventRemove, Node: n.obj})
+	return true
+}
+
+// 节点离开哈希时更新过滤规则的计数
+func (this *Hash) forget(n *node) {
+	if n.capacity > 0 {
+		this.capped--
+	}
+	if n.drained {
+		this.drained--
+	}
+	if n.share > 0 {
+		this.canaries--
+	}
+	if n.breaker != nil {
+		this.breakers--
+	}
+	if n.reinstate != nil {
+		n.reinstate()
+		this.penalized--
+	}
+}
+
+// Meta returns the metadata attached to the object.
+func (this *Hash) Meta(obj interface{}) interface{} {
+	if this == nil || obj == nil {
+		return nil
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	if n, ok := this.nodes[this.id(obj)]; ok {
+		return n.meta
+	}
+	return nil
+}
+
+// SetMeta attaches metadata to the object, and recomputes its weight from it if the hash
+// was created with WithWeightFunc. It returns false if the object is not in the hash, or
+// if the hash is frozen.
+func (this *Hash) SetMeta(obj interface{}, meta interface{}) bool {
+	if this == nil || obj == nil {
+		return false
+	}
+
+	ok := false
+	this.mutate(func() {
+		id := this.id(obj)
+		n, found := this.nodes[id]
+		if !found {
+			return
+		}
+		n.meta = meta
+		this.retire()
+		if this.weightOf != nil && meta != nil {
+			this.setVirtualSlots(id, this.weightOf(meta))
+		}
+		ok = true
+	})
+	return ok
+}
@@ -0,0 +1,92 @@
+package doublejump
+
+import "testing"
+
+func TestHash_AddWeighted(t *testing.T) {
+	h := NewHash()
+	h.Add("a")
+	h.Add("b")
+	if !h.AddWeighted("c", 4) || h.AddWeighted("c", 2) || h.AddWeighted(nil, 2) {
+		t.Fatal("AddWeighted is wrong")
+	}
+	if h.Len() != 3 || len(h.Nodes()) != 3 {
+		t.Fatal("h.Len() != 3")
+	}
+	if h.Weight("a") != 1 || h.Weight("c") != 4 || h.Weight("x") != 0 {
+		t.Fatal("Weight is wrong")
+	}
+
+	// c大约拥有4/6的KEY
+	const n = 60000
+	owned := make(map[interface{}]int)
+	for key := uint64(0); key < n; key++ {
+		owned[h.Get(key)]++
+	}
+	if c := owned["c"]; c < n*4/6*9/10 || c > n*4/6*11/10 {
+		t.Fatalf("the weight is not respected. owned: %v", owned)
+	}
+	if a := h.GetN(0, 3); a == nil || a[0] == a[1] || a[1] == a[2] || a[0] == a[2] {
+		t.Fatalf("GetN should return distinct objects. a: %v", a)
+	}
+
+	h.Remove("c")
+	if h.LooseLen() != 6 || h.Len() != 2 {
+		t.Fatal("all slots of c should be removed")
+	}
+	always(h, t)
+
+	if !h.AddNode(Node{Value: "d", Weight: 2, Meta: "m"}) || h.Meta("d") != "m" || h.Weight("d") != 2 {
+		t.Fatal("AddNode is wrong")
+	}
+}
+
+func TestHash_SetVirtualSlots(t *testing.T) {
+	h := NewHash()
+	for i := 0; i < 5; i++ {
+		h.Add(i)
+	}
+	const n = 20000
+	get := func() []interface{} {
+		a := make([]interface{}, n)
+		for key := range a {
+			a[key] = h.Get(uint64(key))
+		}
+		return a
+	}
+
+	var events []Event
+	h.Subscribe(func(ev Event) { events = append(events, ev) })
+
+	before := get()
+	if !h.SetVirtualSlots(2, 3) || h.Weight(2) != 3 || h.Len() != 5 {
+		t.Fatal("SetVirtualSlots is wrong")
+	}
+	always(h, t)
+	grown := get()
+	for key := range grown {
+		if grown[key] != before[key] && grown[key] != 2 {
+			t.Fatal("growing should only move keys to the object")
+		}
+	}
+
+	if !h.SetVirtualSlots(2, 1) || h.Weight(2) != 1 {
+		t.Fatal("SetVirtualSlots is wrong")
+	}
+	always(h, t)
+	shrunk := get()
+	for key := range shrunk {
+		if shrunk[key] != grown[key] && grown[key] != 2 {
+			t.Fatal("shrinking should only move keys away from the object")
+		}
+	}
+	if h.LooseLen() != 7 || len(h.Nodes()) != 5 {
+		t.Fatal("the slots should be emptied")
+	}
+
+	if h.SetVirtualSlots(100, 2) || !h.SetVirtualSlots(2, 0) {
+		t.Fatal("SetVirtualSlots is wrong")
+	}
+	if len(events) != 2 || events[0].Type != EventWeight || events[0].Node != 2 {
+		t.Fatalf("unexpected events: %v", events)
+	}
+}
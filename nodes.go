@@ -0,0 +1,124 @@
+package doublejump
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Nodes returns all objects in the hash. The objects are ordered by their slots in the
+// inner loose holder, so two hashes which applied the same operations return the same order.
+// With WithPool, the slice can be handed back by Recycle.
+func (this *Hash) Nodes() []interface{} {
+	if this == nil {
+		return nil
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	a := this.pool.get(len(this.nodes))
+	for _, slot := range this.loose.a {
+		if isPrimary(slot) {
+			a = append(a, this.value(slot))
+		}
+	}
+	return a
+}
+
+// Range calls f for each object in the same order as Nodes. If f returns false, Range stops.
+// f is called with the hash locked, so it must not call back into the hash.
+func (this *Hash) Range(f func(obj interface{}) bool) {
+	if this == nil {
+		return
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	for _, slot := range this.loose.a {
+		if isPrimary(slot) && !f(this.value(slot)) {
+			return
+		}
+	}
+}
+
+// Stream sends all objects in the same order as Nodes on the returned channel, which is
+// closed after the last object or once ctx is done. The objects come from a snapshot taken
+// when Stream is called, so the hash is neither locked during the iteration nor copied,
+// which suits very large hashes and slow consumers. The caller must drain the channel or
+// cancel ctx, otherwise the sending goroutine leaks.
+func (this *Hash) Stream(ctx context.Context) <-chan interface{} {
+	ch := make(chan interface{})
+	s := this.Snapshot()
+	if s == nil {
+		close(ch)
+		return ch
+	}
+
+	go func() {
+		defer close(ch)
+		defer s.Release()
+		h := s.hash
+		for _, slot := range h.loose.a {
+			if !isPrimary(slot) {
+				continue
+			}
+			select {
+			case ch <- h.value(slot):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// 判断是否为节点本身所在的位置，而不是空位置或者虚拟位置
+func isPrimary(slot interface{}) bool {
+	if slot == nil {
+		return false
+	}
+	_, ok := slot.(vslot)
+	return !ok
+}
+
+// 序列化后的结构，loose中的null表示空位置，虚拟位置输出为所属的节点
+type hashJSON struct {
+	Loose   []interface{} `json:"loose"`
+	Compact []interface{} `json:"compact"`
+}
+
+// MarshalJSON implements json.Marshaler. It outputs the slots of the loose holder, with
+// null for empty slots, followed by the compact holder, both in their inner order, so
+// that two hashes which applied the same operations are serialized byte-equal.
+func (this *Hash) MarshalJSON() ([]byte, error) {
+	if this == nil {
+		return []byte("null"), nil
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	var v hashJSON
+	v.Loose = make([]interface{}, len(this.loose.a))
+	for i, slot := range this.loose.a {
+		v.Loose[i] = this.value(owner(slot))
+	}
+	v.Compact = make([]interface{}, len(this.compact.a))
+	for i, slot := range this.compact.a {
+		v.Compact[i] = this.value(owner(slot))
+	}
+	return json.Marshal(v)
+}
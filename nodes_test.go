@@ -0,0 +1,103 @@
+package doublejump
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestHash_Nodes(t *testing.T) {
+	h := NewHash()
+	if len(h.Nodes()) != 0 {
+		t.Fatal("h.Nodes() should be empty")
+	}
+
+	for i := 0; i < 5; i++ {
+		h.Add(i)
+	}
+	h.Remove(1)
+	h.Remove(3)
+	h.Add(5)
+
+	want := []interface{}{0, 2, 5, 4}
+	if fmt.Sprint(h.Nodes()) != fmt.Sprint(want) {
+		t.Fatalf("h.Nodes() should be in the loose order. nodes: %v, want: %v", h.Nodes(), want)
+	}
+
+	var a []interface{}
+	h.Range(func(obj interface{}) bool {
+		a = append(a, obj)
+		return len(a) < 2
+	})
+	if fmt.Sprint(a) != fmt.Sprint(want[:2]) {
+		t.Fatalf("h.Range should stop when f returns false. a: %v", a)
+	}
+}
+
+func TestHash_Stream(t *testing.T) {
+	h := NewHash()
+	for i := 0; i < 100; i++ {
+		h.Add(i)
+	}
+	h.Remove(50)
+
+	var a []interface{}
+	for obj := range h.Stream(context.Background()) {
+		if len(a) == 0 {
+			// 迭代过程中哈希没有加锁，也不受变化的影响
+			h.Add(1000)
+			h.Remove(99)
+		}
+		a = append(a, obj)
+	}
+	if len(a) != 99 || a[98] != 99 || contains(a, 1000) {
+		t.Fatalf("Stream should yield the nodes of the snapshot. len: %d", len(a))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := h.Stream(ctx)
+	<-ch
+	cancel()
+	for range ch {
+	}
+
+	var nilHash *Hash
+	if _, ok := <-nilHash.Stream(context.Background()); ok {
+		t.Fatal("a nil hash should yield nothing")
+	}
+}
+
+func TestHash_MarshalJSON(t *testing.T) {
+	replay := func() *Hash {
+		h := NewHash()
+		for i := 0; i < 10; i++ {
+			h.Add(fmt.Sprintf("node%d", i))
+		}
+		h.Remove("node3")
+		h.Remove("node7")
+		h.Add("node10")
+		return h
+	}
+
+	b1, err := json.Marshal(replay())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b2, err := json.Marshal(replay())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b1, b2) {
+		t.Fatalf("replicas should be serialized byte-equal. b1: %s, b2: %s", b1, b2)
+	}
+
+	var v hashJSON
+	if err := json.Unmarshal(b1, &v); err != nil {
+		t.Fatal(err)
+	}
+	if len(v.Loose) != 10 || v.Loose[3] != nil || v.Loose[7] != "node10" || len(v.Compact) != 9 {
+		t.Fatalf("the serialized slots are wrong. %s", b1)
+	}
+}
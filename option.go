@@ -0,0 +1,75 @@
+package doublejump
+
+import "strings"
+
+// Option configures a Hash on construction.
+type Option func(h *Hash)
+
+// WithKeyFunc makes the hash identify nodes by the comparable ID extracted by f,
+// so nodes themselves do not have to be comparable (e.g. structs containing slices).
+// Add, Remove, Contains and the like all look nodes up by the extracted ID.
+func WithKeyFunc(f func(obj interface{}) interface{}) Option {
+	return func(h *Hash) {
+		h.keyFunc = f
+	}
+}
+
+// WithNormalizer makes the hash normalize objects by f before anything else, so that
+// different forms of the same object, like "Host:80" and "host.:80" from sloppy discovery
+// data, collapse into one object instead of silently skewing the distribution. Objects are
+// stored in their normalized form, and Add, Remove, Contains and the like all look them up
+// by it. f must be idempotent and must not return nil for a non-nil object.
+// See NormalizeHostPort.
+func WithNormalizer(f func(obj interface{}) interface{}) Option {
+	return func(h *Hash) {
+		h.normalize = f
+	}
+}
+
+// NormalizeHostPort normalizes string objects of the form "host:port" or "host": it trims
+// spaces and the trailing dots of the host and of the whole string, and lowercases the host.
+// Objects of other types are returned unchanged.
+func NormalizeHostPort(obj interface{}) interface{} {
+	s, ok := obj.(string)
+	if !ok {
+		return obj
+	}
+
+	s = strings.TrimRight(strings.TrimSpace(s), ".")
+	host, port := s, ""
+	// 没有方括号的IPv6地址不拆分端口
+	if i := strings.LastIndexByte(s, ':'); i > 0 && (strings.Count(s, ":") == 1 || s[0] == '[' && s[i-1] == ']') {
+		host, port = s[:i], s[i:]
+	}
+	return strings.ToLower(strings.TrimRight(host, ".")) + port
+}
+
+// WithMixer sets the function which mixes the key before the compact holder selects an
+// object for keys falling into empty slots. The default is DefaultMixer.
+func WithMixer(mix func(key uint64) uint64) Option {
+	return func(h *Hash) {
+		h.compact.mix = mix
+	}
+}
+
+// WithJumpFunc replaces the jump consistent hash selecting one of n slots for a key, e.g.
+// to force specific placements in tests, to simulate pathological distributions, or to try
+// experimental variants. f must return a value in [0, n), and should be consistent like
+// JumpHash: growing n by one should only move keys to the new slot.
+func WithJumpFunc(f func(key uint64, n int) int) Option {
+	return func(h *Hash) {
+		h.loose.jump = f
+		h.compact.jump = f
+	}
+}
+
+// WithWeightFunc makes the hash derive the weight of an object from its metadata, e.g. from
+// its number of CPUs, whenever the metadata is set: by AddNode, SetNodes and SetMeta. The
+// weight then changes as by SetVirtualSlots, so capacity-proportional sharding follows the
+// instance types. Objects without metadata keep the given weight. f is called with the
+// hash locked, so it must not call back into the hash.
+func WithWeightFunc(f func(meta interface{}) int) Option {
+	return func(h *Hash) {
+		h.weightOf = f
+	}
+}
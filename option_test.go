@@ -0,0 +1,155 @@
+package doublejump
+
+import "testing"
+
+type richNode struct {
+	name  string
+	addrs []string
+}
+
+func TestHash_WithKeyFunc(t *testing.T) {
+	h := NewHash(WithKeyFunc(func(obj interface{}) interface{} {
+		return obj.(*richNode).name
+	}))
+
+	for i := 0; i < 10; i++ {
+		h.Add(&richNode{name: string(rune('a' + i)), addrs: []string{"127.0.0.1"}})
+	}
+	always(h, t)
+	if h.Add(&richNode{name: "a"}) {
+		t.Fatal("h.Add should return false for a node with an existing ID")
+	}
+	if !h.Contains(&richNode{name: "b"}) {
+		t.Fatal("h.Contains should look the node up by its ID")
+	}
+	if h.ID(&richNode{name: "b"}) != "b" || NewHash().ID(1) != 1 {
+		t.Fatal("h.ID should return the extracted ID")
+	}
+
+	for key := uint64(0); key < 100; key++ {
+		n, ok := h.Get(key).(*richNode)
+		if !ok || len(n.addrs) != 1 {
+			t.Fatalf("h.Get should return the original node. obj: %v", h.Get(key))
+		}
+		if h.GetExcluding(key, n).(*richNode).name == n.name {
+			t.Fatal("h.GetExcluding should exclude nodes by their IDs")
+		}
+	}
+
+	if !h.Remove(&richNode{name: "a"}) || h.Len() != 9 || len(h.nodes) != 9 || len(h.compact.a) != 9 {
+		t.Fatalf("h.Remove should remove the node by its ID. h.Len(): %d", h.Len())
+	}
+	always(h, t)
+}
+
+func TestNormalizeHostPort(t *testing.T) {
+	cases := map[interface{}]interface{}{
+		"10.0.0.1:11211":        "10.0.0.1:11211",
+		"10.0.0.1:11211.":       "10.0.0.1:11211",
+		" Cache-1.Example.:80 ": "cache-1.example:80",
+		"CACHE-1.example":       "cache-1.example",
+		"[FE80::1]:80":          "[fe80::1]:80",
+		"FE80::1":               "fe80::1",
+		42:                      42,
+	}
+	for in, want := range cases {
+		if got := NormalizeHostPort(in); got != want {
+			t.Fatalf("NormalizeHostPort(%v) != %v. got: %v", in, want, got)
+		}
+	}
+}
+
+func TestWithNormalizer(t *testing.T) {
+	h := NewHash(WithNormalizer(NormalizeHostPort))
+	if !h.Add("Cache-1:80") {
+		t.Fatal("h.Add failed")
+	}
+	if h.Add("cache-1.:80") || h.Add("cache-1:80.") {
+		t.Fatal("the forms of the same object should collapse")
+	}
+	h.Add("cache-2:80")
+	if h.Len() != 2 {
+		t.Fatal("h.Len() != 2")
+	}
+	if !h.Contains("CACHE-1:80") {
+		t.Fatal("Contains should normalize the object")
+	}
+	if h.Get(1) != "cache-1:80" && h.Get(1) != "cache-2:80" {
+		t.Fatal("Get should return the normalized objects")
+	}
+	always(h, t)
+
+	if !h.Remove("cache-1.:80") || h.Len() != 1 {
+		t.Fatal("Remove should normalize the object")
+	}
+
+	s := h.Snapshot()
+	defer s.Release()
+	if !s.hash.Contains("CACHE-2:80") {
+		t.Fatal("the snapshot should keep the normalizer")
+	}
+
+	// 每条路径只规范化一次，不幂等的函数也能找回节点
+	h2 := NewHash(WithNormalizer(func(obj interface{}) interface{} { return obj.(string) + "!" }))
+	h2.Add("a")
+	h2.SetNodes([]Node{{Value: "a"}, {Value: "b"}})
+	if h2.Len() != 2 || !h2.Contains("a") || !h2.Contains("b") || h2.Nodes()[0] != "a!" {
+		t.Fatalf("the objects should be normalized once. nodes: %v", h2.Nodes())
+	}
+	if !h2.Remove("a") || h2.Len() != 1 {
+		t.Fatal("Remove should find the object normalized once")
+	}
+}
+
+func TestWithJumpFunc(t *testing.T) {
+	// 所有KEY都落到最后一个位置
+	last := func(key uint64, n int) int { return n - 1 }
+	h := NewHash(WithJumpFunc(last))
+	for i := 0; i < 5; i++ {
+		h.Add(i)
+	}
+	for key := uint64(0); key < 100; key++ {
+		if h.Get(key) != 4 {
+			t.Fatalf("h.Get(%d) != 4", key)
+		}
+	}
+
+	// 落到空位置时由compactHolder选择最后一个位置
+	h.Remove(4)
+	for key := uint64(0); key < 100; key++ {
+		if h.Get(key) != 3 {
+			t.Fatalf("h.Get(%d) != 3", key)
+		}
+	}
+	s := h.Snapshot()
+	defer s.Release()
+	if s.Get(0) != 3 {
+		t.Fatal("the snapshot should keep the jump function")
+	}
+	if a := h.GetN(0, 4); len(a) != 4 || a[0] != 3 {
+		t.Fatalf("the candidates should not depend on the spread of the jump function. a: %v", a)
+	}
+}
+
+func TestWithWeightFunc(t *testing.T) {
+	type instance struct{ cpus int }
+	h := NewHash(WithWeightFunc(func(meta interface{}) int { return meta.(instance).cpus }))
+	h.Add("plain")
+	h.AddNode(Node{Value: "a", Meta: instance{cpus: 4}})
+	h.AddNode(Node{Value: "b", Weight: 7, Meta: instance{cpus: 2}})
+	if h.Weight("plain") != 1 || h.Weight("a") != 4 || h.Weight("b") != 2 {
+		t.Fatal("the weights should be derived from the metadata")
+	}
+
+	gen := h.Generation()
+	h.SetMeta("a", instance{cpus: 8})
+	if h.Weight("a") != 8 || h.Generation() == gen {
+		t.Fatal("SetMeta should update the weight")
+	}
+	always(h, t)
+
+	h.SetNodes([]Node{{Value: "a", Meta: instance{cpus: 1}}, {Value: "b", Weight: 5}})
+	if h.Weight("a") != 1 || h.Weight("b") != 5 {
+		t.Fatal("SetNodes should update the weights")
+	}
+}
@@ -0,0 +1,92 @@
+package doublejump
+
+import (
+	"math"
+	"math/bits"
+)
+
+// PartitionKey returns the key by which AssignPartitions places partition p.
+// The partition numbers are mixed so that consecutive partitions spread evenly.
+func PartitionKey(p int) uint64 {
+	return SplitMix64(uint64(p))
+}
+
+// AssignPartitions assigns the partitions 0..n-1 to the objects, resolved under a single
+// lock acquisition. Partition p is owned by Get(PartitionKey(p)), so as objects come and
+// go, only the partitions of the removed objects, or the share taken by the added ones,
+// change hands. It returns nil if n <= 0 or the hash is empty.
+func (this *Hash) AssignPartitions(n int) map[int]interface{} {
+	if this == nil || n <= 0 {
+		return nil
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	if len(this.nodes) == 0 {
+		return nil
+	}
+
+	m := make(map[int]interface{}, n)
+	for p := 0; p < n; p++ {
+		m[p] = this.get(PartitionKey(p))
+	}
+	return m
+}
+
+// Range is a contiguous range of keys owned by an object, see Ranges.
+type Range struct {
+	// Start and End are the first and the last keys of the range, both inclusive.
+	Start, End uint64
+	// Node is the object owning the range.
+	Node interface{}
+}
+
+// Contains reports whether the key is in the range.
+func (this Range) Contains(key uint64) bool {
+	return key >= this.Start && key <= this.End
+}
+
+// Ranges splits the whole uint64 keyspace into n contiguous ranges of nearly equal sizes,
+// in ascending order, and assigns them to the objects like AssignPartitions does, i.e.
+// range i is owned by Get(PartitionKey(i)). It is for systems which need to own ranges of
+// keys, e.g. for scans and backfills, rather than single keys. It returns nil if n <= 0
+// or the hash is empty.
+func (this *Hash) Ranges(n int) []Range {
+	if this == nil || n <= 0 {
+		return nil
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	if len(this.nodes) == 0 {
+		return nil
+	}
+
+	a := make([]Range, n)
+	for i := range a {
+		a[i].Start = rangeStart(i, n)
+		if i+1 < n {
+			a[i].End = rangeStart(i+1, n) - 1
+		} else {
+			a[i].End = math.MaxUint64
+		}
+		a[i].Node = this.get(PartitionKey(i))
+	}
+	return a
+}
+
+// 第i个区间的起点: floor(i * 2^64 / n)
+func rangeStart(i, n int) uint64 {
+	q, _ := bits.Div64(uint64(i), 0, uint64(n))
+	return q
+}
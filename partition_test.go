@@ -0,0 +1,99 @@
+package doublejump
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHash_AssignPartitions(t *testing.T) {
+	h := NewHash()
+	if h.AssignPartitions(10) != nil {
+		t.Fatal("an empty hash should assign no partitions")
+	}
+
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+	if h.AssignPartitions(0) != nil {
+		t.Fatal("h.AssignPartitions(0) != nil")
+	}
+
+	const n = 1000
+	m1 := h.AssignPartitions(n)
+	if len(m1) != n {
+		t.Fatalf("len(m1) != %d. len: %d", n, len(m1))
+	}
+	counts := make(map[interface{}]int)
+	for p, obj := range m1 {
+		if obj != h.Get(PartitionKey(p)) {
+			t.Fatalf("partition %d is not owned by h.Get(PartitionKey(%d))", p, p)
+		}
+		counts[obj]++
+	}
+	if len(counts) != 10 {
+		t.Fatalf("len(counts) != 10. len: %d", len(counts))
+	}
+	for obj, c := range counts {
+		if c < n/10/2 || c > n/10*2 {
+			t.Fatalf("the partitions are not balanced. obj: %v, count: %d", obj, c)
+		}
+	}
+
+	// 删除节点时只有该节点的分区变动
+	h.Remove(3)
+	m2 := h.AssignPartitions(n)
+	for p := 0; p < n; p++ {
+		if m1[p] != 3 && m1[p] != m2[p] {
+			t.Fatalf("partition %d moved from %v to %v", p, m1[p], m2[p])
+		}
+		if m2[p] == 3 {
+			t.Fatalf("partition %d is still owned by the removed node", p)
+		}
+	}
+}
+
+func TestHash_Ranges(t *testing.T) {
+	h := NewHash()
+	if h.Ranges(4) != nil {
+		t.Fatal("an empty hash should have no range")
+	}
+	for i := 0; i < 5; i++ {
+		h.Add(i)
+	}
+	if h.Ranges(0) != nil {
+		t.Fatal("h.Ranges(0) != nil")
+	}
+
+	a := h.Ranges(4)
+	want := []Range{
+		{Start: 0, End: 1<<62 - 1},
+		{Start: 1 << 62, End: 1<<63 - 1},
+		{Start: 1 << 63, End: 3<<62 - 1},
+		{Start: 3 << 62, End: math.MaxUint64},
+	}
+	owners := h.AssignPartitions(4)
+	for i, r := range a {
+		if r.Start != want[i].Start || r.End != want[i].End {
+			t.Fatalf("unexpected range %d: [%d, %d]", i, r.Start, r.End)
+		}
+		if r.Node != owners[i] {
+			t.Fatalf("range %d should be owned like partition %d", i, i)
+		}
+	}
+
+	// 区间首尾相接覆盖全部KEY
+	for _, n := range []int{1, 3, 7, 1000} {
+		a := h.Ranges(n)
+		if len(a) != n || a[0].Start != 0 || a[n-1].End != math.MaxUint64 {
+			t.Fatalf("the ranges should cover the keyspace. n: %d", n)
+		}
+		for i := 1; i < n; i++ {
+			if a[i].Start != a[i-1].End+1 {
+				t.Fatalf("the ranges should be contiguous. n: %d, i: %d", n, i)
+			}
+		}
+	}
+	if !a[1].Contains(1<<62) || a[1].Contains(1<<63) {
+		t.Fatal("unexpected Contains")
+	}
+}
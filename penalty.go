@@ -0,0 +1,106 @@
+package doublejump
+
+import "time"
+
+// Penalize excludes the object from selection for d, e.g. after errors, without changing
+// the membership: like Drain, Get deterministically moves its keys to the next candidates,
+// and the object takes back exactly the same keys when it is reinstated automatically
+// after d. Penalizing an object again restarts its window. If all objects are excluded,
+// Get still returns the selected one. It returns false if the object is not in the hash.
+// Since the reinstatement happens on its own goroutine, it always returns false for a hash
+// created by NewHashWithoutLock.
+func (this *Hash) Penalize(obj interface{}, d time.Duration) bool {
+	if this == nil || obj == nil || !this.lock {
+		return false
+	}
+
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	id := this.id(obj)
+	n, ok := this.nodes[id]
+	if !ok {
+		return false
+	}
+	if n.reinstate == nil {
+		this.penalized++
+	} else {
+		n.reinstate()
+	}
+
+	this.armPenalty(id, n, now().Add(d))
+	this.retire()
+	return true
+}
+
+// 安排在until时恢复节点，调用方持有写锁
+func (this *Hash) armPenalty(id interface{}, n *node, until time.Time) {
+	// 窗口重新开始时旧的定时器可能已经触发，用序号区分
+	n.penalty++
+	seq := n.penalty
+	n.until = until
+	n.reinstate = afterFunc(until.Sub(now()), func() {
+		this.reinstate(id, seq)
+	})
+}
+
+// 节点换到了这个哈希，让恢复的定时器作用于它。不加锁的哈希不能在定时器中修改，
+// 直接恢复节点，调用方持有写锁
+func (this *Hash) rearmPenalties() {
+	if this.penalized == 0 {
+		return
+	}
+	for id, n := range this.nodes {
+		if n.reinstate == nil {
+			continue
+		}
+		n.reinstate()
+		if this.lock {
+			this.armPenalty(id, n, n.until)
+		} else {
+			n.reinstate = nil
+			this.penalized--
+		}
+	}
+	if !this.lock {
+		this.retire()
+	}
+}
+
+// Penalized reports whether the object is excluded from selection by Penalize.
+func (this *Hash) Penalized(obj interface{}) bool {
+	if this == nil || obj == nil {
+		return false
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	n, ok := this.nodes[this.id(obj)]
+	return ok && n.reinstate != nil
+}
+
+func (this *Hash) reinstate(id interface{}, seq uint64) {
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	n, ok := this.nodes[id]
+	if !ok || n.reinstate == nil || n.penalty != seq {
+		return
+	}
+	n.reinstate = nil
+	this.penalized--
+	this.retire()
+}
@@ -0,0 +1,73 @@
+package doublejump
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHash_Penalize(t *testing.T) {
+	var fs []func()
+	afterFunc = func(d time.Duration, f func()) func() bool {
+		i := len(fs)
+		fs = append(fs, f)
+		return func() bool {
+			if fs[i] == nil {
+				return false
+			}
+			fs[i] = nil
+			return true
+		}
+	}
+	defer func() { afterFunc = func(d time.Duration, f func()) func() bool { return time.AfterFunc(d, f).Stop } }()
+
+	h := NewHash()
+	for i := 0; i < 5; i++ {
+		h.Add(i)
+	}
+	before := make([]interface{}, 1000)
+	for i := range before {
+		before[i] = h.Get(uint64(i))
+	}
+
+	if h.Penalize(9, time.Second) {
+		t.Fatal("h.Penalize(9) should return false")
+	}
+	if !h.Penalize(2, time.Second) || !h.Penalized(2) {
+		t.Fatal("2 should be penalized")
+	}
+	gen := h.Generation()
+	for i, obj := range before {
+		g := h.Get(uint64(i))
+		if g == 2 || obj != 2 && g != obj {
+			t.Fatalf("only the keys of 2 should move. key: %d, before: %v, now: %v", i, obj, g)
+		}
+	}
+
+	// 再次惩罚重新开始窗口，旧的定时器被取消
+	h.Penalize(2, time.Second)
+	if fs[0] != nil || len(fs) != 2 {
+		t.Fatal("the old timer should be stopped")
+	}
+	fs[1]()
+	if h.Penalized(2) || h.Generation() != gen {
+		t.Fatal("2 should be reinstated without membership change")
+	}
+	for i, obj := range before {
+		if h.Get(uint64(i)) != obj {
+			t.Fatal("2 should take back its keys")
+		}
+	}
+
+	h.Penalize(3, time.Second)
+	h.Remove(3)
+	if fs[2] != nil || h.penalized != 0 {
+		t.Fatal("removing a penalized node should stop its timer")
+	}
+
+	// 不加锁的哈希不能在定时器中恢复节点
+	h2 := NewHashWithoutLock()
+	h2.Add(1)
+	if h2.Penalize(1, time.Second) || h2.Penalized(1) || len(fs) != 3 {
+		t.Fatal("an unlocked hash should refuse Penalize")
+	}
+}
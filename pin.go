@@ -0,0 +1,64 @@
+package doublejump
+
+// Pin forces the key to be mapped to obj regardless of the hash. The pin only takes
+// effect while obj is in the hash; otherwise Get falls back to the normal selection.
+// It returns false if the hash is frozen.
+func (this *Hash) Pin(key uint64, obj interface{}) bool {
+	if this == nil || obj == nil {
+		return false
+	}
+
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	if this.frozen {
+		return false
+	}
+	if this.pins == nil {
+		this.pins = make(map[uint64]interface{})
+	}
+	this.pins[key] = this.id(obj)
+	this.changed()
+	return true
+}
+
+// Unpin removes the pin of the key. It reports whether the key was pinned, and returns
+// false if the hash is frozen.
+func (this *Hash) Unpin(key uint64) bool {
+	if this == nil {
+		return false
+	}
+
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	if this.frozen {
+		return false
+	}
+	if _, ok := this.pins[key]; !ok {
+		return false
+	}
+	delete(this.pins, key)
+	this.changed()
+	return true
+}
+
+// 返回被固定节点的标识，节点已经删除时视为未固定
+func (this *Hash) pinned(key uint64) (interface{}, bool) {
+	id, ok := this.pins[key]
+	if !ok {
+		return nil, false
+	}
+	if _, ok := this.nodes[id]; !ok {
+		return nil, false
+	}
+	return id, true
+}
@@ -0,0 +1,45 @@
+package doublejump
+
+import "testing"
+
+func TestHash_Pin(t *testing.T) {
+	h := NewHash()
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+
+	key := uint64(1000)
+	obj := h.Get(key).(int)
+	target := (obj + 1) % 10
+
+	if !h.Pin(key, target) {
+		t.Fatal("h.Pin should succeed")
+	}
+	if h.Get(key) != target {
+		t.Fatalf("h.Get(key) should return the pinned node. obj: %v, target: %d", h.Get(key), target)
+	}
+
+	h.Remove(target)
+	if h.Get(key) == target {
+		t.Fatal("h.Get(key) should not return a removed pinned node")
+	}
+
+	h.Add(target)
+	if h.Get(key) != target {
+		t.Fatal("the pin should take effect again after the node is re-added")
+	}
+
+	// 冻结的哈希不改变路由
+	h.Freeze()
+	if h.Unpin(key) || h.Pin(key+1, target) || h.Get(key) != target {
+		t.Fatal("a frozen hash should reject Pin and Unpin")
+	}
+	h.Thaw()
+
+	if !h.Unpin(key) || h.Unpin(key) {
+		t.Fatal("h.Unpin should report whether the key was pinned")
+	}
+	if h.Get(key) != obj {
+		t.Fatalf("h.Get(key) should fall back to the hash after Unpin. obj: %v, want: %d", h.Get(key), obj)
+	}
+}
@@ -0,0 +1,49 @@
+package doublejump
+
+import "sync"
+
+// 复用临时数组，避免周期性的Shrink等操作产生大量分配
+type slicePool struct {
+	p sync.Pool
+}
+
+// WithPool makes the hash pool the slices created by Shrink, Nodes and BulkGet. The slices
+// returned by Nodes and BulkGet can be handed back by Recycle once the caller is done with them.
+func WithPool() Option {
+	return func(h *Hash) {
+		h.pool = &slicePool{}
+	}
+}
+
+// 返回长度为0、容量至少为n的数组，未设置WithPool时直接分配
+func (this *slicePool) get(n int) []interface{} {
+	if this != nil {
+		if v := this.p.Get(); v != nil {
+			if a := *v.(*[]interface{}); cap(a) >= n {
+				return a[:0]
+			}
+		}
+	}
+	return make([]interface{}, 0, n)
+}
+
+func (this *slicePool) put(a []interface{}) {
+	if this == nil || cap(a) == 0 {
+		return
+	}
+
+	a = a[:cap(a)]
+	for i := range a {
+		a[i] = nil
+	}
+	a = a[:0]
+	this.p.Put(&a)
+}
+
+// Recycle hands a slice returned by Nodes or BulkGet back to the pool of the hash, see WithPool.
+// The slice must not be used afterwards. It does nothing without WithPool.
+func (this *Hash) Recycle(a []interface{}) {
+	if this != nil {
+		this.pool.put(a)
+	}
+}
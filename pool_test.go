@@ -0,0 +1,51 @@
+package doublejump
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestHash_WithPool(t *testing.T) {
+	h := NewHash(WithPool())
+	h0 := NewHash()
+	for i := 0; i < 100; i++ {
+		h.Add(i)
+		h0.Add(i)
+	}
+
+	for loop := 0; loop < 5; loop++ {
+		for i := loop; i < 100; i += 7 {
+			h.Remove(i)
+			h0.Remove(i)
+		}
+		h.Shrink()
+		h0.Shrink()
+		always(h, t)
+		if !h.EqualLayout(h0) {
+			t.Fatal("Shrink with pool should be the same as without pool")
+		}
+
+		nodes := h.Nodes()
+		if fmt.Sprint(nodes) != fmt.Sprint(h0.Nodes()) {
+			t.Fatal("Nodes with pool should be the same as without pool")
+		}
+		h.Recycle(nodes)
+
+		keys := []uint64{1, 2, 3, 1000}
+		a := h.BulkGet(keys)
+		if fmt.Sprint(a) != fmt.Sprint(h0.BulkGet(keys)) {
+			t.Fatal("BulkGet with pool should be the same as without pool")
+		}
+		h.Recycle(a)
+
+		for i := loop; i < 100; i += 7 {
+			h.Add(i)
+			h0.Add(i)
+		}
+	}
+
+	// 未设置WithPool时Recycle什么都不做
+	h0.Recycle(h0.Nodes())
+	var h2 *Hash
+	h2.Recycle(nil)
+}
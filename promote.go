@@ -0,0 +1,117 @@
+package doublejump
+
+import "unsafe"
+
+// Promote swaps the routing state of the hash with the one of standby in a single step
+// under both locks, so that a large topology rebuild can be prepared off to the side, e.g.
+// by hundreds of Adds on standby, then cut over with a pause of a few pointer swaps instead
+// of holding the lock of the hash through the rebuild. Afterwards standby holds the former
+// state, so promoting it again rolls the change back.
+// The state is the objects with everything set on them, the slots and the pins. Hooks such
+// as WithLoad, subscriptions and statistics stay with each hash, and standby must be created
+// with the same options, since they decide the layout of the slots. Both hashes move past
+// the generations of either, and the subscribers are told the objects the swap removed and
+// added, each in the order of Nodes. Penalties move along with their objects, while drains
+// in progress by DrainOver keep acting on the hash they were started on. The quota usage of
+// tenant hashes moves along too. Promote returns ErrFrozen if either hash is frozen, or
+// ErrQuotaExceeded if the swap would take a tenant beyond its MaxNodes.
+func (this *Hash) Promote(standby *Hash) error {
+	if this == nil || standby == nil {
+		return ErrNilHash
+	}
+	if this == standby {
+		return nil
+	}
+
+	// 按地址的顺序加锁，避免相互提升时死锁
+	first, second := this, standby
+	if uintptr(unsafe.Pointer(second)) < uintptr(unsafe.Pointer(first)) {
+		first, second = second, first
+	}
+	first.mu.Lock()
+	defer first.mu.Unlock()
+	first.race.lockWrite()
+	defer first.race.unlockWrite()
+	second.mu.Lock()
+	defer second.mu.Unlock()
+	second.race.lockWrite()
+	defer second.race.unlockWrite()
+
+	if this.frozen || standby.frozen {
+		return ErrFrozen
+	}
+
+	// 节点在两个哈希之间移动，租户的用量随之转移
+	delta := len(standby.nodes) - len(this.nodes)
+	if this.usage != standby.usage {
+		if this.usage != nil && !this.usage.adjust(delta) {
+			return ErrQuotaExceeded
+		}
+		if standby.usage != nil && !standby.usage.adjust(-delta) {
+			if this.usage != nil {
+				this.usage.adjust(-delta)
+			}
+			return ErrQuotaExceeded
+		}
+	}
+
+	thisBefore, standbyBefore := this.nodes, standby.nodes
+	thisSlots, standbySlots := this.slotOwners(), standby.slotOwners()
+	thisLoose, standbyLoose := this.loose.a, standby.loose.a
+
+	this.loose, standby.loose = standby.loose, this.loose
+	this.compact, standby.compact = standby.compact, this.compact
+	this.pins, standby.pins = standby.pins, this.pins
+	this.nodes, standby.nodes = standby.nodes, this.nodes
+	this.capped, standby.capped = standby.capped, this.capped
+	this.drained, standby.drained = standby.drained, this.drained
+	this.canaries, standby.canaries = standby.canaries, this.canaries
+	this.breakers, standby.breakers = standby.breakers, this.breakers
+	this.penalized, standby.penalized = standby.penalized, this.penalized
+
+	gen := this.gen
+	if standby.gen > gen {
+		gen = standby.gen
+	}
+	this.rearmPenalties()
+	standby.rearmPenalties()
+	this.promoted(gen, thisBefore, thisLoose, thisSlots)
+	standby.promoted(gen, standbyBefore, standbyLoose, standbySlots)
+	return nil
+}
+
+// 换入新的状态之后更新代数并通知订阅者，调用方持有写锁
+// 事件按位置的顺序发出，和Nodes一致，loose是换出的位置
+func (this *Hash) promoted(gen uint64, before map[interface{}]*node, loose []interface{}, slots []interface{}) {
+	// 原来的位置在新的布局中没有意义
+	if this.removed != nil {
+		this.removed.shrunk()
+	}
+	this.gen = gen
+	this.changed()
+
+	if len(this.listeners) > 0 {
+		for _, slot := range loose {
+			if !isPrimary(slot) {
+				continue
+			}
+			if n, ok := before[slot]; ok && this.nodes[slot] == nil {
+				this.emit(Event{Type: EventRemove, Node: n.obj})
+			}
+		}
+		for _, slot := range this.loose.a {
+			if !isPrimary(slot) {
+				continue
+			}
+			if n, ok := this.nodes[slot]; ok && before[slot] == nil {
+				this.emit(Event{Type: EventAdd, Node: n.obj})
+			}
+		}
+	}
+	if slots != nil {
+		for len(slots) < len(this.loose.a) {
+			slots = append(slots, nil)
+		}
+		this.emitSlots(slots)
+	}
+}
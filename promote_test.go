@@ -0,0 +1,203 @@
+package doublejump
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHash_Promote(t *testing.T) {
+	h := NewHash()
+	for i := 0; i < 5; i++ {
+		h.Add(i)
+	}
+	gen := h.Generation()
+
+	standby := NewHash()
+	for i := 3; i < 100; i++ {
+		standby.Add(i)
+	}
+	standby.Remove(50)
+	standby.Drain(60)
+	want := NewHash()
+	for i := 3; i < 100; i++ {
+		want.Add(i)
+	}
+	want.Remove(50)
+	want.Drain(60)
+
+	var adds, removes int
+	h.Subscribe(func(ev Event) {
+		switch ev.Type {
+		case EventAdd:
+			adds++
+		case EventRemove:
+			removes++
+		}
+	})
+	var slots int
+	h.SubscribeSlots(func(c SlotChange) { slots++ })
+
+	if err := h.Promote(standby); err != nil {
+		t.Fatal(err)
+	}
+	if h.Len() != 96 || !h.EqualLayout(want) || !h.Drained(60) {
+		t.Fatalf("the hash should hold the state of the standby. h.Len(): %d", h.Len())
+	}
+	for key := uint64(0); key < 1000; key++ {
+		if h.Get(key) != want.Get(key) {
+			t.Fatalf("key %d should go to %v", key, want.Get(key))
+		}
+	}
+	if standby.Len() != 5 || !standby.Contains(0) {
+		t.Fatal("the standby should hold the former state")
+	}
+	if h.Generation() <= gen || h.Generation() <= want.Generation() {
+		t.Fatal("the generation should move forward")
+	}
+	if adds != 94 || removes != 3 || slots == 0 {
+		t.Fatalf("adds: %d, removes: %d, slots: %d", adds, removes, slots)
+	}
+
+	// 再次提升回滚
+	if err := h.Promote(standby); err != nil || h.Len() != 5 || standby.Len() != 96 {
+		t.Fatal("promoting the standby again should roll back")
+	}
+
+	standby.Freeze()
+	if h.Promote(standby) != ErrFrozen {
+		t.Fatal("h.Promote() != ErrFrozen")
+	}
+	if h.Promote(nil) != ErrNilHash || h.Promote(h) != nil {
+		t.Fatal("unexpected result of promoting nil or the hash itself")
+	}
+}
+
+func TestHash_Promote_EventOrder(t *testing.T) {
+	for round := 0; round < 10; round++ {
+		h := NewHash()
+		for i := 0; i < 20; i++ {
+			h.Add(i)
+		}
+		h.Remove(7)
+		standby := NewHash()
+		for i := 10; i < 40; i++ {
+			standby.Add(i)
+		}
+		standby.Remove(25)
+
+		// 事件的顺序应该和Nodes一致
+		var wantRemoves, wantAdds []interface{}
+		for _, obj := range h.Nodes() {
+			if !standby.Contains(obj) {
+				wantRemoves = append(wantRemoves, obj)
+			}
+		}
+		for _, obj := range standby.Nodes() {
+			if !h.Contains(obj) {
+				wantAdds = append(wantAdds, obj)
+			}
+		}
+
+		var removes, adds []interface{}
+		h.Subscribe(func(ev Event) {
+			switch ev.Type {
+			case EventAdd:
+				adds = append(adds, ev.Node)
+			case EventRemove:
+				removes = append(removes, ev.Node)
+			}
+		})
+		if err := h.Promote(standby); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(removes, wantRemoves) || !reflect.DeepEqual(adds, wantAdds) {
+			t.Fatalf("removes: %v, adds: %v", removes, adds)
+		}
+	}
+}
+
+func TestHash_Promote_Penalty(t *testing.T) {
+	h := NewHash()
+	h.Add("a")
+	standby := NewHash()
+	standby.Add("b")
+	standby.Add("c")
+	standby.Penalize("b", 20*time.Millisecond)
+
+	if err := h.Promote(standby); err != nil {
+		t.Fatal(err)
+	}
+	if !h.Penalized("b") {
+		t.Fatal("the penalty should move along with b")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if h.Penalized("b") {
+		t.Fatal("b should be reinstated on the hash now owning it")
+	}
+
+	// 不加锁的哈希不保留惩罚，以免定时器修改它
+	h2 := NewHashWithoutLock()
+	standby2 := NewHash()
+	standby2.Add("b")
+	standby2.Penalize("b", time.Second)
+	if err := h2.Promote(standby2); err != nil {
+		t.Fatal(err)
+	}
+	if h2.Penalized("b") || h2.penalized != 0 {
+		t.Fatal("an unlocked hash should not keep the penalty")
+	}
+}
+
+func TestHash_Promote_Quota(t *testing.T) {
+	m := NewManager()
+	m.SetQuota("t1", Quota{MaxNodes: 3})
+	h, _ := m.TenantRing("t1", "a")
+	h.Add(0)
+
+	standby := NewHash()
+	for i := 0; i < 10; i++ {
+		standby.Add(i)
+	}
+	if err := h.Promote(standby); err != ErrQuotaExceeded || h.Len() != 1 {
+		t.Fatalf("err != ErrQuotaExceeded. err: %v", err)
+	}
+
+	standby.Remove(9)
+	for i := 3; i < 9; i++ {
+		standby.Remove(i)
+	}
+	if err := h.Promote(standby); err != nil || h.Len() != 3 {
+		t.Fatalf("err: %v, h.Len(): %d", err, h.Len())
+	}
+	if n := m.Tenants()["t1"].Nodes; n != 3 {
+		t.Fatalf("the usage should move along with the nodes. n: %d", n)
+	}
+	if h.Add(9) {
+		t.Fatal("the quota should be enforced after the promotion")
+	}
+	for i := 0; i < 3; i++ {
+		h.Remove(i)
+	}
+	if n := m.Tenants()["t1"].Nodes; n != 0 {
+		t.Fatalf("n != 0. n: %d", n)
+	}
+}
+
+func TestHash_Promote_Concurrent(t *testing.T) {
+	a, b := NewHash(), NewHash()
+	a.Add(1)
+	b.Add(2)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func() { defer wg.Done(); a.Promote(b) }()
+		go func() { defer wg.Done(); b.Promote(a) }()
+	}
+	wg.Wait()
+	if a.Len() != 1 || b.Len() != 1 {
+		t.Fatal("the hashes should still hold one node each")
+	}
+}
@@ -0,0 +1,325 @@
+package doublejump
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// 序列化格式的版本，见doublejump.proto
+const protoVersion = 1
+
+// protobuf的wire type
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var (
+	// ErrNotString is returned by ToProto when an object of the hash is not a string.
+	ErrNotString = errors.New("doublejump: node is not a string")
+	// ErrBadProto is returned by FromProto when the data is not a valid Ring message.
+	ErrBadProto = errors.New("doublejump: malformed ring message")
+)
+
+// ToProto encodes the routing state of the hash, i.e. its objects with their weights,
+// drained states and canary shares, the layout of its slots and its generation, as the
+// Ring message defined in doublejump.proto. The objects must be strings. Pins, capacities,
+// metadata and tombstones are not included.
+func (this *Hash) ToProto() ([]byte, error) {
+	if this == nil {
+		return nil, ErrNilHash
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	return this.toProto()
+}
+
+// ToProto encodes the routing state of the snapshot, see Hash.ToProto.
+func (this *Snapshot) ToProto() ([]byte, error) {
+	if this == nil {
+		return nil, ErrNilHash
+	}
+	return this.hash.ToProto()
+}
+
+// 调用方负责加锁
+func (this *Hash) toProto() ([]byte, error) {
+	s, err := this.state()
+	if err != nil {
+		return nil, err
+	}
+	return encodeProto(s), nil
+}
+
+// MarshalBinary encodes the state as the Ring message defined in doublejump.proto, the same
+// as ToProto of the hash it was taken from.
+func (this *State) MarshalBinary() ([]byte, error) {
+	return encodeProto(this), nil
+}
+
+// UnmarshalBinary decodes the Ring message produced by MarshalBinary or ToProto.
+func (this *State) UnmarshalBinary(data []byte) error {
+	s, err := decodeProto(data)
+	if err != nil {
+		return err
+	}
+	*this = *s
+	return nil
+}
+
+// 编码为Ring消息
+func encodeProto(s *State) []byte {
+	var b []byte
+	b = appendVarintField(b, 1, protoVersion)
+	b = appendVarintField(b, 2, s.Generation)
+	for _, n := range s.Nodes {
+		var m []byte
+		m = appendBytesField(m, 1, []byte(n.ID))
+		m = appendVarintField(m, 2, uint64(n.Weight))
+		if n.Drained {
+			m = appendVarintField(m, 3, 1)
+		}
+		if n.Share > 0 {
+			m = appendTag(m, 4, wireFixed64)
+			m = binary.LittleEndian.AppendUint64(m, math.Float64bits(n.Share))
+		}
+		b = appendBytesField(b, 3, m)
+	}
+
+	// loose中0表示空位置，其他为节点的下标加1
+	loose := make([]uint64, len(s.Loose))
+	looseReplicas := make([]uint64, len(s.Loose))
+	for i, v := range s.Loose {
+		loose[i] = uint64(v.Node + 1)
+		looseReplicas[i] = uint64(v.Replica)
+	}
+	compact := make([]uint64, len(s.Compact))
+	compactReplicas := make([]uint64, len(s.Compact))
+	for i, v := range s.Compact {
+		compact[i] = uint64(v.Node)
+		compactReplicas[i] = uint64(v.Replica)
+	}
+	empty := make([]uint64, len(s.EmptyPositions))
+	for i, idx := range s.EmptyPositions {
+		empty[i] = uint64(idx)
+	}
+
+	b = appendPackedField(b, 4, loose)
+	b = appendPackedField(b, 5, looseReplicas)
+	b = appendPackedField(b, 6, compact)
+	b = appendPackedField(b, 7, compactReplicas)
+	b = appendPackedField(b, 8, empty)
+	return b
+}
+
+// FromProto creates a new threadsafe hash with options from the Ring message produced by
+// ToProto, reproducing exactly the placement of the encoded hash, including its empty
+// slots and its generation.
+func FromProto(data []byte, opts ...Option) (*Hash, error) {
+	s, err := decodeProto(data)
+	if err != nil {
+		return nil, err
+	}
+	h, err := NewHashFromState(s, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadProto, err)
+	}
+	return h, nil
+}
+
+// 解码Ring消息
+func decodeProto(data []byte) (*State, error) {
+	var s State
+	var version uint64
+	var loose, looseReplicas, compact, compactReplicas, empty []uint64
+	err := walkProto(data, func(field int, wire int, v uint64, b []byte) error {
+		switch field {
+		case 1:
+			version = v
+		case 2:
+			s.Generation = v
+		case 3:
+			if wire != wireBytes {
+				return ErrBadProto
+			}
+			n, err := decodeNodeProto(b)
+			if err != nil {
+				return err
+			}
+			s.Nodes = append(s.Nodes, n)
+		case 4:
+			return appendRepeated(&loose, wire, v, b)
+		case 5:
+			return appendRepeated(&looseReplicas, wire, v, b)
+		case 6:
+			return appendRepeated(&compact, wire, v, b)
+		case 7:
+			return appendRepeated(&compactReplicas, wire, v, b)
+		case 8:
+			return appendRepeated(&empty, wire, v, b)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if version > protoVersion {
+		return nil, fmt.Errorf("doublejump: unsupported ring message version %d", version)
+	}
+	if len(loose) != len(looseReplicas) || len(compact) != len(compactReplicas) {
+		return nil, ErrBadProto
+	}
+
+	// 其他超出范围的值由NewHashFromState检查
+	s.Loose = make([]Slot, len(loose))
+	for i, v := range loose {
+		if v > uint64(len(s.Nodes)) {
+			return nil, ErrBadProto
+		}
+		s.Loose[i] = Slot{Node: int(v) - 1, Replica: int(looseReplicas[i])}
+	}
+	s.Compact = make([]Slot, len(compact))
+	for i, v := range compact {
+		if v >= uint64(len(s.Nodes)) {
+			return nil, ErrBadProto
+		}
+		s.Compact[i] = Slot{Node: int(v), Replica: int(compactReplicas[i])}
+	}
+	s.EmptyPositions = make([]int, len(empty))
+	for i, v := range empty {
+		s.EmptyPositions[i] = int(v)
+	}
+	return &s, nil
+}
+
+// 解码Node消息
+func decodeNodeProto(data []byte) (NodeState, error) {
+	var n NodeState
+	err := walkProto(data, func(field int, wire int, v uint64, b []byte) error {
+		switch field {
+		case 1:
+			if wire != wireBytes {
+				return ErrBadProto
+			}
+			n.ID = string(b)
+		case 2:
+			n.Weight = int(v)
+		case 3:
+			n.Drained = v != 0
+		case 4:
+			if wire != wireFixed64 {
+				return ErrBadProto
+			}
+			n.Share = math.Float64frombits(v)
+		}
+		return nil
+	})
+	return n, err
+}
+
+// 依次解析消息的字段，varint和定长字段的值在v中，长度前缀字段的内容在b中
+func walkProto(data []byte, f func(field int, wire int, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			return ErrBadProto
+		}
+		data = data[n:]
+		field, wire := int(tag>>3), int(tag&7)
+		if field <= 0 {
+			return ErrBadProto
+		}
+
+		var v uint64
+		var b []byte
+		switch wire {
+		case wireVarint:
+			if v, n = binary.Uvarint(data); n <= 0 {
+				return ErrBadProto
+			}
+			data = data[n:]
+		case wireFixed64:
+			if len(data) < 8 {
+				return ErrBadProto
+			}
+			v, data = binary.LittleEndian.Uint64(data), data[8:]
+		case wireFixed32:
+			if len(data) < 4 {
+				return ErrBadProto
+			}
+			v, data = uint64(binary.LittleEndian.Uint32(data)), data[4:]
+		case wireBytes:
+			l, n := binary.Uvarint(data)
+			if n <= 0 || l > uint64(len(data)-n) {
+				return ErrBadProto
+			}
+			b, data = data[n:n+int(l)], data[n+int(l):]
+		default:
+			return ErrBadProto
+		}
+
+		if err := f(field, wire, v, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 重复的数值字段可以是packed或者逐个编码的
+func appendRepeated(a *[]uint64, wire int, v uint64, b []byte) error {
+	switch wire {
+	case wireVarint:
+		*a = append(*a, v)
+	case wireBytes:
+		for len(b) > 0 {
+			x, n := binary.Uvarint(b)
+			if n <= 0 {
+				return ErrBadProto
+			}
+			*a = append(*a, x)
+			b = b[n:]
+		}
+	default:
+		return ErrBadProto
+	}
+	return nil
+}
+
+func appendTag(b []byte, field int, wire int) []byte {
+	return binary.AppendUvarint(b, uint64(field)<<3|uint64(wire))
+}
+
+func appendVarintField(b []byte, field int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = appendTag(b, field, wireVarint)
+	return binary.AppendUvarint(b, v)
+}
+
+func appendBytesField(b []byte, field int, v []byte) []byte {
+	b = appendTag(b, field, wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendPackedField(b []byte, field int, a []uint64) []byte {
+	if len(a) == 0 {
+		return b
+	}
+	var p []byte
+	for _, v := range a {
+		p = binary.AppendUvarint(p, v)
+	}
+	return appendBytesField(b, field, p)
+}
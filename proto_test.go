@@ -0,0 +1,109 @@
+package doublejump
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestHash_ToProto(t *testing.T) {
+	h := NewHash()
+	for _, s := range []string{"a", "b", "c", "d", "e"} {
+		h.Add(s)
+	}
+	h.addNode(Node{Value: "w", Weight: 3})
+	h.Remove("b")
+	h.Remove("d")
+	h.Drain("c")
+	h.AddCanary("f", 0.25)
+	h.Add("")
+
+	data, err := h.ToProto()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2, err := FromProto(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	always(h2, t)
+
+	if h2.Generation() != h.Generation() {
+		t.Fatal("h2.Generation() != h.Generation()")
+	}
+	if !h.EqualLayout(h2) {
+		t.Fatal("the layout should be restored")
+	}
+	if !h2.Drained("c") || h2.Share("f") != 0.25 {
+		t.Fatal("the drained states and the shares should be restored")
+	}
+	for key := uint64(0); key < 10000; key++ {
+		if h.Get(key) != h2.Get(key) {
+			t.Fatalf("h.Get(%d) != h2.Get(%d)", key, key)
+		}
+	}
+
+	// 之后的操作也得到相同的布局
+	h.Add("x")
+	h2.Add("x")
+	if !h.EqualLayout(h2) {
+		t.Fatal("the empty positions should be restored in order")
+	}
+
+	data2, _ := h2.ToProto()
+	data3, _ := h.ToProto()
+	if !bytes.Equal(data2, data3) {
+		t.Fatal("the encoding should be deterministic")
+	}
+
+	// 忽略未知的字段
+	unknown := appendBytesField(appendVarintField(data, 100, 7), 101, []byte("x"))
+	if _, err := FromProto(unknown); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewHashFromNodes(1).ToProto(); err != ErrNotString {
+		t.Fatal("err != ErrNotString")
+	}
+	var h0 *Hash
+	if _, err := h0.ToProto(); err != ErrNilHash {
+		t.Fatal("err != ErrNilHash")
+	}
+
+	empty, _ := NewHash().ToProto()
+	if h3, err := FromProto(empty); err != nil || h3.Len() != 0 {
+		t.Fatal("an empty hash should be restored")
+	}
+}
+
+func TestFromProto_Broken(t *testing.T) {
+	h := NewHashFromStrings([]string{"a", "b", "c"})
+	h.Remove("b")
+	data, _ := h.ToProto()
+
+	for i := 1; i < len(data); i++ {
+		if h2, err := FromProto(data[:i]); err == nil {
+			always(h2, t)
+		}
+	}
+
+	future := appendVarintField(nil, 1, protoVersion+1)
+	if _, err := FromProto(future); err == nil {
+		t.Fatal("a newer version should be rejected")
+	}
+
+	// 位置指向不存在的节点
+	bad := appendPackedField(appendBytesField(nil, 3, appendBytesField(nil, 1, []byte("a"))), 6, []uint64{5})
+	bad = appendPackedField(bad, 7, []uint64{0})
+	if _, err := FromProto(bad); !errors.Is(err, ErrBadProto) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// compact与loose不一致
+	bad = appendBytesField(nil, 3, appendBytesField(nil, 1, []byte("a")))
+	bad = appendPackedField(bad, 4, []uint64{1})
+	bad = appendPackedField(bad, 5, []uint64{0})
+	if _, err := FromProto(bad); !errors.Is(err, ErrBadProto) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package doublejump
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ErrQuotaExceeded is returned when a tenant of a Manager would exceed its quota.
+var ErrQuotaExceeded = errors.New("doublejump: tenant quota exceeded")
+
+// Quota limits the hashes a tenant of a Manager creates. Zero values mean no limit.
+type Quota struct {
+	// MaxRings limits the number of hashes of the tenant.
+	MaxRings int
+	// MaxNodes limits the total number of objects over all hashes of the tenant.
+	MaxNodes int
+}
+
+// TenantStats aggregates the hashes of a tenant of a Manager.
+type TenantStats struct {
+	Quota Quota
+	Rings int
+	Nodes int
+	// Rejected is the number of objects not added because of MaxNodes.
+	Rejected int64
+}
+
+// 租户的用量，节点数量在各个哈希加锁期间原子地修改，其余字段由Manager.mu保护
+type usage struct {
+	quota    Quota
+	rings    int
+	max      int64
+	nodes    int64
+	rejected int64
+}
+
+// 调用方持有哈希的写锁
+func (this *usage) acquire() bool {
+	for {
+		n := atomic.LoadInt64(&this.nodes)
+		if max := atomic.LoadInt64(&this.max); max > 0 && n >= max {
+			atomic.AddInt64(&this.rejected, 1)
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&this.nodes, n, n+1) {
+			return true
+		}
+	}
+}
+
+// 节点数量变化delta，增加后超过配额时不变并返回false，调用方持有哈希的写锁
+func (this *usage) adjust(delta int) bool {
+	for {
+		n := atomic.LoadInt64(&this.nodes)
+		if max := atomic.LoadInt64(&this.max); delta > 0 && max > 0 && n+int64(delta) > max {
+			atomic.AddInt64(&this.rejected, int64(delta))
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&this.nodes, n, n+int64(delta)) {
+			return true
+		}
+	}
+}
+
+func (this *usage) release(n int) {
+	atomic.AddInt64(&this.nodes, -int64(n))
+}
+
+// SetQuota sets the quota of the tenant, see TenantRing. Lowering it below the current usage
+// removes nothing, but rejects new hashes and objects until the usage drops.
+func (this *Manager) SetQuota(tenant string, q Quota) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	u := this.tenant(tenant)
+	u.quota = q
+	atomic.StoreInt64(&u.max, int64(q.MaxNodes))
+}
+
+// TenantRing is like Ring, but creates the hash on behalf of the tenant, under the name
+// tenant + "/" + name, and enforces the quota of the tenant: it returns ErrQuotaExceeded if
+// the tenant already has MaxRings hashes, and adding objects beyond MaxNodes over all hashes
+// of the tenant fails as if they were already in the hash, or with ErrQuotaExceeded through
+// Strict.Add. Deleting a hash releases its share of the quota.
+func (this *Manager) TenantRing(tenant, name string) (*Hash, error) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	name = tenant + "/" + name
+	if r, ok := this.rings[name]; ok {
+		return r.hash, nil
+	}
+
+	u := this.tenant(tenant)
+	if u.quota.MaxRings > 0 && u.rings >= u.quota.MaxRings {
+		return nil, ErrQuotaExceeded
+	}
+	u.rings++
+	return this.create(name, u).hash, nil
+}
+
+// Tenants returns the aggregated stats of all tenants with a quota or a hash by their names.
+func (this *Manager) Tenants() map[string]TenantStats {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	m := make(map[string]TenantStats, len(this.tenants))
+	for name, u := range this.tenants {
+		m[name] = TenantStats{
+			Quota:    u.quota,
+			Rings:    u.rings,
+			Nodes:    int(atomic.LoadInt64(&u.nodes)),
+			Rejected: atomic.LoadInt64(&u.rejected),
+		}
+	}
+	return m
+}
+
+// 调用方持有mu
+func (this *Manager) tenant(name string) *usage {
+	u, ok := this.tenants[name]
+	if !ok {
+		u = &usage{}
+		this.tenants[name] = u
+	}
+	return u
+}
+
+// 哈希从Manager中删除后不再计入租户的用量
+func (this *Hash) detach() {
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	if this.usage != nil {
+		this.usage.release(len(this.nodes))
+		this.usage = nil
+	}
+}
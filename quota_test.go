@@ -0,0 +1,99 @@
+package doublejump
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestManager_TenantRing(t *testing.T) {
+	m := NewManager()
+	m.SetQuota("t1", Quota{MaxRings: 2, MaxNodes: 3})
+
+	a, err := m.TenantRing("t1", "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := m.TenantRing("t1", "a"); b != a {
+		t.Fatal("TenantRing should return the same ring for the same name")
+	}
+	if h, ok := m.Lookup("t1/a"); !ok || h != a {
+		t.Fatal("the ring should be named after the tenant")
+	}
+	b, err := m.TenantRing("t1", "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.TenantRing("t1", "c"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("TenantRing should return ErrQuotaExceeded. err: %v", err)
+	}
+
+	a.Add(1)
+	a.Add(2)
+	b.Add(1)
+	if b.Add(2) || b.Len() != 1 {
+		t.Fatal("the quota of nodes should be enforced over all rings")
+	}
+	if err := b.Strict().Add(2); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("Strict.Add should return ErrQuotaExceeded. err: %v", err)
+	}
+	if err := b.Strict().Add(1); !errors.Is(err, ErrNodeExists) {
+		t.Fatalf("Strict.Add should return ErrNodeExists. err: %v", err)
+	}
+
+	s := m.Tenants()["t1"]
+	if s.Rings != 2 || s.Nodes != 3 || s.Rejected != 2 || s.Quota.MaxNodes != 3 {
+		t.Fatalf("m.Tenants() is wrong. stats: %+v", s)
+	}
+
+	a.Remove(1)
+	if !b.Add(2) {
+		t.Fatal("removing a node should release the quota")
+	}
+	m.Delete("t1/a")
+	if _, err := m.TenantRing("t1", "c"); err != nil {
+		t.Fatal(err)
+	}
+	if s := m.Tenants()["t1"]; s.Rings != 2 || s.Nodes != 2 {
+		t.Fatalf("deleting a ring should release the quota. stats: %+v", s)
+	}
+
+	// 没有设置配额的租户不受限制
+	c, _ := m.TenantRing("t2", "a")
+	for i := 0; i < 10; i++ {
+		c.Add(i)
+	}
+	if c.Len() != 10 {
+		t.Fatal("c.Len() != 10")
+	}
+}
+
+func TestManager_Ring_Tenant(t *testing.T) {
+	m := NewManager()
+	m.SetQuota("t1", Quota{MaxRings: 2, MaxNodes: 2})
+
+	// Ring与TenantRing和Apply一样计入租户的配额
+	a := m.Ring("t1/a")
+	if h, _ := m.TenantRing("t1", "a"); h != a {
+		t.Fatal("Ring and TenantRing should return the same ring")
+	}
+	a.Add(1)
+	a.Add(2)
+	if a.Add(3) || a.Len() != 2 {
+		t.Fatal("Ring should enforce the quota of nodes of the tenant")
+	}
+	if m.Ring("t1/b") == nil || m.Ring("t1/c") != nil {
+		t.Fatal("Ring should enforce the quota of rings of the tenant")
+	}
+	if s := m.Tenants()["t1"]; s.Rings != 2 || s.Nodes != 2 {
+		t.Fatalf("m.Tenants() is wrong. stats: %+v", s)
+	}
+
+	// 不是租户的前缀不受限制
+	b := m.Ring("other/a")
+	for i := 0; i < 5; i++ {
+		b.Add(i)
+	}
+	if b.Len() != 5 {
+		t.Fatal("b.Len() != 5")
+	}
+}
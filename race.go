@@ -0,0 +1,60 @@
+package doublejump
+
+import "sync/atomic"
+
+// 检测对哈希的并发访问: state为-1表示正在写，大于0表示正在读的数量
+type raceGuard struct {
+	state int32
+}
+
+// WithRaceCheck makes the methods of the hash panic when they run concurrently in a way
+// which is not allowed for a hash created by NewHashWithoutLock, i.e. a write together with
+// any other read or write, similar to the "concurrent map writes" check of the runtime.
+// The check is best-effort and costs a few atomic operations per call, so it is meant for
+// tests and debugging. It has no effect on a threadsafe hash other than the cost.
+func WithRaceCheck() Option {
+	return func(h *Hash) {
+		h.race = &raceGuard{}
+	}
+}
+
+func (this *raceGuard) lockWrite() {
+	if this == nil {
+		return
+	}
+	if !atomic.CompareAndSwapInt32(&this.state, 0, -1) {
+		if atomic.LoadInt32(&this.state) < 0 {
+			panic("doublejump: concurrent hash writes")
+		}
+		panic("doublejump: concurrent hash read and hash write")
+	}
+}
+
+func (this *raceGuard) unlockWrite() {
+	if this == nil {
+		return
+	}
+	atomic.StoreInt32(&this.state, 0)
+}
+
+func (this *raceGuard) lockRead() {
+	if this == nil {
+		return
+	}
+	for {
+		s := atomic.LoadInt32(&this.state)
+		if s < 0 {
+			panic("doublejump: concurrent hash read and hash write")
+		}
+		if atomic.CompareAndSwapInt32(&this.state, s, s+1) {
+			return
+		}
+	}
+}
+
+func (this *raceGuard) unlockRead() {
+	if this == nil {
+		return
+	}
+	atomic.AddInt32(&this.state, -1)
+}
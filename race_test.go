@@ -0,0 +1,71 @@
+package doublejump
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustPanic(t *testing.T, want string, f func()) {
+	t.Helper()
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("f should panic")
+		}
+		if s, _ := r.(string); !strings.Contains(s, want) {
+			t.Fatalf("unexpected panic: %v", r)
+		}
+	}()
+	f()
+}
+
+func TestHash_RaceCheck(t *testing.T) {
+	h := NewHashWithoutLock(WithRaceCheck())
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+	h.Remove(3)
+	h.Shrink()
+	if h.Get(1) == nil {
+		t.Fatal("h.Get(1) == nil")
+	}
+
+	// 模拟一个正在进行的写操作
+	gen := h.Generation()
+	h.race.lockWrite()
+	mustPanic(t, "concurrent hash writes", func() { h.Add(100) })
+	mustPanic(t, "concurrent hash read and hash write", func() { h.Get(1) })
+	mustPanic(t, "concurrent hash writes", func() { h.TryAdd(100) })
+	mustPanic(t, "concurrent hash writes", func() { h.TryRemove(1) })
+	mustPanic(t, "concurrent hash read and hash write", func() { h.TryGet(1) })
+	mustPanic(t, "concurrent hash writes", func() { h.Set([]interface{}{1}) })
+	mustPanic(t, "concurrent hash writes", func() { h.SetMeta(1, "meta") })
+	mustPanic(t, "concurrent hash writes", func() { h.Pin(1, 2) })
+	mustPanic(t, "concurrent hash writes", func() { h.Unpin(1) })
+	mustPanic(t, "concurrent hash writes", func() { h.SetCapacity(1, 10) })
+	mustPanic(t, "concurrent hash writes", func() { h.Drain(1) })
+	mustPanic(t, "concurrent hash writes", func() { h.SetShare(1, 0.5) })
+	mustPanic(t, "concurrent hash writes", func() { h.Subscribe(func(Event) {}) })
+	mustPanic(t, "concurrent hash writes", func() { h.Freeze() })
+	mustPanic(t, "concurrent hash read and hash write", func() { h.ReportLatency(1, time.Millisecond) })
+	mustPanic(t, "concurrent hash read and hash write", func() { h.GetN(1, 2) })
+	mustPanic(t, "concurrent hash read and hash write", func() { h.GetExcluding(1, 2) })
+	mustPanic(t, "concurrent hash read and hash write", func() { h.Weight(1) })
+	mustPanic(t, "concurrent hash read and hash write", func() { h.Meta(1) })
+	mustPanic(t, "concurrent hash read and hash write", func() { h.Len() })
+	mustPanic(t, "concurrent hash writes", func() { h.CompareAndSet(gen, []interface{}{1}) })
+	h.race.unlockWrite()
+
+	// 模拟一个正在进行的读操作
+	h.race.lockRead()
+	if h.Get(1) == nil {
+		t.Fatal("concurrent reads should be allowed")
+	}
+	mustPanic(t, "concurrent hash read and hash write", func() { h.Remove(1) })
+	h.race.unlockRead()
+
+	if !h.Add(100) {
+		t.Fatal("h.Add(100) should succeed after the other operations finished")
+	}
+}
@@ -0,0 +1,37 @@
+package doublejump
+
+import "errors"
+
+// ErrNotReady is returned when the hash holds fewer objects than required by WithMinNodes.
+var ErrNotReady = errors.New("doublejump: fewer nodes than the minimum membership")
+
+// WithMinNodes makes the hash select no object until it holds at least n objects, so that
+// during a cold start the first object which registers does not receive all the traffic.
+// Until then Get and the like return nil, as if the hash were empty, and Strict.Get returns
+// ErrNotReady. Removing objects below n makes the hash not ready again.
+func WithMinNodes(n int) Option {
+	return func(h *Hash) {
+		h.minNodes = n
+	}
+}
+
+// Ready reports whether the hash holds enough objects to select them, see WithMinNodes.
+func (this *Hash) Ready() bool {
+	if this == nil {
+		return false
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	return this.ready()
+}
+
+// 调用方负责加锁
+func (this *Hash) ready() bool {
+	return len(this.nodes) >= this.minNodes
+}
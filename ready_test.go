@@ -0,0 +1,43 @@
+package doublejump
+
+import "testing"
+
+func TestHash_Ready(t *testing.T) {
+	h := NewHash(WithMinNodes(3))
+	s := h.Strict()
+	if h.Ready() {
+		t.Fatal("h.Ready() should be false")
+	}
+	if _, err := s.Get(0); err != ErrEmpty {
+		t.Fatalf("err != ErrEmpty. err: %v", err)
+	}
+
+	h.Add("a")
+	h.Add("b")
+	if h.Ready() || h.Get(0) != nil || h.GetN(0, 1) != nil || h.GetWhere(0, func(interface{}) bool { return true }) != nil {
+		t.Fatal("the hash should not select any object before it is ready")
+	}
+	if _, err := s.Get(0); err != ErrNotReady {
+		t.Fatalf("err != ErrNotReady. err: %v", err)
+	}
+	snap := h.Snapshot()
+	defer snap.Release()
+
+	h.Add("c")
+	if !h.Ready() {
+		t.Fatal("h.Ready() should be true")
+	}
+	for key := uint64(0); key < 1000; key++ {
+		if h.Get(key) == nil {
+			t.Fatalf("h.Get(%d) == nil", key)
+		}
+	}
+	if snap.Get(0) != nil {
+		t.Fatal("the snapshot should stay not ready")
+	}
+
+	h.Remove("b")
+	if h.Ready() || h.Get(0) != nil {
+		t.Fatal("the hash should not be ready after Remove")
+	}
+}
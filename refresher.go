@@ -0,0 +1,32 @@
+package doublejump
+
+import (
+	"context"
+	"time"
+)
+
+// Refresher fetches the current membership from a discovery system, see Maintain.
+type Refresher interface {
+	Fetch(ctx context.Context) ([]Node, error)
+}
+
+// RefresherFunc adapts a function to a Refresher.
+type RefresherFunc func(ctx context.Context) ([]Node, error)
+
+// Fetch calls f.
+func (f RefresherFunc) Fetch(ctx context.Context) ([]Node, error) {
+	return f(ctx)
+}
+
+// 失败后的最大退避倍数
+const maxBackoff = 16
+
+// Maintain fetches the membership from r every interval and reconciles the hash to it by
+// SetNodes, until ctx is done, then it returns ctx.Err(). The intervals are jittered by up
+// to ±10%, so that many processes do not hit the discovery system at the same time.
+// When Fetch fails or returns no object, the hash keeps its membership and the interval
+// doubles up to 16 times, until a fetch succeeds again. To observe the errors, wrap r. It
+// returns ErrBadInterval at once if interval <= 0.
+func (this *Hash) Maintain(ctx context.Context, r Refresher, interval time.Duration) error {
+	return this.Run(ctx, MaintRefresher(r, interval))
+}
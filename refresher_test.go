@@ -0,0 +1,60 @@
+package doublejump
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHash_Maintain(t *testing.T) {
+	var mu sync.Mutex
+	var fetches []time.Time
+	results := []func() ([]Node, error){
+		func() ([]Node, error) { return []Node{{Value: "a"}, {Value: "b", Weight: 2}}, nil },
+		func() ([]Node, error) { return nil, errors.New("unavailable") },
+		func() ([]Node, error) { return nil, nil },
+		func() ([]Node, error) { return []Node{{Value: "b", Weight: 3, Meta: "m"}, {Value: "c"}}, nil },
+	}
+	done := make(chan struct{})
+	r := RefresherFunc(func(ctx context.Context) ([]Node, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		fetches = append(fetches, time.Now())
+		i := len(fetches) - 1
+		if i == len(results) {
+			close(done)
+		}
+		if i >= len(results) {
+			i = len(results) - 1
+		}
+		return results[i]()
+	})
+
+	h := NewHash()
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error)
+	go func() { errc <- h.Maintain(ctx, r, 10*time.Millisecond) }()
+
+	<-done
+	cancel()
+	if err := <-errc; err != context.Canceled {
+		t.Fatalf("err != context.Canceled. err: %v", err)
+	}
+
+	if h.Len() != 2 || h.Contains("a") || h.Weight("b") != 3 || h.Meta("b") != "m" || !h.Contains("c") {
+		t.Fatalf("the hash should be reconciled to the last membership. nodes: %v", h.Nodes())
+	}
+
+	// 失败后退避: 间隔依次约为20ms、40ms，成功后恢复为10ms
+	mu.Lock()
+	defer mu.Unlock()
+	gaps := make([]time.Duration, 0, len(fetches)-1)
+	for i := 1; i < len(fetches); i++ {
+		gaps = append(gaps, fetches[i].Sub(fetches[i-1]))
+	}
+	if gaps[1] < 18*time.Millisecond || gaps[2] < 36*time.Millisecond || gaps[3] > gaps[2] {
+		t.Fatalf("unexpected intervals: %v", gaps)
+	}
+}
@@ -0,0 +1,188 @@
+package doublejump
+
+import "time"
+
+// RemovedNode describes a recently removed object, see RecentlyRemoved.
+type RemovedNode struct {
+	// Node is the object with its weight and metadata at the time of removal.
+	Node Node
+	// Removed is the time of removal.
+	Removed time.Time
+	// Generation is the generation of the hash right after the removal.
+	Generation uint64
+	// Restorable tells whether all the former slots of the object are still vacant, so that
+	// Restore can put it back with exactly the same keys.
+	Restorable bool
+}
+
+// 被删除节点的记录，slots为各虚拟位置在looseHolder中的位置，Shrink后为空
+type removal struct {
+	id      interface{}
+	node    Node
+	slots   []int
+	removed time.Time
+	gen     uint64
+}
+
+// 最近删除的节点，按删除的顺序排列
+type removedLog struct {
+	size    int
+	records []removal
+}
+
+// WithRecentlyRemoved makes the hash remember its last n removed objects and their former
+// slots, see RecentlyRemoved and Restore.
+func WithRecentlyRemoved(n int) Option {
+	return func(h *Hash) {
+		if n > 0 {
+			h.removed = &removedLog{size: n}
+		}
+	}
+}
+
+// 记录被删除的节点，在删除虚拟位置之前调用
+func (this *removedLog) record(h *Hash, id interface{}, n *node) {
+	slots := make([]int, n.weight)
+	for i := range slots {
+		slots[i] = h.loose.m[slotOf(id, i)]
+	}
+	if len(this.records) == this.size {
+		copy(this.records, this.records[1:])
+		this.records = this.records[:len(this.records)-1]
+	}
+	this.records = append(this.records, removal{
+		id:      id,
+		node:    Node{Value: n.obj, Weight: n.weight, Meta: n.meta},
+		slots:   slots,
+		removed: now(),
+		gen:     h.gen + 1, // 删除后changed()使代数加1
+	})
+}
+
+// 节点重新加入哈希后不再需要恢复
+func (this *removedLog) forget(id interface{}) {
+	for i := range this.records {
+		if this.records[i].id == id {
+			this.records = append(this.records[:i], this.records[i+1:]...)
+			return
+		}
+	}
+}
+
+// Shrink后原来的位置不再有意义
+func (this *removedLog) shrunk() {
+	for i := range this.records {
+		this.records[i].slots = nil
+	}
+}
+
+// RecentlyRemoved returns the recently removed objects which have not been added back,
+// the latest first. It needs WithRecentlyRemoved.
+func (this *Hash) RecentlyRemoved() []RemovedNode {
+	if this == nil {
+		return nil
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	if this.removed == nil {
+		return nil
+	}
+	a := make([]RemovedNode, 0, len(this.removed.records))
+	for i := len(this.removed.records) - 1; i >= 0; i-- {
+		r := &this.removed.records[i]
+		a = append(a, RemovedNode{
+			Node:       r.node,
+			Removed:    r.removed,
+			Generation: r.gen,
+			Restorable: this.restorable(r),
+		})
+	}
+	return a
+}
+
+// Restore adds a recently removed object back into its former slots, so that it takes back
+// exactly the keys it had, e.g. to undo an accidental removal. It reports whether the object
+// was restored, which it is not if it is not among RecentlyRemoved, or if any of its former
+// slots has been taken by another object or dropped by Shrink since.
+func (this *Hash) Restore(obj interface{}) bool {
+	if this == nil || obj == nil {
+		return false
+	}
+
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	if this.frozen || this.removed == nil {
+		return false
+	}
+	id := this.id(obj)
+	var r *removal
+	for i := range this.removed.records {
+		if this.removed.records[i].id == id {
+			r = &this.removed.records[i]
+		}
+	}
+	if r == nil || !this.restorable(r) {
+		return false
+	}
+	if this.usage != nil && !this.usage.acquire() {
+		return false
+	}
+
+	for i, idx := range r.slots {
+		slot := slotOf(id, i)
+		this.loose.put(slot, idx)
+		this.compact.add(slot)
+	}
+	n := r.node
+	this.nodes[id] = &node{obj: n.Value, weight: n.Weight, meta: n.Meta, latency: new(ewma)}
+	this.removed.forget(id)
+	this.changed()
+	this.emit(Event{Type: EventAdd, Node: n.Value})
+	return true
+}
+
+// 调用方负责加锁
+func (this *Hash) restorable(r *removal) bool {
+	if r.slots == nil {
+		return false
+	}
+	for i, idx := range r.slots {
+		if !this.loose.vacant(slotOf(r.id, i), idx) {
+			return false
+		}
+	}
+	return true
+}
+
+// 位置是否为空，并且没有在宽限期内保留给其他节点
+func (this *looseHolder) vacant(obj interface{}, idx int) bool {
+	if idx >= len(this.a) || this.a[idx] != nil {
+		return false
+	}
+	if o, ok := this.reserved[idx]; ok && o != obj && now().Before(this.tombs[o].until) {
+		return false
+	}
+	return true
+}
+
+// 将节点放到指定的空位置
+func (this *looseHolder) put(obj interface{}, idx int) {
+	this.takeEmpty(idx)
+	if o, ok := this.reserved[idx]; ok {
+		delete(this.reserved, idx)
+		delete(this.tombs, o)
+	}
+	this.a[idx] = obj
+	this.m[obj] = idx
+}
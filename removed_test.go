@@ -0,0 +1,70 @@
+package doublejump
+
+import "testing"
+
+func TestHash_Restore(t *testing.T) {
+	h := NewHash(WithRecentlyRemoved(2))
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+	h.AddWeighted("w", 3)
+	w := h.Nodes()
+
+	before := make([]interface{}, 1000)
+	for key := range before {
+		before[key] = h.Get(uint64(key))
+	}
+
+	h.Remove(1)
+	h.Remove("w")
+	h.Remove(2)
+	a := h.RecentlyRemoved()
+	if len(a) != 2 || a[0].Node.Value != 2 || a[1].Node.Value != "w" || a[1].Node.Weight != 3 {
+		t.Fatalf("unexpected removed nodes: %+v", a)
+	}
+	if a[0].Generation != h.Generation() || !a[0].Restorable || !a[1].Restorable {
+		t.Fatalf("unexpected removed nodes: %+v", a)
+	}
+
+	if h.Restore(1) {
+		t.Fatal("1 should be dropped from the bounded history")
+	}
+	if !h.Restore("w") || !h.Restore(2) || h.Restore(2) {
+		t.Fatal("the removed nodes should be restored once")
+	}
+	if h.Weight("w") != 3 || len(h.RecentlyRemoved()) != 0 {
+		t.Fatalf("unexpected state after restoring: %v", h.Nodes())
+	}
+
+	// 1的位置没有恢复，其余KEY都回到原来的节点
+	for key, obj := range before {
+		if obj != 1 && h.Get(uint64(key)) != obj {
+			t.Fatalf("key %d should go back to %v, got %v", key, obj, h.Get(uint64(key)))
+		}
+	}
+	if h.Len() != len(w)-1 {
+		t.Fatalf("h.Len() != %d", len(w)-1)
+	}
+
+	// 位置被占用或者Shrink之后不能恢复
+	h.Remove(3)
+	h.Add(100)
+	if h.RecentlyRemoved()[0].Restorable || h.Restore(3) {
+		t.Fatal("3 should not be restored after its slot is taken")
+	}
+	h.Remove(4)
+	h.Shrink()
+	if h.RecentlyRemoved()[0].Restorable || h.Restore(4) {
+		t.Fatal("4 should not be restored after Shrink")
+	}
+
+	// 重新加入的节点不再出现在列表中
+	h.Add(4)
+	if a := h.RecentlyRemoved(); len(a) != 1 || a[0].Node.Value != 3 {
+		t.Fatalf("unexpected removed nodes: %+v", a)
+	}
+
+	if NewHash().RecentlyRemoved() != nil || NewHash().Restore(1) {
+		t.Fatal("nothing should be remembered without WithRecentlyRemoved")
+	}
+}
@@ -0,0 +1,63 @@
+package doublejump
+
+// Replace puts obj in place of old, in the same slots, so that exactly the keys of old move
+// to obj and no other key moves, e.g. when a machine is swapped for another. obj takes over
+// the weight of old, but not its metadata, capacity, drain state, breaker or the like.
+// Subscribers get EventRemove for old and EventAdd for obj, see SubscribeSlots for the
+// slots changing their owner. It reports whether old was replaced, which is
+// false if old is not in the hash or obj already is.
+func (this *Hash) Replace(old, obj interface{}) bool {
+	if this == nil || old == nil || obj == nil {
+		return false
+	}
+
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	return this.replace(old, obj)
+}
+
+// 调用方负责加锁
+func (this *Hash) replace(old, obj interface{}) bool {
+	if this.frozen {
+		return false
+	}
+	if this.normalize != nil {
+		obj = this.normalize(obj)
+	}
+	oldID, newID := this.id(old), this.keyOf(obj)
+	n, ok := this.nodes[oldID]
+	if !ok {
+		return false
+	}
+	if _, ok := this.nodes[newID]; ok {
+		return false
+	}
+
+	before := this.slotOwners()
+	for i := 0; i < n.weight; i++ {
+		from, to := slotOf(oldID, i), slotOf(newID, i)
+		if idx, ok := this.loose.m[from]; ok {
+			delete(this.loose.m, from)
+			this.loose.a[idx] = to
+			this.loose.m[to] = idx
+		}
+		if idx, ok := this.compact.m[from]; ok {
+			delete(this.compact.m, from)
+			this.compact.a[idx] = to
+			this.compact.m[to] = idx
+		}
+	}
+	this.forget(n)
+	delete(this.nodes, oldID)
+	this.nodes[newID] = &node{obj: obj, weight: n.weight, latency: new(ewma)}
+	this.changed()
+	this.emit(Event{Type: EventRemove, Node: n.obj})
+	this.emit(Event{Type: EventAdd, Node: obj})
+	this.emitSlots(before)
+	return true
+}
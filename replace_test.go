@@ -0,0 +1,110 @@
+package doublejump
+
+import "testing"
+
+func TestHash_Replace(t *testing.T) {
+	h := NewHash()
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+	h.AddWeighted(10, 3)
+	h.Remove(5)
+
+	before := make([]interface{}, 10000)
+	for i := range before {
+		before[i] = h.Get(uint64(i))
+	}
+
+	var events []Event
+	h.Subscribe(func(ev Event) {
+		events = append(events, ev)
+	})
+	var changes []SlotChange
+	h.SubscribeSlots(func(c SlotChange) {
+		changes = append(changes, c)
+	})
+	if h.Replace(1, 2) || h.Replace(5, 11) {
+		t.Fatal("Replace should fail if obj is in the hash or old is not")
+	}
+	if !h.Replace(10, 20) || !h.Replace(3, 30) {
+		t.Fatal("Replace should succeed")
+	}
+	if h.Contains(10) || !h.Contains(20) || h.Weight(20) != 3 || h.Len() != 10 {
+		t.Fatal("20 should take over the slots of 10")
+	}
+	always(h, t)
+
+	h.Drain(30)
+	if !h.Replace(30, 3) || h.Drained(3) || h.Replace(30, 3) {
+		t.Fatal("the replacement should not inherit the drain state")
+	}
+	h.Replace(3, 30)
+
+	for i, obj := range before {
+		want := obj
+		switch obj {
+		case 10:
+			want = 20
+		case 3:
+			want = 30
+		}
+		if g := h.Get(uint64(i)); g != want {
+			t.Fatalf("key %d should move from %v to %v. got: %v", i, obj, want, g)
+		}
+	}
+
+	// 四次替换，各自的EventRemove和EventAdd，以及3 + 1 + 1 + 1个位置的变化
+	if len(events) != 8 {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+	if len(changes) != 6 {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+	for i, c := range changes[:3] {
+		if c.Slot != 10+i || c.From != 10 || c.To != 20 || c.Generation != events[0].Generation {
+			t.Fatalf("unexpected change: %+v", c)
+		}
+	}
+	if c := changes[5]; c.Slot != 3 || c.From != 3 || c.To != 30 {
+		t.Fatalf("unexpected change: %+v", c)
+	}
+}
+
+func TestHash_SubscribeSlots(t *testing.T) {
+	h := NewHash()
+	for i := 0; i < 5; i++ {
+		h.Add(i)
+	}
+	h.Remove(1)
+
+	var changes []SlotChange
+	cancel := h.SubscribeSlots(func(c SlotChange) {
+		changes = append(changes, c)
+	})
+	h.Shrink()
+
+	// 位置1到4依次前移
+	if len(changes) != 4 {
+		t.Fatalf("len(changes) != 4. changes: %+v", changes)
+	}
+	for i, c := range changes {
+		slot := i + 1
+		var from, to interface{}
+		if slot > 1 {
+			from = slot
+		}
+		if slot < 4 {
+			to = slot + 1
+		}
+		if c.Slot != slot || c.From != from || c.To != to {
+			t.Fatalf("unexpected change: %+v", c)
+		}
+	}
+
+	cancel()
+	h.Remove(2)
+	h.Shrink()
+	if len(changes) != 4 {
+		t.Fatal("no change should be received after cancel")
+	}
+}
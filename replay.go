@@ -0,0 +1,105 @@
+package doublejump
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrBadOp is returned by Replay for an operation of an unknown type.
+var ErrBadOp = errors.New("doublejump: unknown operation")
+
+// OpType is the type of an operation of Replay.
+type OpType int
+
+const (
+	// OpAdd adds Op.Node.
+	OpAdd OpType = iota + 1
+	// OpRemove removes Op.Node.
+	OpRemove
+	// OpShrink removes the empty slots.
+	OpShrink
+	// OpSet reconciles the hash to Op.Nodes.
+	OpSet
+)
+
+func (this OpType) String() string {
+	switch this {
+	case OpAdd:
+		return "add"
+	case OpRemove:
+		return "remove"
+	case OpShrink:
+		return "shrink"
+	case OpSet:
+		return "set"
+	}
+	return "unknown"
+}
+
+// Op is a recorded mutation of the hash.
+type Op struct {
+	Type  OpType
+	Node  interface{}   // 用于OpAdd和OpRemove
+	Nodes []interface{} // 用于OpSet
+}
+
+// Replay applies the operations in order, in one critical section, as Add, Remove, Shrink
+// and Set would. Since the placement of the hash only depends on the sequence of its
+// mutations, replaying the mutation history of a hash on a new one reproduces its exact
+// state, which helps reproducing incidents and fuzzing. Set is applied at once even with
+// WithThrottle. Replay checks all operations before applying any: if one is invalid, it
+// returns ErrBadOp or ErrNilNode and changes nothing. It returns ErrFrozen if the hash is
+// frozen.
+func (this *Hash) Replay(ops []Op) error {
+	if this == nil {
+		return ErrNilHash
+	}
+
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	if this.frozen {
+		return ErrFrozen
+	}
+	for i, op := range ops {
+		if err := checkOp(op); err != nil {
+			return fmt.Errorf("doublejump: op %d: %w", i, err)
+		}
+	}
+	for _, op := range ops {
+		this.apply(op)
+	}
+	return nil
+}
+
+// 检查操作是否有效
+func checkOp(op Op) error {
+	switch op.Type {
+	case OpAdd, OpRemove:
+		if op.Node == nil {
+			return ErrNilNode
+		}
+	case OpShrink, OpSet:
+	default:
+		return ErrBadOp
+	}
+	return nil
+}
+
+// 执行有效的操作，调用方持有写锁
+func (this *Hash) apply(op Op) {
+	switch op.Type {
+	case OpAdd:
+		this.add(op.Node)
+	case OpRemove:
+		this.remove(op.Node)
+	case OpShrink:
+		this.shrink()
+	case OpSet:
+		this.set(op.Nodes)
+	}
+}
@@ -0,0 +1,100 @@
+package doublejump
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHash_Replay(t *testing.T) {
+	ops := []Op{
+		{Type: OpAdd, Node: "a"},
+		{Type: OpAdd, Node: "b"},
+		{Type: OpAdd, Node: "c"},
+		{Type: OpRemove, Node: "a"},
+		{Type: OpSet, Nodes: []interface{}{"b", "c", "d", "e"}},
+		{Type: OpRemove, Node: "c"},
+		{Type: OpShrink},
+		{Type: OpAdd, Node: "f"},
+	}
+
+	h := NewHash()
+	for _, op := range ops {
+		switch op.Type {
+		case OpAdd:
+			h.Add(op.Node)
+		case OpRemove:
+			h.Remove(op.Node)
+		case OpShrink:
+			h.Shrink()
+		case OpSet:
+			h.Set(op.Nodes)
+		}
+	}
+
+	h2 := NewHash()
+	if err := h2.Replay(ops); err != nil {
+		t.Fatal(err)
+	}
+	if !h.EqualLayout(h2) {
+		t.Fatal("the replayed hash should be equal to the original")
+	}
+
+	if err := h2.Replay([]Op{{Type: OpAdd, Node: "g"}, {Type: OpAdd}}); !errors.Is(err, ErrNilNode) {
+		t.Fatalf("Replay should return ErrNilNode. err: %v", err)
+	}
+	if h2.Contains("g") || !h.EqualLayout(h2) {
+		t.Fatal("a Replay with an invalid operation should change nothing")
+	}
+	if err := h2.Replay([]Op{{}}); !errors.Is(err, ErrBadOp) {
+		t.Fatalf("Replay should return ErrBadOp. err: %v", err)
+	}
+	h2.Freeze()
+	if err := h2.Replay(ops); err != ErrFrozen {
+		t.Fatalf("Replay should return ErrFrozen. err: %v", err)
+	}
+}
+
+// 每个字节解码为一个操作，替换为Set时取后面的字节作为节点
+func decodeOps(data []byte) []Op {
+	var ops []Op
+	for i := 0; i < len(data); i++ {
+		b := data[i]
+		switch b % 4 {
+		case 0:
+			ops = append(ops, Op{Type: OpAdd, Node: int(b / 4 % 16)})
+		case 1:
+			ops = append(ops, Op{Type: OpRemove, Node: int(b / 4 % 16)})
+		case 2:
+			ops = append(ops, Op{Type: OpShrink})
+		case 3:
+			var nodes []interface{}
+			for n := int(b / 4 % 8); n > 0 && i+1 < len(data); n-- {
+				i++
+				nodes = append(nodes, int(data[i]%16))
+			}
+			ops = append(ops, Op{Type: OpSet, Nodes: nodes})
+		}
+	}
+	return ops
+}
+
+func FuzzHash_Replay(f *testing.F) {
+	f.Add([]byte{0, 4, 8, 1, 2, 12})
+	f.Add([]byte{0, 4, 8, 12, 16, 5, 9, 31, 1, 2, 3, 4, 5, 6, 7, 2})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ops := decodeOps(data)
+		h := NewHashWithoutLock()
+		if err := h.Replay(ops); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.Validate(); err != nil {
+			t.Fatal(err)
+		}
+
+		h2 := NewHashWithoutLock()
+		h2.Replay(ops)
+		if !h.EqualLayout(h2) {
+			t.Fatal("Replay should be deterministic")
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package doublejump
+
+// WithReplication sets the replication factor of the hash, i.e. the number of objects
+// GetReplicas returns for a key. n < 1 means 1, which is the default.
+func WithReplication(n int) Option {
+	return func(h *Hash) {
+		if n < 1 {
+			n = 1
+		}
+		h.replicas = n
+	}
+}
+
+// Replication returns the replication factor of the hash, see WithReplication.
+func (this *Hash) Replication() int {
+	if this == nil || this.replicas < 1 {
+		return 1
+	}
+	return this.replicas
+}
+
+// GetReplicas returns as many distinct objects for the key as the replication factor,
+// the same as GetN(key, Replication()). It returns nil if the hash holds fewer objects
+// than the replication factor.
+func (this *Hash) GetReplicas(key uint64) []interface{} {
+	if this == nil {
+		return nil
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	return this.getN(key, this.Replication())
+}
+
+// GetQuorum returns n distinct objects for the key split into roles: primary is the first
+// object of GetN, the same as Get, and secondaries are the other n-1 in order, e.g. to write
+// to the primary and replicate to the secondaries. It returns nil, nil where GetN does.
+func (this *Hash) GetQuorum(key uint64, n int) (primary interface{}, secondaries []interface{}) {
+	a := this.GetN(key, n)
+	if a == nil {
+		return nil, nil
+	}
+	return a[0], a[1:]
+}
+
+// GetReplicas is like Hash.GetReplicas, but returns ErrEmpty if the hash has no object,
+// or ErrTooFewNodes if it holds fewer objects than the replication factor.
+func (this Strict) GetReplicas(key uint64) ([]interface{}, error) {
+	if this.hash == nil {
+		return nil, ErrNilHash
+	}
+
+	a := this.hash.GetReplicas(key)
+	if a == nil {
+		if this.hash.Len() == 0 {
+			return nil, ErrEmpty
+		}
+		return nil, ErrTooFewNodes
+	}
+	return a, nil
+}
@@ -0,0 +1,76 @@
+package doublejump
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHash_GetReplicas(t *testing.T) {
+	h := NewHash(WithReplication(3))
+	if h.Replication() != 3 {
+		t.Fatal("h.Replication() != 3")
+	}
+	if _, err := h.Strict().GetReplicas(1); err != ErrEmpty {
+		t.Fatal("err != ErrEmpty")
+	}
+
+	h.Add(1)
+	h.Add(2)
+	if h.GetReplicas(1) != nil {
+		t.Fatal("GetReplicas should return nil with fewer nodes than the replication factor")
+	}
+	if _, err := h.Strict().GetReplicas(1); err != ErrTooFewNodes {
+		t.Fatal("err != ErrTooFewNodes")
+	}
+
+	for i := 3; i <= 10; i++ {
+		h.Add(i)
+	}
+	for key := uint64(0); key < 100; key++ {
+		a := h.GetReplicas(key)
+		if !reflect.DeepEqual(a, h.GetN(key, 3)) {
+			t.Fatalf("h.GetReplicas(%d) != h.GetN(%d, 3)", key, key)
+		}
+		if b, err := h.Strict().GetReplicas(key); err != nil || !reflect.DeepEqual(a, b) {
+			t.Fatal("Strict().GetReplicas should be the same as GetReplicas")
+		}
+	}
+
+	s := h.Snapshot()
+	defer s.Release()
+	if len(s.GetReplicas(1)) != 3 {
+		t.Fatal("the snapshot should keep the replication factor")
+	}
+
+	if NewHash().Replication() != 1 || NewHash(WithReplication(-1)).Replication() != 1 {
+		t.Fatal("the default replication factor should be 1")
+	}
+}
+
+func TestHash_GetQuorum(t *testing.T) {
+	h := NewHash()
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+
+	for key := uint64(0); key < 100; key++ {
+		primary, secondaries := h.GetQuorum(key, 3)
+		a := h.GetN(key, 3)
+		if primary != h.Get(key) || primary != a[0] || !reflect.DeepEqual(secondaries, a[1:]) {
+			t.Fatalf("unexpected quorum of key %d: %v, %v", key, primary, secondaries)
+		}
+	}
+
+	if primary, secondaries := h.GetQuorum(1, 1); primary == nil || len(secondaries) != 0 {
+		t.Fatal("a quorum of 1 should have no secondary")
+	}
+	if primary, secondaries := h.GetQuorum(1, 11); primary != nil || secondaries != nil {
+		t.Fatal("GetQuorum should return nil with fewer nodes than n")
+	}
+
+	s := h.Snapshot()
+	defer s.Release()
+	if primary, _ := s.GetQuorum(1, 3); primary != h.Get(1) {
+		t.Fatal("the snapshot should return the same primary")
+	}
+}
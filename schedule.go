@@ -0,0 +1,22 @@
+package doublejump
+
+import "time"
+
+// 测试中可以替换，返回取消函数
+var afterFunc = func(d time.Duration, f func()) (stop func() bool) {
+	return time.AfterFunc(d, f).Stop
+}
+
+// RemoveAfter schedules the removal of obj after d, so dependent caches can pre-warm the
+// replacements before the keys actually move. The returned function cancels the removal
+// and reports whether it was cancelled before it happened. Since the removal happens on its
+// own goroutine, it does nothing on a hash created by NewHashWithoutLock.
+func (this *Hash) RemoveAfter(obj interface{}, d time.Duration) (cancel func() bool) {
+	if this == nil || obj == nil || !this.lock {
+		return func() bool { return false }
+	}
+
+	return afterFunc(d, func() {
+		this.Remove(obj)
+	})
+}
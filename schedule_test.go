@@ -0,0 +1,69 @@
+package doublejump
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHash_RemoveAfter(t *testing.T) {
+	var fs []func()
+	afterFunc = func(d time.Duration, f func()) func() bool {
+		i := len(fs)
+		fs = append(fs, f)
+		return func() bool {
+			if fs[i] == nil {
+				return false
+			}
+			fs[i] = nil
+			return true
+		}
+	}
+	defer func() { afterFunc = func(d time.Duration, f func()) func() bool { return time.AfterFunc(d, f).Stop } }()
+
+	h := NewHash()
+	h.Add(1)
+	h.Add(2)
+
+	h.RemoveAfter(1, time.Minute)
+	cancel := h.RemoveAfter(2, time.Minute)
+	if h.Len() != 2 {
+		t.Fatal("the removal should not happen before the time arrives")
+	}
+
+	if !cancel() || cancel() {
+		t.Fatal("cancel should report whether the removal was cancelled")
+	}
+	for _, f := range fs {
+		if f != nil {
+			f()
+		}
+	}
+	if h.Contains(1) || !h.Contains(2) {
+		t.Fatal("only the removal not cancelled should happen")
+	}
+
+	// 不加锁的哈希不能在定时器中删除节点
+	h2 := NewHashWithoutLock()
+	h2.Add(1)
+	if h2.RemoveAfter(1, time.Minute)() || len(fs) != 2 || !h2.Contains(1) {
+		t.Fatal("an unlocked hash should refuse RemoveAfter")
+	}
+}
+
+func TestHash_RemoveAfterTimer(t *testing.T) {
+	h := NewHash()
+	h.Add(1)
+	done := make(chan struct{})
+	h.Subscribe(func(ev Event) {
+		if ev.Type == EventRemove {
+			close(done)
+		}
+	})
+
+	h.RemoveAfter(1, time.Millisecond)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the node should be removed after the duration")
+	}
+}
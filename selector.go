@@ -0,0 +1,16 @@
+package doublejump
+
+// Selector selects an object for a key. It is implemented by Hash, Snapshot, Sticky and
+// the wrappers in this package, so they can be stacked on each other.
+type Selector interface {
+	Get(key uint64) interface{}
+}
+
+var (
+	_ Selector = (*Hash)(nil)
+	_ Selector = (*Snapshot)(nil)
+	_ Selector = (*Sticky)(nil)
+	_ Selector = (*Shadow)(nil)
+	_ Selector = (*Cells)(nil)
+	_ Selector = (*Bridge)(nil)
+)
@@ -0,0 +1,240 @@
+package doublejump
+
+// Generation returns the generation of the hash, which increases every time the mapping
+// from keys to objects may change, e.g. on Add, Remove, Shrink and Pin.
+func (this *Hash) Generation() uint64 {
+	if this == nil {
+		return 0
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	return this.gen
+}
+
+// GetVersioned is like Get, but also returns the generation of the hash the object was
+// selected at, both read under the same lock. Callers caching the object can compare the
+// generation with Generation later, and select again only if the hash has changed since.
+// Note that Drain, capacities, canary shares and breakers move keys around without changing
+// the generation.
+func (this *Hash) GetVersioned(key uint64) (obj interface{}, gen uint64) {
+	if this == nil {
+		return nil, 0
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		obj, gen = this.get(key), this.gen
+		this.mu.RUnlock()
+		return obj, gen
+	}
+
+	this.race.lockRead()
+	obj, gen = this.get(key), this.gen
+	this.race.unlockRead()
+	return obj, gen
+}
+
+// KEY到节点的映射可能发生变化，调用方持有写锁
+func (this *Hash) changed() {
+	if this.batch {
+		this.dirty = true
+		return
+	}
+	this.gen++
+	this.retire()
+	this.account()
+	if this.history != nil {
+		this.history.record(this)
+	}
+	if this.persist != nil {
+		this.schedulePersist()
+	}
+}
+
+// 事件中的代数，推迟期间为提交后的代数
+func (this *Hash) eventGen() uint64 {
+	if this.dirty {
+		return this.gen + 1
+	}
+	return this.gen
+}
+
+// Set reconciles the hash to contain exactly the given objects. Objects not in nodes are
+// removed in their slot order, then new objects are added in the given order, so that
+// replicas applying the same Set end up with the same layout. See WithThrottle to apply
+// large changes in steps.
+func (this *Hash) Set(nodes []interface{}) {
+	if this == nil {
+		return
+	}
+
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	this.setOrThrottle(nodes)
+}
+
+// 配置了WithThrottle时分步应用，调用方负责加锁
+func (this *Hash) setOrThrottle(nodes []interface{}) {
+	if this.throttle != nil {
+		this.throttledSet(nodes)
+		return
+	}
+	this.set(nodes)
+}
+
+// SetNodes is like Set, but takes objects together with their weights and metadata:
+// the weights of the objects already in the hash are changed as by SetVirtualSlots, and
+// their metadata is replaced.
+func (this *Hash) SetNodes(nodes []Node) {
+	if this == nil {
+		return
+	}
+
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	this.setNodes(nodes)
+}
+
+// 调用方负责加锁
+func (this *Hash) setNodes(nodes []Node) {
+	if this.frozen {
+		return
+	}
+
+	objs := make([]interface{}, 0, len(nodes))
+	for _, n := range nodes {
+		objs = append(objs, n.Value)
+	}
+	this.removeAbsent(objs)
+
+	for _, n := range nodes {
+		if n.Value == nil {
+			continue
+		}
+		// addNode自己规范化，这里不能改写n.Value
+		id := this.id(n.Value)
+		if old, ok := this.nodes[id]; ok {
+			if this.weightOf != nil && n.Meta != nil {
+				n.Weight = this.weightOf(n.Meta)
+			}
+			this.setVirtualSlots(id, n.Weight)
+			old.meta = n.Meta
+			this.retire()
+		} else {
+			this.addNode(n)
+		}
+	}
+}
+
+// CompareAndSet is like Set, but only applies the new membership if the generation of the
+// hash is still expectedGen. Otherwise it returns ErrGenerationMismatch and changes nothing.
+// It returns ErrFrozen if the hash is frozen. Like Set, it applies the change in steps if
+// WithThrottle is set.
+func (this *Hash) CompareAndSet(expectedGen uint64, nodes []interface{}) error {
+	if this == nil {
+		return ErrNilHash
+	}
+
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	if this.frozen {
+		return ErrFrozen
+	}
+	if this.gen != expectedGen {
+		return ErrGenerationMismatch
+	}
+	this.setOrThrottle(nodes)
+	return nil
+}
+
+// ApplyDelta removes and adds objects in one critical section, the shape of the incremental
+// updates of watch-based discovery systems, and returns the generation of the hash after the
+// changes. Like Set, it removes first, then adds in the given order. Nil objects, objects
+// to remove which are not in the hash and objects to add which already are, are ignored.
+func (this *Hash) ApplyDelta(added, removed []interface{}) (gen uint64) {
+	if this == nil {
+		return 0
+	}
+
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	for _, obj := range removed {
+		if obj != nil {
+			this.remove(obj)
+		}
+	}
+	for _, obj := range added {
+		if obj != nil {
+			this.add(obj)
+		}
+	}
+	return this.gen
+}
+
+// 调用方负责加锁
+func (this *Hash) set(nodes []interface{}) {
+	if this.frozen {
+		return
+	}
+
+	this.removeAbsent(nodes)
+	for _, obj := range nodes {
+		if obj != nil {
+			this.add(obj)
+		}
+	}
+}
+
+// 按位置顺序删除不在nodes中的节点，调用方负责加锁
+func (this *Hash) removeAbsent(nodes []interface{}) {
+	for _, id := range this.absent(nodes) {
+		this.removeNode(id)
+	}
+}
+
+// 按位置顺序返回不在nodes中的节点标识，调用方负责加锁
+func (this *Hash) absent(nodes []interface{}) []interface{} {
+	keep := make(map[interface{}]struct{}, len(nodes))
+	for _, obj := range nodes {
+		if obj != nil {
+			keep[this.id(obj)] = struct{}{}
+		}
+	}
+
+	var removed []interface{}
+	for _, slot := range this.loose.a {
+		if !isPrimary(slot) {
+			continue
+		}
+		if _, ok := keep[slot]; !ok {
+			removed = append(removed, slot)
+		}
+	}
+	return removed
+}
@@ -0,0 +1,136 @@
+package doublejump
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestHash_Generation(t *testing.T) {
+	h := NewHash()
+	if h.Generation() != 0 {
+		t.Fatal("h.Generation() should be 0 at first")
+	}
+
+	h.Add(1)
+	h.Add(2)
+	h.Add(1)
+	if h.Generation() != 2 {
+		t.Fatalf("only real insertions should increase the generation. gen: %d", h.Generation())
+	}
+
+	h.Remove(1)
+	h.Remove(1)
+	h.Shrink()
+	h.Shrink()
+	if h.Generation() != 4 {
+		t.Fatalf("only real removals and shrinks should increase the generation. gen: %d", h.Generation())
+	}
+}
+
+func TestHash_GetVersioned(t *testing.T) {
+	h := NewHash()
+	if obj, gen := h.GetVersioned(0); obj != nil || gen != 0 {
+		t.Fatal("GetVersioned should return nil at first")
+	}
+
+	h.Add(1)
+	obj, gen := h.GetVersioned(0)
+	if obj != 1 || gen != h.Generation() {
+		t.Fatalf("GetVersioned is wrong. obj: %v, gen: %d", obj, gen)
+	}
+	h.Add(1)
+	if h.Generation() != gen {
+		t.Fatal("the cached object should still be valid")
+	}
+	h.Add(2)
+	if h.Generation() == gen {
+		t.Fatal("the cached object should be invalidated")
+	}
+}
+
+func TestHash_ApplyDelta(t *testing.T) {
+	h1 := NewHash()
+	h2 := NewHash()
+	for i := 0; i < 5; i++ {
+		h1.Add(i)
+		h2.Add(i)
+	}
+
+	var events []Event
+	h1.Subscribe(func(ev Event) { events = append(events, ev) })
+	gen := h1.ApplyDelta([]interface{}{5, 6, 1, nil}, []interface{}{1, 3, 100, nil})
+	if gen != h1.Generation() || gen != 10 {
+		t.Fatalf("unexpected generation: %d", gen)
+	}
+	if len(events) != 5 || events[0].Type != EventRemove || events[4].Node != 1 {
+		t.Fatalf("the removals should be applied before the additions. events: %v", events)
+	}
+
+	h2.Remove(1)
+	h2.Remove(3)
+	h2.Add(5)
+	h2.Add(6)
+	h2.Add(1)
+	if !h1.EqualLayout(h2) {
+		t.Fatal("ApplyDelta should be the same as the single operations")
+	}
+}
+
+func TestHash_SetNodes(t *testing.T) {
+	h := NewHash()
+	h.SetNodes([]Node{{Value: "a"}, {Value: "b", Weight: 2}, {Value: "c"}})
+	h.SetNodes([]Node{{Value: "c", Meta: "m"}, {Value: "b", Weight: 4}, {Value: "d"}, {}})
+	if h.Len() != 3 || h.Contains("a") || h.Weight("b") != 4 || h.Meta("c") != "m" {
+		t.Fatalf("unexpected nodes: %v", h.Nodes())
+	}
+	always(h, t)
+}
+
+func TestHash_Set(t *testing.T) {
+	h := NewHash()
+	for i := 0; i < 5; i++ {
+		h.Add(i)
+	}
+
+	h.Set([]interface{}{1, 3, 5, 6, nil, 5})
+	always(h, t)
+	if fmt.Sprint(h.Nodes()) != "[1 6 3 5]" {
+		t.Fatalf("h.Nodes() is wrong after Set. nodes: %v", h.Nodes())
+	}
+
+	h2 := NewHash()
+	for i := 0; i < 5; i++ {
+		h2.Add(i)
+	}
+	h2.Set([]interface{}{1, 3, 5, 6})
+	if !h.EqualLayout(h2) {
+		t.Fatal("replicas applying the same Set should have the same layout")
+	}
+
+	h.Set(nil)
+	if h.Len() != 0 {
+		t.Fatalf("h.Set(nil) should remove all nodes. h.Len(): %d", h.Len())
+	}
+}
+
+func TestHash_CompareAndSet(t *testing.T) {
+	h := NewHash()
+	gen := h.Generation()
+	if err := h.CompareAndSet(gen, []interface{}{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.CompareAndSet(gen, []interface{}{3}); err != ErrGenerationMismatch {
+		t.Fatalf("CompareAndSet should fail with a stale generation. err: %v", err)
+	}
+	if fmt.Sprint(h.Nodes()) != "[1 2]" {
+		t.Fatalf("a failed CompareAndSet should change nothing. nodes: %v", h.Nodes())
+	}
+	if err := h.CompareAndSet(h.Generation(), []interface{}{3}); err != nil {
+		t.Fatal(err)
+	}
+
+	var h2 *Hash
+	if err := h2.CompareAndSet(0, nil); err != ErrNilHash {
+		t.Fatalf("CompareAndSet should return ErrNilHash. err: %v", err)
+	}
+}
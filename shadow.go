@@ -0,0 +1,114 @@
+package doublejump
+
+import "sync/atomic"
+
+// Shadow routes keys by a primary selector while also computing the selection of a
+// candidate one, e.g. a hash with a new topology or algorithm, and counts how often they
+// diverge. It de-risks migrations: the candidate can be watched under real traffic
+// before it takes over. Shadow is threadsafe as long as both selectors are.
+type Shadow struct {
+	primary   Selector
+	candidate Selector
+
+	equal       func(a, b interface{}) bool
+	mirror      func(key uint64, primary, candidate interface{})
+	mirrorEvery uint64
+
+	lookups  uint64
+	diverged uint64
+}
+
+// ShadowOption configures a Shadow.
+type ShadowOption func(s *Shadow)
+
+// WithShadowEqual sets the function telling whether two selected objects are the same.
+// The default is ==, which panics if the objects are not comparable.
+func WithShadowEqual(equal func(a, b interface{}) bool) ShadowOption {
+	return func(s *Shadow) {
+		s.equal = equal
+	}
+}
+
+// WithShadowMirror makes Get call f with both selections for one in every lookups,
+// e.g. to send a copy of the request to the candidate object. every <= 1 means all of
+// them. f is called synchronously, so it should hand heavy work off to other goroutines.
+func WithShadowMirror(every int, f func(key uint64, primary, candidate interface{})) ShadowOption {
+	return func(s *Shadow) {
+		if every < 1 {
+			every = 1
+		}
+		s.mirror = f
+		s.mirrorEvery = uint64(every)
+	}
+}
+
+// NewShadow creates a shadow routing by primary and comparing against candidate.
+func NewShadow(primary, candidate Selector, opts ...ShadowOption) *Shadow {
+	s := &Shadow{primary: primary, candidate: candidate}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Get returns the object selected by the primary selector.
+func (this *Shadow) Get(key uint64) interface{} {
+	if this == nil {
+		return nil
+	}
+
+	p := this.primary.Get(key)
+	c := this.candidate.Get(key)
+
+	n := atomic.AddUint64(&this.lookups, 1)
+	if !this.same(p, c) {
+		atomic.AddUint64(&this.diverged, 1)
+	}
+	if this.mirror != nil && n%this.mirrorEvery == 0 {
+		this.mirror(key, p, c)
+	}
+	return p
+}
+
+func (this *Shadow) same(a, b interface{}) bool {
+	if this.equal != nil {
+		return this.equal(a, b)
+	}
+	return a == b
+}
+
+// ShadowStats counts the lookups of a Shadow.
+type ShadowStats struct {
+	// Lookups is the number of calls to Get.
+	Lookups uint64
+	// Diverged is the number of lookups where the candidate selected a different object.
+	Diverged uint64
+}
+
+// Divergence returns the fraction of the lookups which diverged, 0 if there is none.
+func (this ShadowStats) Divergence() float64 {
+	if this.Lookups == 0 {
+		return 0
+	}
+	return float64(this.Diverged) / float64(this.Lookups)
+}
+
+// Stats returns the counters of the shadow.
+func (this *Shadow) Stats() ShadowStats {
+	if this == nil {
+		return ShadowStats{}
+	}
+	return ShadowStats{
+		Lookups:  atomic.LoadUint64(&this.lookups),
+		Diverged: atomic.LoadUint64(&this.diverged),
+	}
+}
+
+// Reset clears the counters, e.g. after the candidate was adjusted.
+func (this *Shadow) Reset() {
+	if this == nil {
+		return
+	}
+	atomic.StoreUint64(&this.lookups, 0)
+	atomic.StoreUint64(&this.diverged, 0)
+}
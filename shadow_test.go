@@ -0,0 +1,66 @@
+package doublejump
+
+import (
+	"math"
+	"testing"
+)
+
+func TestShadow(t *testing.T) {
+	primary := NewHash()
+	candidate := NewHash()
+	for i := 0; i < 10; i++ {
+		primary.Add(i)
+		candidate.Add(i)
+	}
+	candidate.Add(10)
+
+	var mirrored []uint64
+	s := NewShadow(primary, candidate, WithShadowMirror(10, func(key uint64, p, c interface{}) {
+		if p != primary.Get(key) || c != candidate.Get(key) {
+			t.Errorf("unexpected mirror. key: %d", key)
+		}
+		mirrored = append(mirrored, key)
+	}))
+
+	const n = 10000
+	diverged := 0
+	for key := uint64(0); key < n; key++ {
+		if s.Get(key) != primary.Get(key) {
+			t.Fatalf("s.Get(%d) != primary.Get(%d)", key, key)
+		}
+		if primary.Get(key) != candidate.Get(key) {
+			diverged++
+		}
+	}
+
+	stats := s.Stats()
+	if stats.Lookups != n {
+		t.Fatalf("stats.Lookups != %d. n: %d", n, stats.Lookups)
+	}
+	if stats.Diverged != uint64(diverged) {
+		t.Fatalf("stats.Diverged != %d. n: %d", diverged, stats.Diverged)
+	}
+	// 新增1个节点后大约1/11的KEY会移动
+	if d := stats.Divergence(); math.Abs(d-1.0/11) > 0.02 {
+		t.Fatalf("unexpected divergence: %f", d)
+	}
+	if len(mirrored) != n/10 {
+		t.Fatalf("len(mirrored) != %d. len: %d", n/10, len(mirrored))
+	}
+
+	s.Reset()
+	if s.Stats() != (ShadowStats{}) {
+		t.Fatal("the counters should be cleared")
+	}
+	if (ShadowStats{}).Divergence() != 0 {
+		t.Fatal("the divergence of no lookup should be 0")
+	}
+
+	same := NewShadow(primary, candidate, WithShadowEqual(func(a, b interface{}) bool { return true }))
+	for key := uint64(0); key < 100; key++ {
+		same.Get(key)
+	}
+	if same.Stats().Diverged != 0 {
+		t.Fatal("the equal function should be used")
+	}
+}
@@ -0,0 +1,82 @@
+package doublejump
+
+// SlotChange tells that a slot of the loose holder changed its owner, by Shrink or Replace,
+// so the keys hashing to the slot, see GetIndex, are now served by another object.
+type SlotChange struct {
+	Slot int
+	// From is the previous owner of the slot, nil if the slot was empty.
+	From interface{}
+	// To is the new owner of the slot, nil if the slot is gone.
+	To interface{}
+	// Generation is the generation of the hash after the change.
+	Generation uint64
+}
+
+type slotListener struct {
+	f func(SlotChange)
+}
+
+// SubscribeSlots registers f to be called for every slot changing its owner, so that storage
+// systems can move the data of exactly those slots instead of rescanning everything, and
+// returns a function to cancel the subscription. Adding and removing objects, which Subscribe
+// reports, do not change the owners of the slots of other objects and are not reported.
+// f is called with the hash locked, so it must not call back into the hash.
+func (this *Hash) SubscribeSlots(f func(c SlotChange)) (cancel func()) {
+	if this == nil || f == nil {
+		return func() {}
+	}
+
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	l := &slotListener{f: f}
+	this.slotListeners = append(this.slotListeners, l)
+	return func() {
+		if this.lock {
+			this.mu.Lock()
+			defer this.mu.Unlock()
+		}
+		this.race.lockWrite()
+		defer this.race.unlockWrite()
+
+		for i, v := range this.slotListeners {
+			if v == l {
+				this.slotListeners = append(this.slotListeners[:i:i], this.slotListeners[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// 无人订阅时不需要记录变化前的位置
+func (this *Hash) slotOwners() []interface{} {
+	if len(this.slotListeners) == 0 {
+		return nil
+	}
+	a := make([]interface{}, len(this.loose.a))
+	for i, slot := range this.loose.a {
+		a[i] = this.value(owner(slot))
+	}
+	return a
+}
+
+// 对比变化前的位置，通知每个所有者变化了的位置，调用方负责加锁
+func (this *Hash) emitSlots(before []interface{}) {
+	for i, from := range before {
+		var to interface{}
+		if i < len(this.loose.a) {
+			to = this.value(owner(this.loose.a[i]))
+		}
+		if to == from {
+			continue
+		}
+		c := SlotChange{Slot: i, From: from, To: to, Generation: this.eventGen()}
+		for _, l := range this.slotListeners {
+			l.f(c)
+		}
+	}
+}
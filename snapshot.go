@@ -0,0 +1,219 @@
+package doublejump
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Snapshot is a frozen view of a Hash. Lookups on a snapshot take no lock and are not
+// affected by later changes of the hash, so a request handler can grab one snapshot and
+// make many consistent lookups with it.
+//
+// The hash keeps handing out the same snapshot until its state changes, so taking snapshots
+// is cheap for read-dominated rings. Callers may Release a snapshot when done with it: once
+// the hash has moved on and every holder has released it, its arrays are recycled for the
+// next snapshot instead of becoming garbage.
+type Snapshot struct {
+	hash *Hash
+	refs int32 // 哈希缓存持有一个引用，减为0时回收
+}
+
+// 回收作废快照的内部结构，避免频繁变化的哈希产生大量垃圾
+var snapshotPool = sync.Pool{
+	New: func() interface{} { return new(Hash) },
+}
+
+// Snapshot returns a frozen view of the current state of the hash.
+func (this *Hash) Snapshot() *Snapshot {
+	if this == nil {
+		return nil
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	// 多个读者可能同时创建快照
+	this.snapMu.Lock()
+	defer this.snapMu.Unlock()
+
+	if this.snap == nil {
+		this.snap = &Snapshot{hash: this.clone(), refs: 1}
+	}
+	atomic.AddInt32(&this.snap.refs, 1)
+	return this.snap
+}
+
+// 作废当前的快照，调用方持有写锁
+func (this *Hash) retire() {
+	if this.snap != nil {
+		this.snap.release()
+		this.snap = nil
+	}
+}
+
+// Release tells that the caller is done with the snapshot, which must not be used afterwards.
+// Calling it is optional; unreleased snapshots are simply left to the garbage collector.
+func (this *Snapshot) Release() {
+	if this != nil {
+		this.release()
+	}
+}
+
+func (this *Snapshot) release() {
+	if atomic.AddInt32(&this.refs, -1) == 0 {
+		h := this.hash
+		this.hash = nil
+		recycle(h)
+	}
+}
+
+// 复制一个不加锁的哈希，调用方负责加锁
+func (this *Hash) clone() *Hash {
+	h := snapshotPool.Get().(*Hash)
+	h.keyFunc = this.keyFunc
+	h.normalize = this.normalize
+	h.hot = this.hot
+	h.load = this.load
+	h.capped = this.capped
+	h.bounded = this.bounded
+	h.drained = this.drained
+	h.canaries = this.canaries
+	h.breakers = this.breakers
+	h.penalized = this.penalized
+	h.replicas = this.replicas
+	h.minNodes = this.minNodes
+	h.window = this.window
+	h.digest = this.digest
+	h.latencyK = this.latencyK
+	h.slack = this.slack
+	h.reporter = this.reporter
+	h.factor = this.factor
+	h.seed = this.seed
+	h.gen = this.gen
+
+	h.loose.a = append(h.loose.a[:0], this.loose.a...)
+	if h.loose.m == nil {
+		h.loose.m = make(map[interface{}]int, len(this.loose.m))
+	}
+	for id, idx := range this.loose.m {
+		h.loose.m[id] = idx
+	}
+	h.loose.emptyPoses = append(h.loose.emptyPoses[:0], this.loose.emptyPoses...)
+
+	h.compact.a = append(h.compact.a[:0], this.compact.a...)
+	h.compact.mix = this.compact.mix
+	h.compact.jump = this.compact.jump
+	h.loose.jump = this.loose.jump
+	if h.compact.m == nil {
+		h.compact.m = make(map[interface{}]int, len(this.compact.m))
+	}
+	for id, idx := range this.compact.m {
+		h.compact.m[id] = idx
+	}
+
+	if len(this.pins) > 0 {
+		if h.pins == nil {
+			h.pins = make(map[uint64]interface{}, len(this.pins))
+		}
+		for key, id := range this.pins {
+			h.pins[key] = id
+		}
+	}
+	if h.nodes == nil {
+		h.nodes = make(map[interface{}]*node, len(this.nodes))
+	}
+	for id, n := range this.nodes {
+		c := *n
+		h.nodes[id] = &c
+	}
+	return h
+}
+
+// 清空内部结构后放回池中，保留数组和map的容量
+func recycle(h *Hash) {
+	for i := range h.loose.a {
+		h.loose.a[i] = nil
+	}
+	for i := range h.compact.a {
+		h.compact.a[i] = nil
+	}
+	h.loose.a = h.loose.a[:0]
+	h.compact.a = h.compact.a[:0]
+	h.loose.emptyPoses = h.loose.emptyPoses[:0]
+	clear(h.loose.m)
+	clear(h.compact.m)
+	clear(h.pins)
+	clear(h.nodes)
+	h.keyFunc = nil
+	h.normalize = nil
+	h.hot = nil
+	h.load = nil
+	h.reporter = nil
+	h.hygiene = hygiene{}
+	h.compact.mix = nil
+	h.compact.jump = nil
+	h.loose.jump = nil
+	snapshotPool.Put(h)
+}
+
+// Get returns an object according to the key provided.
+func (this *Snapshot) Get(key uint64) interface{} {
+	if this == nil {
+		return nil
+	}
+	return this.hash.Get(key)
+}
+
+// GetN returns n distinct objects for the key, see Hash.GetN.
+func (this *Snapshot) GetN(key uint64, n int) []interface{} {
+	if this == nil {
+		return nil
+	}
+	return this.hash.GetN(key, n)
+}
+
+// GetUpToN returns at most n distinct objects for the key, see Hash.GetUpToN.
+func (this *Snapshot) GetUpToN(key uint64, n int) ([]interface{}, bool) {
+	if this == nil {
+		return nil, n <= 0
+	}
+	return this.hash.GetUpToN(key, n)
+}
+
+// GetReplicas returns as many distinct objects for the key as the replication factor,
+// see Hash.GetReplicas.
+func (this *Snapshot) GetReplicas(key uint64) []interface{} {
+	if this == nil {
+		return nil
+	}
+	return this.hash.GetReplicas(key)
+}
+
+// GetQuorum returns n distinct objects for the key split into a primary and the ordered
+// secondaries, see Hash.GetQuorum.
+func (this *Snapshot) GetQuorum(key uint64, n int) (interface{}, []interface{}) {
+	if this == nil {
+		return nil, nil
+	}
+	return this.hash.GetQuorum(key, n)
+}
+
+// Nodes returns all objects in the snapshot, see Hash.Nodes.
+func (this *Snapshot) Nodes() []interface{} {
+	if this == nil {
+		return nil
+	}
+	return this.hash.Nodes()
+}
+
+// Len returns the number of objects in the snapshot.
+func (this *Snapshot) Len() int {
+	if this == nil {
+		return 0
+	}
+	return this.hash.Len()
+}
@@ -0,0 +1,95 @@
+package doublejump
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestHash_Snapshot(t *testing.T) {
+	h := NewHash()
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+	h.Remove(3)
+	h.Pin(1, 5)
+
+	s := h.Snapshot()
+	always(s.hash, t)
+	if s.hash.lock {
+		t.Fatal("the snapshot should not take any lock")
+	}
+
+	want := make([]interface{}, 1000)
+	for key := uint64(0); key < 1000; key++ {
+		want[key] = h.Get(key)
+		if s.Get(key) != want[key] {
+			t.Fatalf("s.Get(%d) != h.Get(%d)", key, key)
+		}
+		if fmt.Sprint(s.GetN(key, 3)) != fmt.Sprint(h.GetN(key, 3)) {
+			t.Fatalf("s.GetN(%d, 3) != h.GetN(%d, 3)", key, key)
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+		h.Remove(i)
+	}
+	if s.Len() != 9 || len(s.Nodes()) != 9 {
+		t.Fatalf("the snapshot should not be affected by later changes. s.Len(): %d", s.Len())
+	}
+	for key := uint64(0); key < 1000; key++ {
+		if s.Get(key) != want[key] {
+			t.Fatalf("the snapshot should not be affected by later changes. key: %d", key)
+		}
+	}
+
+	var s2 *Snapshot
+	if s2.Get(0) != nil || s2.GetN(0, 1) != nil || s2.Nodes() != nil || s2.Len() != 0 {
+		t.Fatal("something is wrong with nil snapshot")
+	}
+}
+
+func TestHash_SnapshotReuse(t *testing.T) {
+	h := NewHash()
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+
+	s1 := h.Snapshot()
+	s2 := h.Snapshot()
+	if s1 != s2 {
+		t.Fatal("the same snapshot should be returned while the hash does not change")
+	}
+
+	h.SetMeta(1, "meta")
+	s3 := h.Snapshot()
+	if s3 == s1 || s3.hash.nodes[1].meta != "meta" {
+		t.Fatal("a new snapshot should be taken after the hash changes")
+	}
+
+	// 作废的快照在所有持有者释放后回收
+	s1.Release()
+	if s1.hash == nil {
+		t.Fatal("the snapshot should not be recycled while it is still held")
+	}
+	s2.Release()
+	if s1.hash != nil {
+		t.Fatal("the retired snapshot should be recycled after all holders release it")
+	}
+	if s1.Get(0) != nil || s1.Len() != 0 {
+		t.Fatal("a recycled snapshot should not return anything")
+	}
+
+	// 当前的快照释放后不会回收，直到哈希发生变化
+	s3.Release()
+	if s3.hash == nil || h.Snapshot() != s3 {
+		t.Fatal("the current snapshot should be kept by the hash")
+	}
+	h.Add(10)
+	s4 := h.Snapshot()
+	always(s4.hash, t)
+	for key := uint64(0); key < 1000; key++ {
+		if s4.Get(key) != h.Get(key) {
+			t.Fatalf("the snapshot built from recycled internals is wrong. key: %d", key)
+		}
+	}
+}
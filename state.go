@@ -0,0 +1,171 @@
+package doublejump
+
+import "fmt"
+
+// State is the routing state of a hash: its objects with their weights, drained states
+// and canary shares, the layout of its slots and its generation. It is the common form of
+// the serializations of the hash, such as ToProto and the msgpack subpackage, and can be
+// encoded in any other format. The objects must be strings. Pins, capacities, metadata and
+// tombstones are not part of it.
+type State struct {
+	Generation uint64
+	// Nodes are the objects in the order of their primary slots.
+	Nodes []NodeState
+	// Loose and Compact are the slots of the inner holders.
+	Loose   []Slot
+	Compact []Slot
+	// EmptyPositions are the empty positions of the loose holder, in the order they are
+	// reused from the end.
+	EmptyPositions []int
+}
+
+// NodeState is an object of a State.
+type NodeState struct {
+	ID      string
+	Weight  int
+	Drained bool
+	// Share is the share of the keyspace of a canary object, 0 for a full object.
+	Share float64
+}
+
+// Slot is a slot of a State.
+type Slot struct {
+	// Node is the index of the owning object in State.Nodes, -1 for an empty slot.
+	Node int
+	// Replica is the index of the virtual slot of the object, 0 for the primary one.
+	Replica int
+}
+
+// State returns the routing state of the hash. It returns ErrNotString if an object is
+// not a string.
+func (this *Hash) State() (*State, error) {
+	if this == nil {
+		return nil, ErrNilHash
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	return this.state()
+}
+
+// 调用方负责加锁
+func (this *Hash) state() (*State, error) {
+	s := &State{
+		Generation:     this.gen,
+		Nodes:          make([]NodeState, 0, len(this.nodes)),
+		Loose:          make([]Slot, len(this.loose.a)),
+		Compact:        make([]Slot, len(this.compact.a)),
+		EmptyPositions: append([]int(nil), this.loose.emptyPoses...),
+	}
+
+	index := make(map[interface{}]int, len(this.nodes))
+	for _, slot := range this.loose.a {
+		if !isPrimary(slot) {
+			continue
+		}
+		n := this.nodes[slot]
+		id, ok := n.obj.(string)
+		if !ok {
+			return nil, ErrNotString
+		}
+		index[slot] = len(s.Nodes)
+		s.Nodes = append(s.Nodes, NodeState{ID: id, Weight: n.weight, Drained: n.drained, Share: n.share})
+	}
+
+	for i, slot := range this.loose.a {
+		if slot == nil {
+			s.Loose[i] = Slot{Node: -1}
+		} else {
+			s.Loose[i] = Slot{Node: index[owner(slot)], Replica: replicaOf(slot)}
+		}
+	}
+	for i, slot := range this.compact.a {
+		s.Compact[i] = Slot{Node: index[owner(slot)], Replica: replicaOf(slot)}
+	}
+	return s, nil
+}
+
+// 返回位置是节点的第几个位置
+func replicaOf(slot interface{}) int {
+	if v, ok := slot.(vslot); ok {
+		return v.i
+	}
+	return 0
+}
+
+// NewHashFromState creates a new threadsafe hash with options from the routing state,
+// reproducing exactly the placement of the hash the state was taken from, including its
+// empty slots and its generation. It returns an error if the state is inconsistent.
+func NewHashFromState(s *State, opts ...Option) (*Hash, error) {
+	h := newHash(true, opts)
+	if err := h.loadState(s); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// 只能在新创建的哈希上调用
+func (this *Hash) loadState(s *State) error {
+	this.gen = s.Generation
+
+	ids := make([]interface{}, len(s.Nodes))
+	for i, ns := range s.Nodes {
+		var obj interface{} = ns.ID
+		if this.normalize != nil {
+			obj = this.normalize(obj)
+		}
+		id := this.keyOf(obj)
+		if _, ok := this.nodes[id]; ok {
+			return fmt.Errorf("doublejump: duplicated node %v", obj)
+		}
+		ids[i] = id
+
+		weight := ns.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		n := &node{obj: obj, weight: weight, drained: ns.Drained, latency: new(ewma)}
+		this.nodes[id] = n
+		if ns.Drained {
+			this.drained++
+		}
+		if ns.Share > 0 {
+			this.setShare(n, ns.Share)
+		}
+	}
+
+	slot := func(v Slot) (interface{}, error) {
+		if v.Node < 0 || v.Node >= len(ids) || v.Replica < 0 || v.Replica >= this.nodes[ids[v.Node]].weight {
+			return nil, fmt.Errorf("doublejump: slot %+v refers to no node", v)
+		}
+		return slotOf(ids[v.Node], v.Replica), nil
+	}
+	this.loose.a = make([]interface{}, len(s.Loose))
+	for i, v := range s.Loose {
+		if v.Node < 0 {
+			continue
+		}
+		obj, err := slot(v)
+		if err != nil {
+			return err
+		}
+		this.loose.a[i] = obj
+		this.loose.m[obj] = i
+	}
+	this.compact.a = make([]interface{}, len(s.Compact))
+	for i, v := range s.Compact {
+		obj, err := slot(v)
+		if err != nil {
+			return err
+		}
+		this.compact.a[i] = obj
+		this.compact.m[obj] = i
+	}
+	this.loose.emptyPoses = append([]int(nil), s.EmptyPositions...)
+	return this.validate()
+}
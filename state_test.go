@@ -0,0 +1,47 @@
+package doublejump
+
+import "testing"
+
+func TestNewHashFromState(t *testing.T) {
+	h := NewHash()
+	for _, s := range []string{"a", "b", "c", "d"} {
+		h.Add(s)
+	}
+	h.addNode(Node{Value: "w", Weight: 2})
+	h.Remove("b")
+
+	s, err := h.State()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Nodes) != 4 || len(s.EmptyPositions) != 1 || s.Loose[s.EmptyPositions[0]].Node != -1 {
+		t.Fatalf("unexpected state: %+v", s)
+	}
+	h2, err := NewHashFromState(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	always(h2, t)
+	if !h.EqualLayout(h2) || h2.Generation() != h.Generation() {
+		t.Fatal("the state should be restored")
+	}
+
+	broken := []func(s *State){
+		func(s *State) { s.Compact[0].Node = len(s.Nodes) },
+		func(s *State) { s.Compact[0].Replica = 5 },
+		func(s *State) { s.Loose = s.Loose[:len(s.Loose)-1] },
+		func(s *State) { s.Nodes[1].ID = s.Nodes[0].ID },
+		func(s *State) { s.EmptyPositions = nil },
+	}
+	for i, f := range broken {
+		s, _ := h.State()
+		f(s)
+		if _, err := NewHashFromState(s); err == nil {
+			t.Fatalf("the broken state %d should be rejected", i)
+		}
+	}
+
+	if _, err := NewHashFromNodes(1).State(); err != ErrNotString {
+		t.Fatal("err != ErrNotString")
+	}
+}
@@ -0,0 +1,167 @@
+package doublejump
+
+import (
+	"time"
+	"unsafe"
+)
+
+// Stats is a summary of the state of a hash.
+type Stats struct {
+	// Len is the number of objects.
+	Len int
+	// LooseLen is the size of the inner loose holder.
+	LooseLen int
+	// EmptySlots is the number of empty slots in the loose holder.
+	EmptySlots int
+	// Generation is the generation of the hash.
+	Generation uint64
+	// CacheHits and CacheMisses count the lookups of the result cache, see WithCache.
+	CacheHits   uint64
+	CacheMisses uint64
+	// LockAcquisitions counts the read and write lock acquisitions and LockWaitTime sums up
+	// the time spent waiting for them. WriteLocks counts the write lock acquisitions and
+	// WriteHoldTime sums up the time the write lock was held. They are only recorded if the
+	// hash was created with WithLockProfile.
+	LockAcquisitions uint64
+	LockWaitTime     time.Duration
+	WriteLocks       uint64
+	WriteHoldTime    time.Duration
+	// SinceShrink is the time since the last Shrink, or since the creation of the hash.
+	SinceShrink time.Duration
+	// EmptySlotSeconds sums up the number of empty slots over time, in slot-seconds, as a
+	// measure of the waste accumulated by not shrinking the hash.
+	EmptySlotSeconds float64
+	// StoreFailures counts the failed background saves of WithStore, and StoreError is the
+	// error of the last one, nil if there was none.
+	StoreFailures uint64
+	StoreError    error
+}
+
+// Stats returns a summary of the state of the hash.
+func (this *Hash) Stats() Stats {
+	if this == nil {
+		return Stats{}
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	return this.stats()
+}
+
+// 调用方负责加锁
+func (this *Hash) stats() Stats {
+	s := Stats{
+		Len:        len(this.nodes),
+		LooseLen:   len(this.loose.a),
+		EmptySlots: len(this.loose.emptyPoses),
+		Generation: this.gen,
+	}
+	if h := &this.hygiene; !h.shrunk.IsZero() {
+		t := now()
+		s.SinceShrink = t.Sub(h.shrunk)
+		s.EmptySlotSeconds = h.seconds
+		if !h.since.IsZero() {
+			s.EmptySlotSeconds += float64(h.empty) * t.Sub(h.since).Seconds()
+		}
+	}
+	if this.cache != nil {
+		s.CacheHits, s.CacheMisses = this.cache.counters()
+	}
+	if this.mu.prof != nil {
+		this.mu.prof.fill(&s)
+	}
+	if this.persist != nil {
+		s.StoreFailures, s.StoreError = this.persist.failures()
+	}
+	return s
+}
+
+// EmptySlots returns the number of empty slots in the inner loose holder.
+// Shrink removes them.
+func (this *Hash) EmptySlots() int {
+	if this == nil {
+		return 0
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	return len(this.loose.emptyPoses)
+}
+
+// LooseFactor returns the size of the inner loose holder divided by the size of the
+// inner compact holder. It is 1 when there are no empty slots, and grows as objects
+// are removed without Shrink. It returns 0 for an empty hash.
+func (this *Hash) LooseFactor() float64 {
+	if this == nil {
+		return 0
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	if len(this.compact.a) == 0 {
+		return 0
+	}
+	return float64(len(this.loose.a)) / float64(len(this.compact.a))
+}
+
+// 估算map占用的内存: 每个条目包含键、值和1字节控制信息，按7/8的装载因子计算
+func mapFootprint(n int, entrySize uintptr) int64 {
+	const header = 48
+	return header + int64(n)*int64(entrySize+1)*8/7
+}
+
+// MemoryFootprint estimates the number of bytes held by the hash: both inner holders
+// including their empty slots and spare capacity, the maps indexing them, and the
+// per-object bookkeeping. Memory referenced by the objects themselves is not counted.
+func (this *Hash) MemoryFootprint() int64 {
+	if this == nil {
+		return 0
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	var iface interface{}
+	ifaceSize := unsafe.Sizeof(iface)
+	intSize := unsafe.Sizeof(int(0))
+
+	n := int64(unsafe.Sizeof(*this))
+	n += int64(cap(this.loose.a)) * int64(ifaceSize)
+	n += int64(cap(this.loose.emptyPoses)) * int64(intSize)
+	n += mapFootprint(len(this.loose.m), ifaceSize+intSize)
+	n += int64(cap(this.compact.a)) * int64(ifaceSize)
+	n += mapFootprint(len(this.compact.m), ifaceSize+intSize)
+	n += mapFootprint(len(this.nodes), ifaceSize+unsafe.Sizeof(&node{}))
+	n += int64(len(this.nodes)) * int64(unsafe.Sizeof(node{})+unsafe.Sizeof(ewma{}))
+	if len(this.loose.m) > len(this.nodes) {
+		// 虚拟位置作为interface{}保存时需要额外分配
+		n += int64(len(this.loose.m)-len(this.nodes)) * int64(unsafe.Sizeof(vslot{}))
+	}
+	if this.pins != nil {
+		n += mapFootprint(len(this.pins), unsafe.Sizeof(uint64(0))+ifaceSize)
+	}
+	if this.loose.tombs != nil {
+		n += mapFootprint(len(this.loose.tombs), ifaceSize+unsafe.Sizeof(tombstone{}))
+		n += mapFootprint(len(this.loose.reserved), intSize+ifaceSize)
+	}
+	return n
+}
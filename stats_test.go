@@ -0,0 +1,92 @@
+package doublejump
+
+import "testing"
+
+func TestHash_Stats(t *testing.T) {
+	h := NewHash()
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+	h.Remove(3)
+	h.Remove(7)
+
+	s := h.Stats()
+	if s.Len != 8 || s.LooseLen != 10 || s.EmptySlots != 2 || s.Generation != 12 {
+		t.Fatalf("h.Stats() is wrong. s: %+v", s)
+	}
+
+	var h2 *Hash
+	if h2.Stats() != (Stats{}) {
+		t.Fatal("Stats should handle nil hash")
+	}
+}
+
+func TestHash_MemoryFootprint(t *testing.T) {
+	h := NewHash()
+	empty := h.MemoryFootprint()
+	if empty <= 0 {
+		t.Fatalf("an empty hash should still take some memory. n: %d", empty)
+	}
+
+	for i := 0; i < 1000; i++ {
+		h.Add(i)
+	}
+	n1 := h.MemoryFootprint()
+	if n1 < empty+1000*(16*2+16*2) {
+		t.Fatalf("the footprint is too small for 1000 nodes. n: %d", n1)
+	}
+
+	for i := 0; i < 500; i++ {
+		h.Remove(i)
+	}
+	n2 := h.MemoryFootprint()
+	if n2 >= n1 {
+		t.Fatalf("the footprint should decrease after Remove. n1: %d, n2: %d", n1, n2)
+	}
+
+	// 空位置仍然占用内存，Shrink后才会释放
+	h.Shrink()
+	if n3 := h.MemoryFootprint(); n3 >= n2 {
+		t.Fatalf("the footprint should decrease after Shrink. n2: %d, n3: %d", n2, n3)
+	}
+
+	var h2 *Hash
+	if h2.MemoryFootprint() != 0 {
+		t.Fatal("MemoryFootprint should handle nil hash")
+	}
+}
+
+func TestHash_LooseFactor(t *testing.T) {
+	h := NewHash()
+	if h.EmptySlots() != 0 {
+		t.Fatal("h.EmptySlots() != 0")
+	}
+	if h.LooseFactor() != 0 {
+		t.Fatal("h.LooseFactor() != 0")
+	}
+
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+	if h.LooseFactor() != 1 {
+		t.Fatal("h.LooseFactor() != 1")
+	}
+
+	for i := 0; i < 5; i++ {
+		h.Remove(i)
+	}
+	if h.EmptySlots() != 5 {
+		t.Fatal("h.EmptySlots() != 5")
+	}
+	if h.LooseFactor() != 2 {
+		t.Fatal("h.LooseFactor() != 2")
+	}
+
+	h.Shrink()
+	if h.EmptySlots() != 0 {
+		t.Fatal("h.EmptySlots() != 0")
+	}
+	if h.LooseFactor() != 1 {
+		t.Fatal("h.LooseFactor() != 1")
+	}
+}
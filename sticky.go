@@ -0,0 +1,94 @@
+package doublejump
+
+import (
+	"sync"
+	"time"
+)
+
+// Sticky wraps a Hash and remembers recent key→node assignments in a bounded LRU.
+// When the topology changes and a key would move to another node, Sticky keeps
+// serving the remembered node for a grace period, as long as that node is still
+// in the hash. It smooths session affinity during rolling restarts.
+type Sticky struct {
+	hash  *Hash
+	grace time.Duration
+
+	mu    sync.Mutex
+	cache *lru
+}
+
+type stickyEntry struct {
+	obj      interface{}
+	deadline time.Time // 首次发现与哈希结果不一致时设置，零值表示一致
+}
+
+// NewSticky creates a sticky wrapper remembering at most size keys, which is threadsafe.
+func NewSticky(h *Hash, size int, grace time.Duration) *Sticky {
+	return &Sticky{
+		hash:  h,
+		grace: grace,
+		cache: newLRU(size),
+	}
+}
+
+// Get returns the remembered object for the key if it is still within its grace
+// period, otherwise the object selected by the underlying hash.
+func (this *Sticky) Get(key uint64) interface{} {
+	if this == nil {
+		return nil
+	}
+
+	obj := this.hash.Get(key)
+
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	if v, ok := this.cache.get(key); ok {
+		e := v.(*stickyEntry)
+		if this.hash.id(e.obj) == this.hash.id(obj) {
+			e.deadline = time.Time{}
+			return obj
+		}
+
+		// 记住的节点仍然存在时，在宽限期内继续返回该节点
+		if obj != nil && this.hash.Contains(e.obj) {
+			t := now()
+			if e.deadline.IsZero() {
+				e.deadline = t.Add(this.grace)
+			}
+			if t.Before(e.deadline) {
+				return e.obj
+			}
+		}
+	}
+
+	if obj == nil {
+		this.cache.remove(key)
+	} else {
+		this.cache.add(key, &stickyEntry{obj: obj})
+	}
+	return obj
+}
+
+// Forget drops the remembered assignment of the key.
+func (this *Sticky) Forget(key uint64) {
+	if this == nil {
+		return
+	}
+
+	this.mu.Lock()
+	this.cache.remove(key)
+	this.mu.Unlock()
+}
+
+// Len returns the number of remembered assignments.
+func (this *Sticky) Len() int {
+	if this == nil {
+		return 0
+	}
+
+	this.mu.Lock()
+	n := this.cache.len()
+	this.mu.Unlock()
+	return n
+}
@@ -0,0 +1,82 @@
+package doublejump
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSticky_Grace(t *testing.T) {
+	t0 := time.Unix(1000, 0)
+	cur := t0
+	now = func() time.Time { return cur }
+	defer func() { now = time.Now }()
+
+	h := NewHash()
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+	s := NewSticky(h, 1000, time.Minute)
+
+	old := make(map[uint64]interface{})
+	for key := uint64(0); key < 1000; key++ {
+		old[key] = s.Get(key)
+	}
+
+	h.Add(10)
+	moved := 0
+	for key := uint64(0); key < 1000; key++ {
+		if h.Get(key) != old[key] {
+			moved++
+		}
+		if obj := s.Get(key); obj != old[key] {
+			t.Fatalf("s.Get(%d) should stick to %v during the grace period. obj: %v", key, old[key], obj)
+		}
+	}
+	if moved == 0 {
+		t.Fatal("adding a node should move some keys")
+	}
+
+	cur = t0.Add(2 * time.Minute)
+	for key := uint64(0); key < 1000; key++ {
+		if obj := s.Get(key); obj != h.Get(key) {
+			t.Fatalf("s.Get(%d) should follow the hash after the grace period. obj: %v", key, obj)
+		}
+	}
+}
+
+func TestSticky_Removed(t *testing.T) {
+	h := NewHash()
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+	s := NewSticky(h, 100, time.Hour)
+
+	for key := uint64(0); key < 100; key++ {
+		obj := s.Get(key)
+		h.Remove(obj)
+		if obj2 := s.Get(key); obj2 == obj {
+			t.Fatalf("s.Get(%d) should not return a removed node. obj: %v", key, obj)
+		}
+		h.Add(obj)
+	}
+
+	if s.Len() != 100 {
+		t.Fatalf("s.Len() != 100. len: %d", s.Len())
+	}
+	s.Forget(0)
+	if s.Len() != 99 {
+		t.Fatalf("s.Len() != 99. len: %d", s.Len())
+	}
+}
+
+func TestHash_Contains(t *testing.T) {
+	h := NewHash()
+	h.Add(1)
+	if !h.Contains(1) || h.Contains(2) || h.Contains(nil) {
+		t.Fatal("something is wrong with Contains")
+	}
+	h.Remove(1)
+	if h.Contains(1) {
+		t.Fatal("h.Contains(1) should be false after Remove")
+	}
+}
@@ -0,0 +1,162 @@
+package doublejump
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// Store persists the routing state of a hash across process restarts, see WithStore.
+type Store interface {
+	// Save stores the state, replacing the one saved before.
+	Save(s *State) error
+	// Load returns the state saved last, or nil if nothing has been saved yet.
+	Load() (*State, error)
+}
+
+// 状态变化后延迟保存，合并短时间内的多次变化
+const persistDelay = 100 * time.Millisecond
+
+// 见WithStore
+type persister struct {
+	store Store
+	mu    sync.Mutex  // 保证各次保存的顺序和取状态的顺序一致
+	stop  func() bool // 正在等待保存时不为空
+
+	errMu sync.Mutex // 保护后台保存的失败记录，见Stats.StoreFailures
+	fails uint64
+	err   error
+}
+
+func (this *persister) fail(err error) {
+	this.errMu.Lock()
+	this.fails++
+	this.err = err
+	this.errMu.Unlock()
+}
+
+func (this *persister) failures() (uint64, error) {
+	this.errMu.Lock()
+	defer this.errMu.Unlock()
+	return this.fails, this.err
+}
+
+// WithStore makes the hash save its routing state to s shortly after every change of the
+// mapping, coalescing bursts of changes into one save. A failed save is retried with the
+// next change and counted by Stats.StoreFailures; call Persist to save at once and check the
+// error, e.g. on shutdown. Use NewHashFromStore to restore the hash from s on startup. The
+// objects must be strings, see State. Since the saves happen on their own goroutine, the
+// option has no effect on a hash created by NewHashWithoutLock.
+func WithStore(s Store) Option {
+	return func(h *Hash) {
+		if s == nil || !h.lock {
+			h.persist = nil
+			return
+		}
+		h.persist = &persister{store: s}
+	}
+}
+
+// NewHashFromStore creates a new threadsafe hash with options, restored from the state
+// saved last in s, or empty if nothing has been saved yet, and saving itself to s, see
+// WithStore.
+func NewHashFromStore(s Store, opts ...Option) (*Hash, error) {
+	state, err := s.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts, WithStore(s))
+	if state == nil {
+		return NewHash(opts...), nil
+	}
+	return NewHashFromState(state, opts...)
+}
+
+// Persist saves the routing state of the hash to its store at once, see WithStore. It does
+// nothing if the hash has no store.
+func (this *Hash) Persist() error {
+	if this == nil {
+		return ErrNilHash
+	}
+	p := this.persist
+	if p == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	this.mu.Lock()
+	if p.stop != nil {
+		p.stop()
+		p.stop = nil
+	}
+	s, err := this.state()
+	this.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return p.store.Save(s)
+}
+
+// 安排一次延迟保存，调用方持有写锁
+func (this *Hash) schedulePersist() {
+	p := this.persist
+	if p.stop != nil {
+		return
+	}
+	p.stop = afterFunc(persistDelay, func() {
+		if err := this.Persist(); err != nil {
+			p.fail(err)
+		}
+	})
+}
+
+// FileStore is a Store keeping the state in a file, encoded as the Ring message defined in
+// doublejump.proto. The file is replaced atomically, so a crash does not leave it torn.
+type FileStore struct {
+	path string
+}
+
+// NewFileStore creates a FileStore keeping the state in the file at path.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Save writes the state to a temporary file, then renames it over the file.
+func (this *FileStore) Save(s *State) error {
+	data, err := s.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	tmp := this.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, this.path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// Load reads the state from the file. It returns nil if the file does not exist.
+func (this *FileStore) Load() (*State, error) {
+	data, err := os.ReadFile(this.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := new(State)
+	if err := s.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
@@ -0,0 +1,93 @@
+package doublejump
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type countingStore struct {
+	Store
+	saves int
+}
+
+func (this *countingStore) Save(s *State) error {
+	this.saves++
+	return this.Store.Save(s)
+}
+
+func TestHash_WithStore(t *testing.T) {
+	var fs []func()
+	afterFunc = func(d time.Duration, f func()) func() bool {
+		i := len(fs)
+		fs = append(fs, f)
+		return func() bool {
+			if fs[i] == nil {
+				return false
+			}
+			fs[i] = nil
+			return true
+		}
+	}
+	defer func() { afterFunc = func(d time.Duration, f func()) func() bool { return time.AfterFunc(d, f).Stop } }()
+
+	path := filepath.Join(t.TempDir(), "ring")
+	s := &countingStore{Store: NewFileStore(path)}
+	h, err := NewHashFromStore(s)
+	if err != nil || h.Len() != 0 {
+		t.Fatalf("the new hash should be empty. err: %v", err)
+	}
+
+	// 多次变化只安排一次保存
+	for _, id := range []string{"a", "b", "c", "d"} {
+		h.Add(id)
+	}
+	h.Remove("b")
+	if len(fs) != 1 || s.saves != 0 {
+		t.Fatalf("len(fs): %d, saves: %d", len(fs), s.saves)
+	}
+	fs[0]()
+	if s.saves != 1 {
+		t.Fatal("s.saves != 1")
+	}
+
+	h.Add("e")
+	if len(fs) != 2 {
+		t.Fatal("the next change should schedule another save")
+	}
+	if err := h.Persist(); err != nil || s.saves != 2 || fs[1] != nil {
+		t.Fatalf("Persist should save at once and cancel the scheduled save. err: %v", err)
+	}
+
+	h2, err := NewHashFromStore(s)
+	if err != nil || !h2.EqualLayout(h) || h2.Generation() != h.Generation() {
+		t.Fatalf("the restored hash should have the same layout. err: %v", err)
+	}
+
+	h.Add(1)
+	if err := h.Persist(); err != ErrNotString {
+		t.Fatalf("err != ErrNotString. err: %v", err)
+	}
+	h.Add(2)
+	fs[len(fs)-1]()
+	if st := h.Stats(); st.StoreFailures != 1 || st.StoreError != ErrNotString {
+		t.Fatalf("the failed background save should be reported. stats: %+v", st)
+	}
+
+	os.WriteFile(path, []byte{0xff}, 0o644)
+	if _, err := NewHashFromStore(s); !errors.Is(err, ErrBadProto) {
+		t.Fatalf("err != ErrBadProto. err: %v", err)
+	}
+
+	if err := NewHash().Persist(); err != nil {
+		t.Fatal("Persist should do nothing without a store")
+	}
+	n := len(fs)
+	h3 := NewHashWithoutLock(WithStore(s))
+	h3.Add("a")
+	if len(fs) != n {
+		t.Fatal("a hash without lock should not save in the background")
+	}
+}
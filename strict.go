@@ -0,0 +1,95 @@
+package doublejump
+
+import "errors"
+
+var (
+	// ErrEmpty is returned when the hash has no object to select.
+	ErrEmpty = errors.New("doublejump: hash is empty")
+	// ErrNilNode is returned when a nil object is added or removed.
+	ErrNilNode = errors.New("doublejump: nil node")
+	// ErrNilHash is returned when the methods are called on a nil hash.
+	ErrNilHash = errors.New("doublejump: nil hash")
+	// ErrGenerationMismatch is returned when the hash has changed since the expected generation.
+	ErrGenerationMismatch = errors.New("doublejump: generation mismatch")
+	// ErrTooFewNodes is returned when the hash holds fewer objects than the replication factor.
+	ErrTooFewNodes = errors.New("doublejump: fewer nodes than the replication factor")
+	// ErrNodeExists is returned when an object already in the hash is added.
+	ErrNodeExists = errors.New("doublejump: node already exists")
+	// ErrNodeNotFound is returned when an object not in the hash is removed.
+	ErrNodeNotFound = errors.New("doublejump: node not found")
+)
+
+// Strict is a view of the hash reporting misuse through errors instead of
+// silently returning nil or doing nothing. All errors are package-level sentinels, together
+// with ErrFrozen and ErrNotReady, so callers can branch on them with errors.Is.
+type Strict struct {
+	hash *Hash
+}
+
+// Strict returns the error-returning view of the hash.
+func (this *Hash) Strict() Strict {
+	return Strict{hash: this}
+}
+
+// Get returns an object according to the key provided, ErrEmpty if the hash has no object,
+// or ErrNotReady if it holds fewer objects than required by WithMinNodes.
+func (this Strict) Get(key uint64) (interface{}, error) {
+	if this.hash == nil {
+		return nil, ErrNilHash
+	}
+
+	obj := this.hash.Get(key)
+	if obj == nil {
+		if this.hash.Len() > 0 && !this.hash.Ready() {
+			return nil, ErrNotReady
+		}
+		return nil, ErrEmpty
+	}
+	return obj, nil
+}
+
+// Add adds an object to the hash, or returns ErrNodeExists if it is already in the hash,
+// or ErrQuotaExceeded if the tenant owning the hash has reached its quota, see
+// Manager.TenantRing.
+func (this Strict) Add(obj interface{}) error {
+	if this.hash == nil {
+		return ErrNilHash
+	}
+	if obj == nil {
+		return ErrNilNode
+	}
+
+	var exists, added bool
+	if err := this.hash.mutate(func() {
+		_, exists = this.hash.nodes[this.hash.id(obj)]
+		added = this.hash.add(obj)
+	}); err != nil {
+		return err
+	}
+	switch {
+	case exists:
+		return ErrNodeExists
+	case !added:
+		return ErrQuotaExceeded
+	}
+	return nil
+}
+
+// Remove removes an object from the hash, or returns ErrNodeNotFound if it is not in the hash.
+func (this Strict) Remove(obj interface{}) error {
+	if this.hash == nil {
+		return ErrNilHash
+	}
+	if obj == nil {
+		return ErrNilNode
+	}
+
+	removed := false
+	if err := this.hash.mutate(func() { removed = this.hash.remove(obj) }); err != nil {
+		return err
+	}
+	if !removed {
+		return ErrNodeNotFound
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package doublejump
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStrict(t *testing.T) {
+	s := NewHash().Strict()
+	if _, err := s.Get(0); err != ErrEmpty {
+		t.Fatalf("Get should return ErrEmpty. err: %v", err)
+	}
+	if err := s.Add(nil); err != ErrNilNode {
+		t.Fatalf("Add should return ErrNilNode. err: %v", err)
+	}
+	if err := s.Remove(nil); err != ErrNilNode {
+		t.Fatalf("Remove should return ErrNilNode. err: %v", err)
+	}
+
+	if err := s.Add(100); err != nil {
+		t.Fatal(err)
+	}
+	if obj, err := s.Get(0); err != nil || obj != 100 {
+		t.Fatalf("Get is wrong. obj: %v, err: %v", obj, err)
+	}
+	if err := s.Add(100); !errors.Is(err, ErrNodeExists) {
+		t.Fatalf("Add should return ErrNodeExists. err: %v", err)
+	}
+	if err := s.Remove(100); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Remove(100); !errors.Is(err, ErrNodeNotFound) {
+		t.Fatalf("Remove should return ErrNodeNotFound. err: %v", err)
+	}
+	if _, err := s.Get(0); err != ErrEmpty {
+		t.Fatalf("Get should return ErrEmpty after Remove. err: %v", err)
+	}
+
+	var h *Hash
+	if _, err := h.Strict().Get(0); err != ErrNilHash {
+		t.Fatalf("Get should return ErrNilHash. err: %v", err)
+	}
+	if err := h.Strict().Add(1); err != ErrNilHash {
+		t.Fatalf("Add should return ErrNilHash. err: %v", err)
+	}
+}
@@ -0,0 +1,23 @@
+package doublejump
+
+// 租户视图: KEY与租户的盐混合后再查找
+type tenant struct {
+	hash *Hash
+	salt uint64
+}
+
+// Tenant returns a view of the hash for the tenant identified by salt. The view shares the
+// objects of the hash, so membership is managed once for all tenants, but it mixes the salt
+// into every key, so that different tenants get decorrelated placements: the keys of one
+// tenant which land on the same object are spread over all objects for another tenant.
+// The placement of a tenant is deterministic across processes.
+func (this *Hash) Tenant(salt string) Selector {
+	w := anyHasher{h: fnvOffset64}
+	w.string(salt)
+	return tenant{hash: this, salt: Murmur3Mixer(w.h)}
+}
+
+// Get returns an object according to the key provided.
+func (this tenant) Get(key uint64) interface{} {
+	return this.hash.Get(SplitMix64(key ^ this.salt))
+}
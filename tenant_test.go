@@ -0,0 +1,39 @@
+package doublejump
+
+import "testing"
+
+func TestHash_Tenant(t *testing.T) {
+	h := NewHash()
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+
+	a1, a2, b := h.Tenant("a"), h.Tenant("a"), h.Tenant("b")
+	const n = 10000
+	same := 0
+	for key := uint64(0); key < n; key++ {
+		if a1.Get(key) != a2.Get(key) {
+			t.Fatalf("the same tenant should get the same placement. key: %d", key)
+		}
+		if a1.Get(key) == b.Get(key) {
+			same++
+		}
+	}
+	// 不同租户的选择相互独立，大约1/10相同
+	if same < n/10/2 || same > n/10*2 {
+		t.Fatalf("the placements of tenants are correlated. same: %d", same)
+	}
+
+	// 租户共享节点
+	h.Remove(0)
+	for key := uint64(0); key < 1000; key++ {
+		if a1.Get(key) == 0 {
+			t.Fatal("the tenant should see the removal")
+		}
+	}
+
+	var h2 *Hash
+	if h2.Tenant("a").Get(1) != nil {
+		t.Fatal("the tenant of a nil hash should return nil")
+	}
+}
@@ -0,0 +1,193 @@
+package doublejump
+
+import "time"
+
+// 见WithThrottle，target为空表示没有待应用的变化，dropped为最近一次Set中无法加入的对象
+type throttle struct {
+	fraction float64
+	interval time.Duration
+	target   []interface{}
+	dropped  []interface{}
+	stop     func() bool
+}
+
+// WithThrottle makes Set apply large membership changes in steps, so that downstream caches
+// are not invalidated all at once. Each step removes, then adds objects until they own the
+// given fraction of the keyspace, but applies at least one change, and is a generation of
+// its own; the next step follows after interval. A Set with pending steps replaces the
+// target membership, and objects added or removed meanwhile by other means are reconciled
+// with it by the next step. Objects which cannot be added, e.g. because of WithQuota, are
+// dropped from the target and reported by Dropped. The first Set of an empty hash has
+// nothing to invalidate and is applied at once. fraction <= 0 or >= 1 disables the option.
+// Since the steps happen on their own goroutine, the option has no effect on a hash created
+// by NewHashWithoutLock.
+func WithThrottle(fraction float64, interval time.Duration) Option {
+	return func(h *Hash) {
+		if fraction <= 0 || fraction >= 1 || !h.lock {
+			h.throttle = nil
+			return
+		}
+		h.throttle = &throttle{fraction: fraction, interval: interval}
+	}
+}
+
+// Pending returns the number of additions and removals which throttled Set calls have not
+// applied yet, see WithThrottle.
+func (this *Hash) Pending() int {
+	if this == nil {
+		return 0
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	if this.throttle == nil || this.throttle.target == nil {
+		return 0
+	}
+	return len(this.absent(this.throttle.target)) + len(this.missing(this.throttle.target))
+}
+
+// Dropped returns the objects which the last throttled Set could not add and gave up on,
+// see WithThrottle.
+func (this *Hash) Dropped() []interface{} {
+	if this == nil {
+		return nil
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	if this.throttle == nil || len(this.throttle.dropped) == 0 {
+		return nil
+	}
+	return append([]interface{}{}, this.throttle.dropped...)
+}
+
+// 调用方负责加锁
+func (this *Hash) throttledSet(nodes []interface{}) {
+	if this.frozen {
+		return
+	}
+	if len(this.nodes) == 0 {
+		this.set(nodes)
+		return
+	}
+
+	t := this.throttle
+	t.target = append([]interface{}{}, nodes...)
+	t.dropped = nil
+	if t.stop != nil {
+		t.stop()
+		t.stop = nil
+	}
+	this.step()
+}
+
+// 应用一步变化，还有剩余时安排下一步，调用方负责加锁
+func (this *Hash) step() {
+	t := this.throttle
+	t.stop = nil
+	if this.frozen {
+		t.stop = afterFunc(t.interval, this.nextStep)
+		return
+	}
+
+	// 一步中的所有变化只算一代
+	if !this.batch {
+		this.batch = true
+		defer func() {
+			this.batch = false
+			if this.dirty {
+				this.dirty = false
+				this.changed()
+			}
+		}()
+	}
+
+	moved, applied := 0.0, 0
+	fits := func(share float64) bool {
+		if applied > 0 && moved+share > t.fraction {
+			return false
+		}
+		moved += share
+		applied++
+		return true
+	}
+
+	for _, id := range this.absent(t.target) {
+		if !fits(float64(this.nodes[id].weight) / float64(len(this.compact.a))) {
+			t.stop = afterFunc(t.interval, this.nextStep)
+			return
+		}
+		this.removeNode(id)
+	}
+	for _, obj := range this.missing(t.target) {
+		share := 1 / float64(len(this.compact.a)+1)
+		if !fits(share) {
+			t.stop = afterFunc(t.interval, this.nextStep)
+			return
+		}
+		if !this.add(obj) {
+			// 加不进去的对象不再重试，也不占用这一步的份额
+			moved -= share
+			applied--
+			this.drop(obj)
+		}
+	}
+	t.target = nil
+}
+
+// 把obj从目标中去掉并记入dropped，调用方负责加锁
+func (this *Hash) drop(obj interface{}) {
+	t := this.throttle
+	id := this.id(obj)
+	target := t.target[:0]
+	for _, v := range t.target {
+		if v == nil || this.id(v) != id {
+			target = append(target, v)
+		}
+	}
+	t.target = target
+	t.dropped = append(t.dropped, obj)
+}
+
+func (this *Hash) nextStep() {
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	if this.throttle != nil && this.throttle.target != nil {
+		this.step()
+	}
+}
+
+// 按给定顺序返回nodes中还不在哈希中的对象，调用方负责加锁
+func (this *Hash) missing(nodes []interface{}) []interface{} {
+	var a []interface{}
+	seen := make(map[interface{}]struct{}, len(nodes))
+	for _, obj := range nodes {
+		if obj == nil {
+			continue
+		}
+		id := this.id(obj)
+		if _, ok := this.nodes[id]; ok {
+			continue
+		}
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			a = append(a, obj)
+		}
+	}
+	return a
+}
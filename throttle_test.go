@@ -0,0 +1,143 @@
+package doublejump
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHash_WithThrottle(t *testing.T) {
+	var fs []func()
+	afterFunc = func(d time.Duration, f func()) func() bool {
+		fs = append(fs, f)
+		return func() bool { return false }
+	}
+	defer func() { afterFunc = func(d time.Duration, f func()) func() bool { return time.AfterFunc(d, f).Stop } }()
+
+	h := NewHash(WithThrottle(0.25, time.Second))
+	var nodes []interface{}
+	for i := 0; i < 10; i++ {
+		nodes = append(nodes, i)
+	}
+	h.Set(nodes)
+	if h.Len() != 10 || h.Pending() != 0 || len(fs) != 0 {
+		t.Fatal("the first Set should be applied at once")
+	}
+
+	// 替换一半的节点，每步最多移动25%的KEY
+	nodes = nodes[5:]
+	for i := 10; i < 15; i++ {
+		nodes = append(nodes, i)
+	}
+	gen := h.Generation()
+	h.Set(nodes)
+	if h.Pending() == 0 || len(fs) != 1 {
+		t.Fatal("a large change should be applied in steps")
+	}
+	steps := 1
+	for len(fs) > 0 {
+		f := fs[0]
+		fs = fs[1:]
+		f()
+		steps++
+	}
+	if steps < 4 {
+		t.Fatalf("too few steps: %d", steps)
+	}
+	if h.Pending() != 0 || h.Generation() != gen+uint64(steps) {
+		t.Fatal("every change should have been applied, one generation per step")
+	}
+	for _, obj := range nodes {
+		if !h.Contains(obj) {
+			t.Fatalf("%v is missing", obj)
+		}
+	}
+	if h.Len() != 10 {
+		t.Fatal("h.Len() != 10")
+	}
+
+	// CompareAndSet同样分步应用
+	if err := h.CompareAndSet(h.Generation(), append(append([]interface{}{}, nodes[5:]...), 20, 21, 22, 23, 24)); err != nil {
+		t.Fatal(err)
+	}
+	if h.Pending() == 0 || len(fs) != 1 {
+		t.Fatal("CompareAndSet should be throttled, too")
+	}
+	for len(fs) > 0 {
+		f := fs[0]
+		fs = fs[1:]
+		f()
+	}
+	h.Set(nodes)
+	for len(fs) > 0 {
+		f := fs[0]
+		fs = fs[1:]
+		f()
+	}
+	if h.Pending() != 0 || h.Len() != 10 {
+		t.Fatal("h.Set(nodes) should have been applied")
+	}
+
+	// 新的Set替换待应用的目标
+	h.Set(nodes[:4])
+	h.Set(nodes[:9])
+	for len(fs) > 0 {
+		f := fs[0]
+		fs = fs[1:]
+		f()
+	}
+	if h.Len() != 9 || h.Contains(nodes[9]) {
+		t.Fatal("the last Set should win")
+	}
+
+	// 不加锁的哈希一次应用全部变化
+	h2 := NewHashWithoutLock(WithThrottle(0.25, time.Second))
+	h2.Set(nodes[:5])
+	h2.Set(nodes[5:])
+	if h2.Len() != 5 || h2.Pending() != 0 || len(fs) != 0 {
+		t.Fatal("an unlocked hash should not be throttled")
+	}
+}
+
+func TestHash_WithThrottle_Dropped(t *testing.T) {
+	var fs []func()
+	afterFunc = func(d time.Duration, f func()) func() bool {
+		fs = append(fs, f)
+		return func() bool { return false }
+	}
+	defer func() { afterFunc = func(d time.Duration, f func()) func() bool { return time.AfterFunc(d, f).Stop } }()
+
+	m := NewManager(WithThrottle(0.25, time.Second))
+	m.SetQuota("t1", Quota{MaxNodes: 6})
+	h, _ := m.TenantRing("t1", "a")
+	h.Set([]interface{}{0, 1, 2, 3, 4})
+	if h.Len() != 5 {
+		t.Fatal("h.Len() != 5")
+	}
+
+	// 只有一个对象能加进去，其余的被放弃
+	h.Set([]interface{}{0, 1, 2, 3, 4, 5, 6, 7, 8})
+	for i := 0; len(fs) > 0; i++ {
+		if i > 10 {
+			t.Fatal("the unappliable objects should not be retried forever")
+		}
+		f := fs[0]
+		fs = fs[1:]
+		f()
+	}
+	if h.Len() != 6 || h.Pending() != 0 {
+		t.Fatalf("h.Len(): %d, h.Pending(): %d", h.Len(), h.Pending())
+	}
+	if d := h.Dropped(); len(d) != 3 {
+		t.Fatalf("len(h.Dropped()) != 3. dropped: %v", d)
+	}
+
+	h.Set([]interface{}{0, 1, 2, 3, 4})
+	for len(fs) > 0 {
+		f := fs[0]
+		fs = fs[1:]
+		f()
+	}
+	if h.Dropped() != nil {
+		t.Fatal("a new Set should reset the dropped objects")
+	}
+}
@@ -0,0 +1,77 @@
+package doublejump
+
+import "time"
+
+type tombstone struct {
+	idx   int
+	until time.Time
+}
+
+// WithTombstone keeps the loose slot of a removed object reserved for d, so that other
+// objects added within d do not take it. If the same object is added back while its slot
+// is still vacant, it lands in the same slot and takes back exactly the same keys, instead
+// of shuffling keys twice during a quick replacement.
+func WithTombstone(d time.Duration) Option {
+	return func(h *Hash) {
+		h.loose.grace = d
+	}
+}
+
+// 记录被删除节点的位置
+func (this *looseHolder) bury(obj interface{}, idx int) {
+	if this.tombs == nil {
+		this.tombs = make(map[interface{}]tombstone)
+		this.reserved = make(map[int]interface{})
+	}
+	this.tombs[obj] = tombstone{idx: idx, until: now().Add(this.grace)}
+	this.reserved[idx] = obj
+}
+
+// 节点原来的位置仍然为空时放回原位置，否则取最后一个没有被保留的空位置，都没有时追加到最后
+func (this *looseHolder) addReserved(obj interface{}) {
+	idx := -1
+	if t, ok := this.tombs[obj]; ok {
+		delete(this.tombs, obj)
+		delete(this.reserved, t.idx)
+		idx = t.idx
+		this.takeEmpty(idx)
+	} else {
+		idx = this.takeFree()
+	}
+
+	if idx < 0 {
+		this.a = append(this.a, obj)
+		this.m[obj] = len(this.a) - 1
+	} else {
+		this.a[idx] = obj
+		this.m[obj] = idx
+	}
+}
+
+// 从空位置中取出指定的位置
+func (this *looseHolder) takeEmpty(idx int) {
+	for i := len(this.emptyPoses) - 1; i >= 0; i-- {
+		if this.emptyPoses[i] == idx {
+			this.emptyPoses = append(this.emptyPoses[:i], this.emptyPoses[i+1:]...)
+			return
+		}
+	}
+}
+
+// 取出最后一个没有被保留的空位置，保留已经过期的位置视为没有被保留
+func (this *looseHolder) takeFree() int {
+	t := now()
+	for i := len(this.emptyPoses) - 1; i >= 0; i-- {
+		idx := this.emptyPoses[i]
+		if obj, ok := this.reserved[idx]; ok {
+			if t.Before(this.tombs[obj].until) {
+				continue
+			}
+			delete(this.tombs, obj)
+			delete(this.reserved, idx)
+		}
+		this.emptyPoses = append(this.emptyPoses[:i], this.emptyPoses[i+1:]...)
+		return idx
+	}
+	return -1
+}
@@ -0,0 +1,64 @@
+package doublejump
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHash_WithTombstone(t *testing.T) {
+	t0 := time.Unix(1000, 0)
+	cur := t0
+	now = func() time.Time { return cur }
+	defer func() { now = time.Now }()
+
+	h := NewHash(WithTombstone(time.Minute))
+	for i := 0; i < 5; i++ {
+		h.Add(i)
+	}
+
+	h.Remove(1)
+	h.Remove(3)
+	h.Add(5)
+	always(h, t)
+	if h.loose.m[5] != 5 {
+		t.Fatalf("a new node should not take the reserved slots. idx: %d", h.loose.m[5])
+	}
+
+	h.Add(1)
+	always(h, t)
+	if h.loose.m[1] != 1 {
+		t.Fatalf("the re-added node should land in its old slot. idx: %d", h.loose.m[1])
+	}
+
+	cur = t0.Add(2 * time.Minute)
+	h.Add(6)
+	always(h, t)
+	if h.loose.m[6] != 3 || len(h.loose.tombs) != 0 || len(h.loose.reserved) != 0 {
+		t.Fatalf("a new node should take the expired reserved slot. idx: %d", h.loose.m[6])
+	}
+
+	h.Remove(6)
+	h.Remove(0)
+	h.Shrink()
+	always(h, t)
+	if h.loose.tombs != nil || h.loose.reserved != nil {
+		t.Fatal("Shrink should clear the tombstones")
+	}
+}
+
+func TestHash_WithTombstoneWeighted(t *testing.T) {
+	h := NewHash(WithTombstone(time.Hour))
+	h.Add("a")
+	h.addNode(Node{Value: "b", Weight: 3})
+	h.Add("c")
+
+	h.Remove("b")
+	h.Add("d")
+	h.addNode(Node{Value: "b", Weight: 3})
+	always(h, t)
+	for i := 0; i < 3; i++ {
+		if h.loose.m[slotOf("b", i)] != i+1 {
+			t.Fatalf("every slot of the re-added node should be restored. i: %d, idx: %d", i, h.loose.m[slotOf("b", i)])
+		}
+	}
+}
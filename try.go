@@ -0,0 +1,61 @@
+package doublejump
+
+// TryGet is like Get but does not block if the hash is locked by a writer.
+// ok is false if the lock could not be acquired immediately.
+func (this *Hash) TryGet(key uint64) (obj interface{}, ok bool) {
+	if this == nil {
+		return nil, true
+	}
+
+	if this.lock {
+		if !this.mu.TryRLock() {
+			return nil, false
+		}
+		obj = this.get(key)
+		this.mu.RUnlock()
+		return obj, true
+	}
+
+	this.race.lockRead()
+	obj = this.get(key)
+	this.race.unlockRead()
+	return obj, true
+}
+
+// TryAdd is like Add but does not block if the hash is locked.
+// ok is false if the lock could not be acquired immediately.
+func (this *Hash) TryAdd(obj interface{}) (added, ok bool) {
+	if this == nil || obj == nil {
+		return false, true
+	}
+
+	if this.lock {
+		if !this.mu.TryLock() {
+			return false, false
+		}
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	return this.add(obj), true
+}
+
+// TryRemove is like Remove but does not block if the hash is locked.
+// ok is false if the lock could not be acquired immediately.
+func (this *Hash) TryRemove(obj interface{}) (removed, ok bool) {
+	if this == nil || obj == nil {
+		return false, true
+	}
+
+	if this.lock {
+		if !this.mu.TryLock() {
+			return false, false
+		}
+		defer this.mu.Unlock()
+	}
+	this.race.lockWrite()
+	defer this.race.unlockWrite()
+
+	return this.remove(obj), true
+}
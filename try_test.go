@@ -0,0 +1,53 @@
+package doublejump
+
+import "testing"
+
+func TestHash_Try(t *testing.T) {
+	h := NewHash()
+	if added, ok := h.TryAdd(1); !added || !ok {
+		t.Fatal("TryAdd should succeed when the hash is not locked")
+	}
+	if added, ok := h.TryAdd(1); added || !ok {
+		t.Fatal("TryAdd should report that the node already exists")
+	}
+	h.TryAdd(2)
+	if obj, ok := h.TryGet(0); !ok || obj == nil {
+		t.Fatalf("TryGet should succeed when the hash is not locked. obj: %v, ok: %v", obj, ok)
+	}
+
+	h.mu.Lock()
+	if _, ok := h.TryGet(0); ok {
+		t.Fatal("TryGet should fail when the hash is locked by a writer")
+	}
+	if _, ok := h.TryAdd(3); ok {
+		t.Fatal("TryAdd should fail when the hash is locked")
+	}
+	if _, ok := h.TryRemove(1); ok {
+		t.Fatal("TryRemove should fail when the hash is locked")
+	}
+	h.mu.Unlock()
+
+	h.mu.RLock()
+	if _, ok := h.TryGet(0); !ok {
+		t.Fatal("TryGet should succeed when the hash is locked by a reader")
+	}
+	if _, ok := h.TryRemove(1); ok {
+		t.Fatal("TryRemove should fail when the hash is locked by a reader")
+	}
+	h.mu.RUnlock()
+
+	if removed, ok := h.TryRemove(1); !removed || !ok || h.Len() != 1 {
+		t.Fatalf("TryRemove should remove the node. h.Len(): %d", h.Len())
+	}
+	if removed, ok := h.TryRemove(1); removed || !ok {
+		t.Fatal("TryRemove should report that the node does not exist")
+	}
+
+	h2 := NewHashWithoutLock()
+	if _, ok := h2.TryAdd(1); !ok {
+		t.Fatal("TryAdd should always succeed without lock")
+	}
+	if obj, ok := h2.TryGet(0); !ok || obj != 1 {
+		t.Fatalf("TryGet is wrong. obj: %v, ok: %v", obj, ok)
+	}
+}
@@ -0,0 +1,206 @@
+package doublejump
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// Apply applies the operations to the hashes with the given names all or nothing, e.g. to
+// change the read and write rings of a router together. The hashes are created if they do
+// not exist, on behalf of the tenant if the name is tenant + "/" + name of a known tenant,
+// as by TenantRing. All of them stay locked until every operation is applied, in the order
+// of Replay, and each hash moves to the next generation only once, whatever the number of
+// its operations; the events of a hash all carry that generation. Nothing is applied if any
+// operation is invalid, with ErrBadOp or ErrNilNode, if any hash is frozen, or if the
+// operations would take a tenant beyond its quota at any point, with ErrQuotaExceeded.
+func (this *Manager) Apply(changes map[string][]Op) error {
+	names := make([]string, 0, len(changes))
+	for name, ops := range changes {
+		for i, op := range ops {
+			if err := checkOp(op); err != nil {
+				return fmt.Errorf("doublejump: ring %q, op %d: %w", name, i, err)
+			}
+		}
+		names = append(names, name)
+	}
+	// 按名称的顺序加锁，避免并发的事务之间死锁
+	sort.Strings(names)
+
+	hashes, created, err := this.collect(names)
+	if err != nil {
+		return err
+	}
+	if err := commit(names, hashes, changes); err != nil {
+		// 失败时删除为事务创建的哈希
+		for _, name := range created {
+			this.Delete(name)
+		}
+		return err
+	}
+	return nil
+}
+
+// 取出或者创建各个哈希，返回新创建的哈希的名称
+func (this *Manager) collect(names []string) ([]*Hash, []string, error) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+
+	// 先检查租户的哈希数量，再创建
+	var missing []string
+	more := make(map[*usage]int)
+	for _, name := range names {
+		if _, ok := this.rings[name]; ok {
+			continue
+		}
+		missing = append(missing, name)
+		if u := this.tenantOf(name); u != nil {
+			more[u]++
+			if u.quota.MaxRings > 0 && u.rings+more[u] > u.quota.MaxRings {
+				return nil, nil, fmt.Errorf("doublejump: ring %q: %w", name, ErrQuotaExceeded)
+			}
+		}
+	}
+	for _, name := range missing {
+		u := this.tenantOf(name)
+		if u != nil {
+			u.rings++
+		}
+		this.create(name, u)
+	}
+
+	hashes := make([]*Hash, len(names))
+	for i, name := range names {
+		hashes[i] = this.rings[name].hash
+	}
+	return hashes, missing, nil
+}
+
+// 名称为tenant/name并且租户存在时返回租户的用量，调用方持有mu
+func (this *Manager) tenantOf(name string) *usage {
+	tenant, _, ok := strings.Cut(name, "/")
+	if !ok {
+		return nil
+	}
+	return this.tenants[tenant]
+}
+
+func commit(names []string, hashes []*Hash, changes map[string][]Op) error {
+	for i, h := range hashes {
+		h.mu.Lock()
+		h.race.lockWrite()
+		defer h.mu.Unlock()
+		defer h.race.unlockWrite()
+		if h.frozen {
+			return fmt.Errorf("doublejump: ring %q: %w", names[i], ErrFrozen)
+		}
+	}
+
+	// 预先占用执行过程中用量的峰值，执行期间不再逐个节点检查配额
+	peaks, finals, err := simulate(names, hashes, changes)
+	if err != nil {
+		return err
+	}
+	var reserved []*usage
+	for u, peak := range peaks {
+		if !u.adjust(peak) {
+			for _, r := range reserved {
+				r.adjust(-peaks[r])
+			}
+			return ErrQuotaExceeded
+		}
+		reserved = append(reserved, u)
+	}
+
+	for i, h := range hashes {
+		u := h.usage
+		h.usage = nil
+		h.batch = true
+		for _, op := range changes[names[i]] {
+			h.apply(op)
+		}
+		h.batch = false
+		h.usage = u
+	}
+	for u, peak := range peaks {
+		u.adjust(finals[u] - peak)
+	}
+	for _, h := range hashes {
+		if h.dirty {
+			h.dirty = false
+			h.changed()
+		}
+	}
+	return nil
+}
+
+// 按顺序模拟各个操作，返回每个租户节点数量增加的峰值和最终的变化，调用方持有写锁
+func simulate(names []string, hashes []*Hash, changes map[string][]Op) (peaks, finals map[*usage]int, err error) {
+	peaks = make(map[*usage]int)
+	finals = make(map[*usage]int)
+	for i, h := range hashes {
+		u := h.usage
+		if u == nil {
+			continue
+		}
+		max := int(atomic.LoadInt64(&u.max))
+		used := int(atomic.LoadInt64(&u.nodes))
+
+		members := make(map[interface{}]bool, len(h.nodes))
+		for id := range h.nodes {
+			members[id] = true
+		}
+		add := func(obj interface{}) error {
+			id := h.id(obj)
+			if members[id] {
+				return nil
+			}
+			members[id] = true
+			finals[u]++
+			if finals[u] > peaks[u] {
+				peaks[u] = finals[u]
+			}
+			if max > 0 && used+peaks[u] > max {
+				return fmt.Errorf("doublejump: ring %q: %w", names[i], ErrQuotaExceeded)
+			}
+			return nil
+		}
+		remove := func(id interface{}) {
+			if members[id] {
+				delete(members, id)
+				finals[u]--
+			}
+		}
+
+		for _, op := range changes[names[i]] {
+			switch op.Type {
+			case OpAdd:
+				err = add(op.Node)
+			case OpRemove:
+				remove(h.id(op.Node))
+			case OpSet:
+				keep := make(map[interface{}]bool, len(op.Nodes))
+				for _, obj := range op.Nodes {
+					if obj != nil {
+						keep[h.id(obj)] = true
+					}
+				}
+				for id := range members {
+					if !keep[id] {
+						remove(id)
+					}
+				}
+				for _, obj := range op.Nodes {
+					if obj != nil && err == nil {
+						err = add(obj)
+					}
+				}
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return peaks, finals, nil
+}
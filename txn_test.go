@@ -0,0 +1,104 @@
+package doublejump
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestManager_Apply(t *testing.T) {
+	m := NewManager()
+	read := m.Ring("read")
+	read.Add("a")
+	gen := read.Generation()
+
+	var events []Event
+	read.Subscribe(func(ev Event) { events = append(events, ev) })
+
+	err := m.Apply(map[string][]Op{
+		"read":  {{Type: OpAdd, Node: "b"}, {Type: OpAdd, Node: "c"}, {Type: OpRemove, Node: "a"}},
+		"write": {{Type: OpSet, Nodes: []interface{}{"b", "c"}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	write, ok := m.Lookup("write")
+	if !ok || write.Len() != 2 || read.Len() != 2 || read.Contains("a") {
+		t.Fatalf("read: %v, write: %v", read.Nodes(), write)
+	}
+	if read.Generation() != gen+1 || write.Generation() != 1 {
+		t.Fatalf("every ring should move to the next generation once. read: %d, write: %d",
+			read.Generation(), write.Generation())
+	}
+	if len(events) != 3 || events[0].Generation != gen+1 || events[2].Generation != gen+1 {
+		t.Fatalf("unexpected events: %v", events)
+	}
+
+	// 任何一个操作无效时都不执行
+	err = m.Apply(map[string][]Op{
+		"read":  {{Type: OpAdd, Node: "d"}},
+		"write": {{Type: OpAdd, Node: "d"}, {Type: OpAdd}},
+	})
+	if !errors.Is(err, ErrNilNode) || read.Contains("d") || write.Contains("d") {
+		t.Fatalf("nothing should be applied. err: %v", err)
+	}
+
+	write.Freeze()
+	err = m.Apply(map[string][]Op{
+		"read":  {{Type: OpAdd, Node: "d"}},
+		"write": {{Type: OpAdd, Node: "d"}},
+	})
+	if !errors.Is(err, ErrFrozen) || read.Contains("d") {
+		t.Fatalf("nothing should be applied to a frozen ring. err: %v", err)
+	}
+	if err := m.Apply(map[string][]Op{"read": {{Type: OpType(9)}}}); !errors.Is(err, ErrBadOp) {
+		t.Fatalf("err != ErrBadOp. err: %v", err)
+	}
+
+	// 提交之后哈希恢复正常
+	read.Add("e")
+	if read.Generation() != gen+2 {
+		t.Fatal("the ring should work normally after the transaction")
+	}
+}
+
+func TestManager_Apply_Quota(t *testing.T) {
+	m := NewManager()
+	m.SetQuota("t1", Quota{MaxRings: 2, MaxNodes: 3})
+	a, _ := m.TenantRing("t1", "a")
+	a.Add(1)
+
+	// 第二个哈希中的Add超出配额，之前的操作也不执行
+	err := m.Apply(map[string][]Op{
+		"t1/a": {{Type: OpAdd, Node: 2}},
+		"t1/b": {{Type: OpAdd, Node: 3}, {Type: OpAdd, Node: 4}},
+	})
+	if !errors.Is(err, ErrQuotaExceeded) || a.Contains(2) {
+		t.Fatalf("nothing should be applied beyond the quota. err: %v", err)
+	}
+	if _, ok := m.Lookup("t1/b"); ok {
+		t.Fatal("the ring created for the failed transaction should be deleted")
+	}
+	if st := m.Tenants()["t1"]; st.Nodes != 1 || st.Rings != 1 {
+		t.Fatalf("the usage should not change. stats: %+v", st)
+	}
+
+	// 先删除再增加时按峰值检查
+	err = m.Apply(map[string][]Op{
+		"t1/a": {{Type: OpRemove, Node: 1}, {Type: OpAdd, Node: 2}},
+		"t1/b": {{Type: OpAdd, Node: 3}, {Type: OpAdd, Node: 4}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := m.Lookup("t1/b")
+	if a.Len() != 1 || b.Len() != 2 || m.Tenants()["t1"].Nodes != 3 || m.Tenants()["t1"].Rings != 2 {
+		t.Fatalf("unexpected state. stats: %+v", m.Tenants()["t1"])
+	}
+	if a.Add(5) {
+		t.Fatal("the quota should still be enforced after the transaction")
+	}
+
+	if err := m.Apply(map[string][]Op{"t1/c": {{Type: OpAdd, Node: 6}}}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("creating a third ring should exceed MaxRings. err: %v", err)
+	}
+}
@@ -0,0 +1,489 @@
+package doublejump
+
+import (
+	"sync"
+)
+
+// 与looseHolder相同，但直接保存具体类型的节点，live标记位置是否有效
+type typedLoose[T comparable] struct {
+	a          []T
+	live       []bool
+	m          map[T]int
+	emptyPoses []int
+}
+
+func (this *typedLoose[T]) add(obj T) bool {
+	if _, ok := this.m[obj]; ok {
+		return false
+	}
+
+	if nf := len(this.emptyPoses); nf == 0 {
+		this.a = append(this.a, obj)
+		this.live = append(this.live, true)
+		this.m[obj] = len(this.a) - 1
+	} else {
+		idx := this.emptyPoses[nf-1]
+		this.emptyPoses = this.emptyPoses[:nf-1]
+		this.a[idx] = obj
+		this.live[idx] = true
+		this.m[obj] = idx
+	}
+	return true
+}
+
+func (this *typedLoose[T]) remove(obj T) bool {
+	idx, ok := this.m[obj]
+	if ok {
+		var zero T
+		this.emptyPoses = append(this.emptyPoses, idx)
+		this.a[idx] = zero
+		this.live[idx] = false
+		delete(this.m, obj)
+	}
+	return ok
+}
+
+func (this *typedLoose[T]) get(key uint64) (T, bool) {
+	na := len(this.a)
+	if na == 0 {
+		var zero T
+		return zero, false
+	}
+
+	h := JumpHash(key, na)
+	return this.a[h], this.live[h]
+}
+
+func (this *typedLoose[T]) shrink() {
+	var a []T
+	for i, obj := range this.a {
+		if this.live[i] {
+			a = append(a, obj)
+			this.m[obj] = len(a) - 1
+		}
+	}
+	this.a = a
+	this.live = make([]bool, len(a))
+	for i := range this.live {
+		this.live[i] = true
+	}
+	this.emptyPoses = nil
+}
+
+// 与compactHolder相同，但直接保存具体类型的节点
+type typedCompact[T comparable] struct {
+	a []T
+	m map[T]int
+}
+
+func (this *typedCompact[T]) add(obj T) {
+	this.a = append(this.a, obj)
+	this.m[obj] = len(this.a) - 1
+}
+
+func (this *typedCompact[T]) shrink(a []T) {
+	for i, obj := range a {
+		this.a[i] = obj
+		this.m[obj] = i
+	}
+}
+
+func (this *typedCompact[T]) remove(obj T) {
+	if idx, ok := this.m[obj]; ok {
+		var zero T
+		n := len(this.a)
+		this.a[idx] = this.a[n-1]
+		this.m[this.a[idx]] = idx
+		this.a[n-1] = zero
+		this.a = this.a[:n-1]
+		delete(this.m, obj)
+	}
+}
+
+func (this *typedCompact[T]) get(key uint64) (T, bool) {
+	na := len(this.a)
+	if na == 0 {
+		var zero T
+		return zero, false
+	}
+
+	h := JumpHash(key*0xc6a4a7935bd1e995, na)
+	return this.a[h], true
+}
+
+// 具体类型的哈希，与Hash的选择结果完全相同，但没有interface{}的装箱开销
+// 方法不导出，由HashString等包装，在包装中检查空指针
+type typed[T comparable] struct {
+	mu      sync.RWMutex
+	loose   typedLoose[T]
+	compact typedCompact[T]
+	lock    bool
+}
+
+func (this *typed[T]) init(lock bool) {
+	this.lock = lock
+	this.loose.m = make(map[T]int)
+	this.compact.m = make(map[T]int)
+}
+
+func (this *typed[T]) add(obj T) bool {
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+
+	if !this.loose.add(obj) {
+		return false
+	}
+	this.compact.add(obj)
+	return true
+}
+
+func (this *typed[T]) remove(obj T) bool {
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+
+	if !this.loose.remove(obj) {
+		return false
+	}
+	this.compact.remove(obj)
+	return true
+}
+
+func (this *typed[T]) contains(obj T) bool {
+	if this.lock {
+		this.mu.RLock()
+		_, ok := this.compact.m[obj]
+		this.mu.RUnlock()
+		return ok
+	}
+
+	_, ok := this.compact.m[obj]
+	return ok
+}
+
+func (this *typed[T]) len() int {
+	if this.lock {
+		this.mu.RLock()
+		n := len(this.compact.a)
+		this.mu.RUnlock()
+		return n
+	}
+
+	return len(this.compact.a)
+}
+
+func (this *typed[T]) looseLen() int {
+	if this.lock {
+		this.mu.RLock()
+		n := len(this.loose.a)
+		this.mu.RUnlock()
+		return n
+	}
+
+	return len(this.loose.a)
+}
+
+func (this *typed[T]) shrink() {
+	if this.lock {
+		this.mu.Lock()
+		defer this.mu.Unlock()
+	}
+
+	if len(this.loose.emptyPoses) == 0 {
+		return
+	}
+	this.loose.shrink()
+	this.compact.shrink(this.loose.a)
+}
+
+func (this *typed[T]) nodes() []T {
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+
+	a := make([]T, 0, len(this.compact.a))
+	for i, obj := range this.loose.a {
+		if this.loose.live[i] {
+			a = append(a, obj)
+		}
+	}
+	return a
+}
+
+func (this *typed[T]) get(key uint64) (obj T, ok bool) {
+	if this.lock {
+		this.mu.RLock()
+		obj, ok = this.lookup(key)
+		this.mu.RUnlock()
+		return obj, ok
+	}
+
+	return this.lookup(key)
+}
+
+func (this *typed[T]) lookup(key uint64) (T, bool) {
+	if obj, ok := this.loose.get(key); ok {
+		return obj, true
+	}
+	return this.compact.get(key)
+}
+
+// HashString is a doublejump hash whose objects are strings. It stores the strings in
+// native maps and slices, avoiding the interface conversions of Hash, while selecting
+// exactly the same objects as a Hash which applied the same operations.
+type HashString struct {
+	typed[string]
+}
+
+// NewHashString creates a new doublejump hash of strings, which is threadsafe.
+func NewHashString() *HashString {
+	h := &HashString{}
+	h.init(true)
+	return h
+}
+
+// NewHashStringWithoutLock creates a new doublejump hash of strings, which does NOT threadsafe.
+func NewHashStringWithoutLock() *HashString {
+	h := &HashString{}
+	h.init(false)
+	return h
+}
+
+// Add adds an object to the hash. It reports whether the object was newly inserted.
+func (this *HashString) Add(obj string) bool {
+	if this == nil {
+		return false
+	}
+	return this.typed.add(obj)
+}
+
+// Remove removes an object from the hash. It reports whether the object was in the hash.
+func (this *HashString) Remove(obj string) bool {
+	if this == nil {
+		return false
+	}
+	return this.typed.remove(obj)
+}
+
+// Contains reports whether obj is in the hash.
+func (this *HashString) Contains(obj string) bool {
+	if this == nil {
+		return false
+	}
+	return this.typed.contains(obj)
+}
+
+// Len returns the number of objects in the hash.
+func (this *HashString) Len() int {
+	if this == nil {
+		return 0
+	}
+	return this.typed.len()
+}
+
+// LooseLen returns the size of the inner loose object holder.
+func (this *HashString) LooseLen() int {
+	if this == nil {
+		return 0
+	}
+	return this.typed.looseLen()
+}
+
+// Shrink removes all empty slots from the hash.
+func (this *HashString) Shrink() {
+	if this == nil {
+		return
+	}
+	this.typed.shrink()
+}
+
+// Nodes returns all objects in the hash, ordered by their slots in the loose holder.
+func (this *HashString) Nodes() []string {
+	if this == nil {
+		return nil
+	}
+	return this.typed.nodes()
+}
+
+// Get returns an object according to the key provided. ok is false if the hash is empty.
+func (this *HashString) Get(key uint64) (obj string, ok bool) {
+	if this == nil {
+		return obj, false
+	}
+	return this.typed.get(key)
+}
+
+// HashUint64 is a doublejump hash whose objects are uint64 IDs. It stores the IDs in
+// native maps and slices, avoiding the interface conversions of Hash, while selecting
+// exactly the same objects as a Hash which applied the same operations.
+type HashUint64 struct {
+	typed[uint64]
+}
+
+// NewHashUint64 creates a new doublejump hash of uint64 IDs, which is threadsafe.
+func NewHashUint64() *HashUint64 {
+	h := &HashUint64{}
+	h.init(true)
+	return h
+}
+
+// NewHashUint64WithoutLock creates a new doublejump hash of uint64 IDs, which does NOT threadsafe.
+func NewHashUint64WithoutLock() *HashUint64 {
+	h := &HashUint64{}
+	h.init(false)
+	return h
+}
+
+// Add adds an object to the hash. It reports whether the object was newly inserted.
+func (this *HashUint64) Add(obj uint64) bool {
+	if this == nil {
+		return false
+	}
+	return this.typed.add(obj)
+}
+
+// Remove removes an object from the hash. It reports whether the object was in the hash.
+func (this *HashUint64) Remove(obj uint64) bool {
+	if this == nil {
+		return false
+	}
+	return this.typed.remove(obj)
+}
+
+// Contains reports whether obj is in the hash.
+func (this *HashUint64) Contains(obj uint64) bool {
+	if this == nil {
+		return false
+	}
+	return this.typed.contains(obj)
+}
+
+// Len returns the number of objects in the hash.
+func (this *HashUint64) Len() int {
+	if this == nil {
+		return 0
+	}
+	return this.typed.len()
+}
+
+// LooseLen returns the size of the inner loose object holder.
+func (this *HashUint64) LooseLen() int {
+	if this == nil {
+		return 0
+	}
+	return this.typed.looseLen()
+}
+
+// Shrink removes all empty slots from the hash.
+func (this *HashUint64) Shrink() {
+	if this == nil {
+		return
+	}
+	this.typed.shrink()
+}
+
+// Nodes returns all objects in the hash, ordered by their slots in the loose holder.
+func (this *HashUint64) Nodes() []uint64 {
+	if this == nil {
+		return nil
+	}
+	return this.typed.nodes()
+}
+
+// Get returns an object according to the key provided. ok is false if the hash is empty.
+func (this *HashUint64) Get(key uint64) (obj uint64, ok bool) {
+	if this == nil {
+		return obj, false
+	}
+	return this.typed.get(key)
+}
+
+// HashOf is a doublejump hash whose objects are of the comparable type T, e.g. a struct of
+// a host and a port. Like HashString it stores the objects without interface conversions,
+// so Get does not allocate, while selecting exactly the same objects as a Hash which
+// applied the same operations.
+type HashOf[T comparable] struct {
+	typed[T]
+}
+
+// NewHashOf creates a new doublejump hash of T, which is threadsafe.
+func NewHashOf[T comparable]() *HashOf[T] {
+	h := &HashOf[T]{}
+	h.init(true)
+	return h
+}
+
+// NewHashOfWithoutLock creates a new doublejump hash of T, which does NOT threadsafe.
+func NewHashOfWithoutLock[T comparable]() *HashOf[T] {
+	h := &HashOf[T]{}
+	h.init(false)
+	return h
+}
+
+// Add adds an object to the hash. It reports whether the object was newly inserted.
+func (this *HashOf[T]) Add(obj T) bool {
+	if this == nil {
+		return false
+	}
+	return this.typed.add(obj)
+}
+
+// Remove removes an object from the hash. It reports whether the object was in the hash.
+func (this *HashOf[T]) Remove(obj T) bool {
+	if this == nil {
+		return false
+	}
+	return this.typed.remove(obj)
+}
+
+// Contains reports whether obj is in the hash.
+func (this *HashOf[T]) Contains(obj T) bool {
+	if this == nil {
+		return false
+	}
+	return this.typed.contains(obj)
+}
+
+// Len returns the number of objects in the hash.
+func (this *HashOf[T]) Len() int {
+	if this == nil {
+		return 0
+	}
+	return this.typed.len()
+}
+
+// LooseLen returns the size of the inner loose object holder.
+func (this *HashOf[T]) LooseLen() int {
+	if this == nil {
+		return 0
+	}
+	return this.typed.looseLen()
+}
+
+// Shrink removes all empty slots from the hash.
+func (this *HashOf[T]) Shrink() {
+	if this == nil {
+		return
+	}
+	this.typed.shrink()
+}
+
+// Nodes returns all objects in the hash, ordered by their slots in the loose holder.
+func (this *HashOf[T]) Nodes() []T {
+	if this == nil {
+		return nil
+	}
+	return this.typed.nodes()
+}
+
+// Get returns an object according to the key provided. ok is false if the hash is empty.
+func (this *HashOf[T]) Get(key uint64) (obj T, ok bool) {
+	if this == nil {
+		return obj, false
+	}
+	return this.typed.get(key)
+}
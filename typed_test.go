@@ -0,0 +1,162 @@
+package doublejump
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestHashString(t *testing.T) {
+	h1 := NewHash()
+	h2 := NewHashString()
+	h3 := NewHashUint64WithoutLock()
+
+	r := rand.New(rand.NewSource(1))
+	for loop := 0; loop < 2000; loop++ {
+		n := r.Intn(50)
+		switch r.Intn(10) {
+		case 0, 1, 2, 3, 4:
+			a := h1.Add(fmt.Sprint(n))
+			if h2.Add(fmt.Sprint(n)) != a || h3.Add(uint64(n)) != a {
+				t.Fatal("Add should report the same as Hash")
+			}
+		case 5, 6, 7, 8:
+			a := h1.Remove(fmt.Sprint(n))
+			if h2.Remove(fmt.Sprint(n)) != a || h3.Remove(uint64(n)) != a {
+				t.Fatal("Remove should report the same as Hash")
+			}
+		default:
+			h1.Shrink()
+			h2.Shrink()
+			h3.Shrink()
+		}
+
+		if h2.Len() != h1.Len() || h2.LooseLen() != h1.LooseLen() || h3.Len() != h1.Len() {
+			t.Fatalf("the lengths are different. h1: %d, h2: %d, h3: %d", h1.Len(), h2.Len(), h3.Len())
+		}
+		for key := uint64(0); key < 100; key++ {
+			obj, ok := h2.Get(key)
+			if want := h1.Get(key); (want == nil && ok) || (want != nil && want != obj) {
+				t.Fatalf("HashString should select the same node as Hash. key: %d, obj: %s, want: %v", key, obj, want)
+			}
+			id, _ := h3.Get(key)
+			if ok && fmt.Sprint(id) != obj {
+				t.Fatalf("HashUint64 should select the same node as Hash. key: %d, id: %d, obj: %s", key, id, obj)
+			}
+		}
+	}
+
+	if fmt.Sprint(h2.Nodes()) != fmt.Sprint(h1.Nodes()) {
+		t.Fatalf("h2.Nodes() != h1.Nodes(). h2: %v, h1: %v", h2.Nodes(), h1.Nodes())
+	}
+	h2.Add("x")
+	if !h2.Contains("x") || h2.Contains("y") {
+		t.Fatal("something is wrong with Contains")
+	}
+}
+
+func TestHashString_Empty(t *testing.T) {
+	h := NewHashStringWithoutLock()
+	if _, ok := h.Get(0); ok {
+		t.Fatal("Get should return false when the hash has no node at all")
+	}
+	h.Add("")
+	if obj, ok := h.Get(0); !ok || obj != "" {
+		t.Fatal("the empty string should be a valid node")
+	}
+	h.Remove("")
+	if _, ok := h.Get(0); ok {
+		t.Fatal("Get should return false after the only node is removed")
+	}
+
+	h2 := NewHashUint64()
+	h2.Add(0)
+	if id, ok := h2.Get(100); !ok || id != 0 {
+		t.Fatal("0 should be a valid node")
+	}
+}
+
+func TestHashString_Nil(t *testing.T) {
+	var h *HashString
+	if h.Add("a") || h.Remove("a") || h.Contains("a") || h.Len() != 0 || h.LooseLen() != 0 || h.Nodes() != nil {
+		t.Fatal("a nil hash should act as an empty one")
+	}
+	h.Shrink()
+	if _, ok := h.Get(0); ok {
+		t.Fatal("Get should return false on a nil hash")
+	}
+
+	var h2 *HashUint64
+	if h2.Add(1) || h2.Remove(1) || h2.Contains(1) || h2.Len() != 0 || h2.LooseLen() != 0 || h2.Nodes() != nil {
+		t.Fatal("a nil hash should act as an empty one")
+	}
+	h2.Shrink()
+	if _, ok := h2.Get(0); ok {
+		t.Fatal("Get should return false on a nil hash")
+	}
+}
+
+type addr struct {
+	host string
+	port int
+}
+
+func TestHashOf(t *testing.T) {
+	h1 := NewHash()
+	h2 := NewHashOf[addr]()
+	for i := 0; i < 20; i++ {
+		a := addr{host: fmt.Sprintf("10.0.0.%d", i), port: 80}
+		h1.Add(a)
+		h2.Add(a)
+	}
+	h1.Remove(addr{host: "10.0.0.3", port: 80})
+	h2.Remove(addr{host: "10.0.0.3", port: 80})
+	for key := uint64(0); key < 1000; key++ {
+		if obj, ok := h2.Get(key); !ok || obj != h1.Get(key) {
+			t.Fatalf("HashOf should select the same node as Hash. key: %d", key)
+		}
+	}
+}
+
+func TestHashOf_Nil(t *testing.T) {
+	var h *HashOf[addr]
+	a := addr{host: "10.0.0.1", port: 80}
+	if h.Add(a) || h.Remove(a) || h.Contains(a) || h.Len() != 0 || h.LooseLen() != 0 || h.Nodes() != nil {
+		t.Fatal("a nil hash should act as an empty one")
+	}
+	h.Shrink()
+	if obj, ok := h.Get(0); ok || obj != (addr{}) {
+		t.Fatal("Get should return the zero value and false on a nil hash")
+	}
+}
+
+func TestGet_Allocs(t *testing.T) {
+	h1 := NewHash()
+	h2 := NewHashString()
+	h3 := NewHashUint64()
+	h4 := NewHashOf[addr]()
+	for i := 0; i < 100; i++ {
+		h1.Add(fmt.Sprint(i))
+		h2.Add(fmt.Sprint(i))
+		h3.Add(uint64(i))
+		h4.Add(addr{host: fmt.Sprint(i)})
+	}
+	for i := 0; i < 100; i += 3 {
+		h1.Remove(fmt.Sprint(i))
+		h2.Remove(fmt.Sprint(i))
+		h3.Remove(uint64(i))
+		h4.Remove(addr{host: fmt.Sprint(i)})
+	}
+
+	key := uint64(0)
+	for name, get := range map[string]func(){
+		"Hash":       func() { h1.Get(key) },
+		"HashString": func() { h2.Get(key) },
+		"HashUint64": func() { h3.Get(key) },
+		"HashOf":     func() { h4.Get(key) },
+	} {
+		if n := testing.AllocsPerRun(1000, func() { key++; get() }); n != 0 {
+			t.Fatalf("%s.Get should not allocate. allocs: %f", name, n)
+		}
+	}
+}
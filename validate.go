@@ -0,0 +1,85 @@
+package doublejump
+
+import "fmt"
+
+// Validate checks the inner invariants of the hash: the loose and compact holders hold
+// the same slots, the indices kept in the maps match the positions in the slices, the
+// empty positions of the loose holder are really empty, and every slot belongs to a
+// known object. It returns nil if the hash is consistent, or an error describing the
+// first broken invariant. It takes O(n) time and is meant for tests and for checking a
+// hash restored from outside.
+func (this *Hash) Validate() error {
+	if this == nil {
+		return ErrNilHash
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	return this.validate()
+}
+
+// 调用方负责加锁
+func (this *Hash) validate() error {
+	loose, compact := &this.loose, &this.compact
+	if len(loose.a) != len(loose.m)+len(loose.emptyPoses) {
+		return fmt.Errorf("doublejump: loose holder has %d slots, but %d objects and %d empty positions",
+			len(loose.a), len(loose.m), len(loose.emptyPoses))
+	}
+	if len(compact.a) != len(compact.m) {
+		return fmt.Errorf("doublejump: compact holder has %d slots, but %d objects", len(compact.a), len(compact.m))
+	}
+	if len(loose.m) != len(compact.m) {
+		return fmt.Errorf("doublejump: loose holder has %d objects, but compact holder has %d",
+			len(loose.m), len(compact.m))
+	}
+
+	empty := make(map[int]bool, len(loose.emptyPoses))
+	for _, idx := range loose.emptyPoses {
+		if idx < 0 || idx >= len(loose.a) {
+			return fmt.Errorf("doublejump: empty position %d is out of range [0, %d)", idx, len(loose.a))
+		}
+		if empty[idx] {
+			return fmt.Errorf("doublejump: empty position %d is duplicated", idx)
+		}
+		if loose.a[idx] != nil {
+			return fmt.Errorf("doublejump: empty position %d holds %v", idx, loose.a[idx])
+		}
+		empty[idx] = true
+	}
+	for idx := range loose.reserved {
+		if !empty[idx] {
+			return fmt.Errorf("doublejump: reserved position %d is not empty", idx)
+		}
+	}
+
+	for obj, idx := range loose.m {
+		if idx < 0 || idx >= len(loose.a) || loose.a[idx] != obj {
+			return fmt.Errorf("doublejump: loose index of %v is %d, which does not hold it", obj, idx)
+		}
+		if _, ok := compact.m[obj]; !ok {
+			return fmt.Errorf("doublejump: %v is in the loose holder but not in the compact holder", obj)
+		}
+		if _, ok := this.nodes[owner(obj)]; !ok {
+			return fmt.Errorf("doublejump: slot %v belongs to no object", obj)
+		}
+	}
+	for obj, idx := range compact.m {
+		if idx < 0 || idx >= len(compact.a) || compact.a[idx] != obj {
+			return fmt.Errorf("doublejump: compact index of %v is %d, which does not hold it", obj, idx)
+		}
+	}
+
+	slots := 0
+	for _, n := range this.nodes {
+		slots += n.weight
+	}
+	if slots != len(compact.m) {
+		return fmt.Errorf("doublejump: objects own %d slots, but the holders have %d", slots, len(compact.m))
+	}
+	return nil
+}
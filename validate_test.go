@@ -0,0 +1,60 @@
+package doublejump
+
+import "testing"
+
+func TestHash_Validate(t *testing.T) {
+	var h0 *Hash
+	if h0.Validate() != ErrNilHash {
+		t.Fatal("h0.Validate() != ErrNilHash")
+	}
+
+	h := NewHash()
+	if err := h.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		h.Add(i)
+	}
+	h.addNode(Node{Value: "w", Weight: 3})
+	for i := 0; i < 100; i += 3 {
+		h.Remove(i)
+	}
+	if err := h.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	h.Shrink()
+	if err := h.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHash_ValidateBroken(t *testing.T) {
+	build := func() *Hash {
+		h := NewHash()
+		for i := 0; i < 10; i++ {
+			h.Add(i)
+		}
+		h.Remove(5)
+		return h
+	}
+
+	cases := map[string]func(h *Hash){
+		"empty position holds an object": func(h *Hash) { h.loose.a[5] = 100 },
+		"loose index mismatch":           func(h *Hash) { h.loose.m[1] = 2 },
+		"compact index mismatch":         func(h *Hash) { h.compact.m[1], h.compact.m[2] = h.compact.m[2], h.compact.m[1] },
+		"missing from compact": func(h *Hash) {
+			h.compact.remove(1)
+			h.compact.add(100)
+		},
+		"unknown object":    func(h *Hash) { delete(h.nodes, 1) },
+		"duplicated empty":  func(h *Hash) { h.loose.emptyPoses = append(h.loose.emptyPoses, 5); h.loose.a = append(h.loose.a, nil) },
+		"compact too short": func(h *Hash) { h.compact.remove(1) },
+	}
+	for name, f := range cases {
+		h := build()
+		f(h)
+		if h.Validate() == nil {
+			t.Fatalf("Validate should fail: %s", name)
+		}
+	}
+}
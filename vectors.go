@@ -0,0 +1,123 @@
+package doublejump
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// TestVectors describes the layout of a hash together with the objects its core algorithm
+// selects for some keys, so that implementations of the same placement in other languages
+// can be checked against this one. The core algorithm is:
+//
+//	slot = loose[jump(key, len(loose))]
+//	if slot is empty:
+//	    slot = compact[jump(mixer(key), len(compact))]
+//
+// where jump is the jump consistent hash of Lamping and Veach. Pins, hot keys, the result
+// cache and the filters such as WithLoad, Drain and AddCanary are not part of it.
+type TestVectors struct {
+	// Algorithm names the version of the core algorithm.
+	Algorithm string `json:"algorithm"`
+	// Mixer names the key mixer of the compact holder: "default", "splitmix64",
+	// "murmur3", "xxhash", or "custom" for one given by WithMixer.
+	Mixer string `json:"mixer"`
+	// Loose and Compact are the objects of the slots of the inner holders, shown by
+	// fmt.Sprint, with null for empty slots.
+	Loose   []*string `json:"loose"`
+	Compact []string  `json:"compact"`
+	// Vectors are the keys with their selections.
+	Vectors []TestVector `json:"vectors"`
+}
+
+// TestVector is a key with the selection of the core algorithm for it.
+type TestVector struct {
+	// Key is serialized as a string, since JSON numbers of many languages cannot hold
+	// every uint64.
+	Key uint64 `json:"key,string"`
+	// Compact tells whether the key fell into an empty slot of the loose holder, so the
+	// compact holder selected the object.
+	Compact bool `json:"compact,omitempty"`
+	// Index is the index of the selected slot in the holder.
+	Index int `json:"index"`
+	// Node is the selected object, shown by fmt.Sprint.
+	Node string `json:"node"`
+}
+
+// 已知的混合函数的名字
+var mixerNames = map[uintptr]string{
+	reflect.ValueOf(DefaultMixer).Pointer(): "default",
+	reflect.ValueOf(SplitMix64).Pointer():   "splitmix64",
+	reflect.ValueOf(Murmur3Mixer).Pointer(): "murmur3",
+	reflect.ValueOf(XXHashMixer).Pointer():  "xxhash",
+}
+
+// TestVectors returns the layout of the hash and the selections of its core algorithm for
+// the keys. It returns nil for an empty hash.
+func (this *Hash) TestVectors(keys []uint64) *TestVectors {
+	if this == nil {
+		return nil
+	}
+
+	if this.lock {
+		this.mu.RLock()
+		defer this.mu.RUnlock()
+	}
+	this.race.lockRead()
+	defer this.race.unlockRead()
+
+	if len(this.compact.a) == 0 {
+		return nil
+	}
+
+	name := func(slot interface{}) string {
+		return fmt.Sprint(this.value(owner(slot)))
+	}
+	v := &TestVectors{
+		Algorithm: "doublejump/1",
+		Mixer:     "custom",
+		Loose:     make([]*string, len(this.loose.a)),
+		Compact:   make([]string, len(this.compact.a)),
+		Vectors:   make([]TestVector, len(keys)),
+	}
+	if s, ok := mixerNames[reflect.ValueOf(this.Mixer()).Pointer()]; ok {
+		v.Mixer = s
+	}
+	for i, slot := range this.loose.a {
+		if slot != nil {
+			s := name(slot)
+			v.Loose[i] = &s
+		}
+	}
+	for i, slot := range this.compact.a {
+		v.Compact[i] = name(slot)
+	}
+
+	for i, key := range keys {
+		tv := TestVector{Key: key}
+		if slot := this.loose.get(key); slot != nil {
+			tv.Index = this.loose.m[slot]
+			tv.Node = name(slot)
+		} else {
+			slot = this.compact.get(key)
+			tv.Compact = true
+			tv.Index = this.compact.m[slot]
+			tv.Node = name(slot)
+		}
+		v.Vectors[i] = tv
+	}
+	return v
+}
+
+// WriteTestVectors writes the test vectors of the keys as indented JSON, see TestVectors.
+func (this *Hash) WriteTestVectors(w io.Writer, keys []uint64) error {
+	v := this.TestVectors(keys)
+	if v == nil {
+		return ErrEmpty
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
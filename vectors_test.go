@@ -0,0 +1,83 @@
+package doublejump
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestHash_TestVectors(t *testing.T) {
+	h := NewHash()
+	if h.TestVectors([]uint64{1}) != nil {
+		t.Fatal("an empty hash should have no test vectors")
+	}
+	if err := h.WriteTestVectors(&bytes.Buffer{}, nil); err != ErrEmpty {
+		t.Fatal("err != ErrEmpty")
+	}
+
+	for _, s := range []string{"a", "b", "c", "d", "e"} {
+		h.Add(s)
+	}
+	h.Remove("b")
+	h.Remove("d")
+
+	keys := make([]uint64, 1000)
+	for i := range keys {
+		keys[i] = SplitMix64(uint64(i))
+	}
+	v := h.TestVectors(keys)
+	if v.Mixer != "default" {
+		t.Fatalf("unexpected mixer: %s", v.Mixer)
+	}
+	if len(v.Loose) != 5 || v.Loose[1] != nil || v.Loose[3] != nil || *v.Loose[0] != "a" {
+		t.Fatal("unexpected loose layout")
+	}
+	if len(v.Compact) != 3 {
+		t.Fatal("len(v.Compact) != 3")
+	}
+
+	// 按照文档描述的算法重新计算
+	compact := 0
+	for _, tv := range v.Vectors {
+		if tv.Node != h.Get(tv.Key) {
+			t.Fatalf("the vector does not match Get. key: %d", tv.Key)
+		}
+		idx := int(JumpHash(tv.Key, len(v.Loose)))
+		if v.Loose[idx] != nil {
+			if tv.Compact || tv.Index != idx || *v.Loose[idx] != tv.Node {
+				t.Fatalf("unexpected loose vector: %+v", tv)
+			}
+			continue
+		}
+		compact++
+		idx = int(JumpHash(DefaultMixer(tv.Key), len(v.Compact)))
+		if !tv.Compact || tv.Index != idx || v.Compact[idx] != tv.Node {
+			t.Fatalf("unexpected compact vector: %+v", tv)
+		}
+	}
+	if compact == 0 {
+		t.Fatal("some keys should fall into empty slots")
+	}
+
+	var buf bytes.Buffer
+	if err := h.WriteTestVectors(&buf, keys[:1]); err != nil {
+		t.Fatal(err)
+	}
+	var got TestVectors
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Vectors[0] != v.Vectors[0] || !bytes.Contains(buf.Bytes(), []byte(`"key": "`)) {
+		t.Fatal("the keys should be serialized as strings")
+	}
+
+	if NewHashFromNodes(1).TestVectors(nil).Mixer != "default" {
+		t.Fatal("unexpected mixer")
+	}
+	if v := NewHash(WithMixer(Murmur3Mixer)); v.Add(1) && v.TestVectors(nil).Mixer != "murmur3" {
+		t.Fatal("unexpected mixer")
+	}
+	if v := NewHash(WithMixer(func(k uint64) uint64 { return k })); v.Add(1) && v.TestVectors(nil).Mixer != "custom" {
+		t.Fatal("unexpected mixer")
+	}
+}
@@ -0,0 +1,32 @@
+package doublejump
+
+import "time"
+
+// WithTimeBucket makes the selection for a key also depend on the time bucket of length d
+// it is made in, counted from the Unix epoch. Within a bucket keys are as stable as
+// without the option, and at every bucket boundary they are deliberately reshuffled, which
+// suits cache-freshness strategies spreading the keys of a node to other nodes
+// periodically. Get, GetN, Iter and the like mix the key with the bucket number; d <= 0
+// disables the option.
+func WithTimeBucket(d time.Duration) Option {
+	return func(h *Hash) {
+		h.window = d
+	}
+}
+
+// TimeBucket returns the number of the current time bucket, or 0 without WithTimeBucket.
+// Callers can tag cached entries with it to tell the windows they were selected in apart.
+func (this *Hash) TimeBucket() int64 {
+	if this == nil || this.window <= 0 {
+		return 0
+	}
+	return now().UnixNano() / int64(this.window)
+}
+
+// 未设置WithTimeBucket时原样返回KEY，否则与当前时间段的编号混合
+func (this *Hash) windowKey(key uint64) uint64 {
+	if this.window <= 0 {
+		return key
+	}
+	return key ^ SplitMix64(uint64(this.TimeBucket()))
+}
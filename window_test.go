@@ -0,0 +1,64 @@
+package doublejump
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHash_WithTimeBucket(t *testing.T) {
+	cur := time.Unix(3600, 0)
+	now = func() time.Time { return cur }
+	defer func() { now = time.Now }()
+
+	h := NewHash(WithTimeBucket(time.Hour))
+	for i := 0; i < 10; i++ {
+		h.Add(i)
+	}
+	if h.TimeBucket() != 1 {
+		t.Fatal("h.TimeBucket() != 1")
+	}
+
+	first := make([]interface{}, 1000)
+	for i := range first {
+		first[i] = h.Get(uint64(i))
+	}
+
+	// 同一时间段内的选择保持不变
+	cur = cur.Add(59 * time.Minute)
+	for i := range first {
+		if h.Get(uint64(i)) != first[i] {
+			t.Fatal("the selection changed within a bucket")
+		}
+		if a := h.GetN(uint64(i), 2); a[0] != first[i] {
+			t.Fatal("GetN is not consistent with Get")
+		}
+		if obj, _ := h.Iter(uint64(i)).Next(); obj != first[i] {
+			t.Fatal("Iter is not consistent with Get")
+		}
+		if h.GetExcluding(uint64(i)) != first[i] {
+			t.Fatal("GetExcluding is not consistent with Get")
+		}
+		if h.GetWhere(uint64(i), func(obj interface{}) bool { return true }) != first[i] {
+			t.Fatal("GetWhere is not consistent with Get")
+		}
+	}
+
+	cur = cur.Add(time.Minute)
+	if h.TimeBucket() != 2 {
+		t.Fatal("h.TimeBucket() != 2")
+	}
+	moved := 0
+	for i := range first {
+		if h.Get(uint64(i)) != first[i] {
+			moved++
+		}
+	}
+	if moved < 800 {
+		t.Fatalf("keys are not reshuffled. moved: %d", moved)
+	}
+
+	h2 := NewHash()
+	if h2.TimeBucket() != 0 {
+		t.Fatal("h2.TimeBucket() != 0")
+	}
+}